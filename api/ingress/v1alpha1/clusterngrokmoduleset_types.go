@@ -0,0 +1,72 @@
+/*
+MIT License
+
+Copyright (c) 2022 ngrok, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ClusterNgrokModuleSet is a cluster wide set of modules applied to the routes of every Ingress. Unless it is
+// enforced, it is a baseline that the namespace default and Ingress NgrokModuleSets are merged over. Secrets
+// referenced by its modules are read from the namespace of each Ingress.
+type ClusterNgrokModuleSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Modules NgrokModuleSetModules `json:"modules,omitempty"`
+
+	// MergeStrategy is how the modules are merged over the ones of the module sets applied before this one, like
+	// an NgrokModuleSet's
+	MergeStrategy NgrokModuleSetMergeStrategy `json:"mergeStrategy,omitempty"`
+
+	// Enforced module sets are merged over every other module set and module annotation of an Ingress, so
+	// their modules can't be overridden
+	Enforced bool `json:"enforced,omitempty"`
+}
+
+// NgrokModuleSet returns an NgrokModuleSet with the modules and merge strategy of cms, to merge it with
+// NgrokModuleSet.Merge
+func (cms *ClusterNgrokModuleSet) NgrokModuleSet() *NgrokModuleSet {
+	return &NgrokModuleSet{
+		Modules:       cms.Modules,
+		MergeStrategy: cms.MergeStrategy,
+	}
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterNgrokModuleSetList contains a list of ClusterNgrokModuleSet
+type ClusterNgrokModuleSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterNgrokModuleSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterNgrokModuleSet{}, &ClusterNgrokModuleSetList{})
+}
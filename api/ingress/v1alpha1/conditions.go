@@ -0,0 +1,48 @@
+/*
+MIT License
+
+Copyright (c) 2022 ngrok, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package v1alpha1
+
+// Condition types reported on the status of the ngrok CRDs
+const (
+	// ConditionReady is true when the object is programmed in ngrok and the last reconcile succeeded
+	ConditionReady = "Ready"
+
+	// ConditionProgrammed is true once the object exists in ngrok
+	ConditionProgrammed = "Programmed"
+
+	// ConditionDegraded is true when the last reconcile of the object failed. The object may still exist in
+	// ngrok, but it doesn't reflect the current generation of the spec.
+	ConditionDegraded = "Degraded"
+)
+
+// Condition reasons reported on the status of the ngrok CRDs. When a reconcile fails with an ngrok API error,
+// the reason is the ngrok error code instead, such as ERR_NGROK_446.
+const (
+	ReasonReady          = "Ready"
+	ReasonProgrammed     = "Programmed"
+	ReasonPending        = "Pending"
+	ReasonReconciled     = "Reconciled"
+	ReasonReconcileError = "ReconcileError"
+)
@@ -50,6 +50,11 @@ type HTTPSEdgeRouteSpec struct {
 	// +kubebuilder:validation:Required
 	Backend TunnelGroupBackend `json:"backend,omitempty"`
 
+	// WeightedBackends splits the traffic for this route between tunnel group
+	// backends by weight. When set, it is used instead of Backend.
+	// +kubebuilder:validation:Optional
+	WeightedBackends []WeightedTunnelGroupBackend `json:"weightedBackends,omitempty"`
+
 	// CircuitBreaker is a circuit breaker configuration to apply to this route
 	CircuitBreaker *EndpointCircuitBreaker `json:"circuitBreaker,omitempty"`
 
@@ -75,6 +80,13 @@ type HTTPSEdgeRouteSpec struct {
 	WebhookVerification *EndpointWebhookVerification `json:"webhookVerification,omitempty"`
 
 	Policy *EndpointPolicy `json:"policy,omitempty"`
+
+	// PolicyConfigMapRef is a reference to a ConfigMap key holding the traffic policy to apply to this route,
+	// as YAML or JSON. It is only used when Policy is not set.
+	PolicyConfigMapRef *ConfigMapKeyRef `json:"policyConfigMap,omitempty"`
+
+	// WebsocketTCPConverter converts websocket connections to this route into TCP connections to the backend
+	WebsocketTCPConverter *EndpointWebsocketTCPConverter `json:"websocketTCPConverter,omitempty"`
 }
 
 // HTTPSEdgeSpec defines the desired state of HTTPSEdge
@@ -90,6 +102,10 @@ type HTTPSEdgeSpec struct {
 
 	// TLSTermination is the TLS termination configuration for this edge
 	TLSTermination *EndpointTLSTerminationAtEdge `json:"tlsTermination,omitempty"`
+
+	// MutualTLS is the mutual TLS configuration for this edge, requiring clients to present a
+	// certificate signed by one of its certificate authorities
+	MutualTLS *EndpointMutualTLS `json:"mutualTls,omitempty"`
 }
 
 type HTTPSEdgeRouteStatus struct {
@@ -117,10 +133,20 @@ type HTTPSEdgeStatus struct {
 	URI string `json:"uri,omitempty"`
 
 	Routes []HTTPSEdgeRouteStatus `json:"routes,omitempty"`
+
+	// Conditions describe the state of the object in ngrok. Ready summarizes Programmed and Degraded.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="ID",type=string,JSONPath=`.status.id`,description="HTTPSEdge ID"
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Ready"
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,description="Reason the object is not ready"
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="Age"
 
 // HTTPSEdge is the Schema for the httpsedges API
 type HTTPSEdge struct {
@@ -6,6 +6,7 @@ import (
 	"github.com/ngrok/ngrok-api-go/v5"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 // common ngrok API/Dashboard fields
@@ -25,6 +26,12 @@ type EndpointCompression struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+type EndpointWebsocketTCPConverter struct {
+	// Enabled is whether or not to convert websocket connections to TCP connections to the
+	// upstream service
+	Enabled bool `json:"enabled,omitempty"`
+}
+
 type EndpointIPPolicy struct {
 	IPPolicies []string `json:"policies,omitempty"`
 }
@@ -62,6 +69,10 @@ type EndpointMutualTLS struct {
 	// List of CA IDs that will be used to validate incoming connections to the
 	// edge.
 	CertificateAuthorities []string `json:"certificateAuthorities,omitempty"`
+	// List of Secret keys holding PEM encoded CA certificates that will be used to
+	// validate incoming connections to the edge. An ngrok Certificate Authority is
+	// created for each of them, and deleted once no edge references it anymore.
+	CertificateAuthoritySecrets []SecretKeyRef `json:"certificateAuthoritySecrets,omitempty"`
 }
 
 type EndpointTLSTermination struct {
@@ -86,6 +97,13 @@ type SecretKeyRef struct {
 	Key string `json:"key,omitempty"`
 }
 
+type ConfigMapKeyRef struct {
+	// Name of the Kubernetes config map
+	Name string `json:"name,omitempty"`
+	// Key in the config map to use
+	Key string `json:"key,omitempty"`
+}
+
 type EndpointWebhookVerification struct {
 	// a string indicating which webhook provider will be sending webhooks to this
 	// endpoint. Value must be one of the supported providers defined at
@@ -457,6 +475,16 @@ type EndpointAction struct {
 	Config json.RawMessage `json:"config,omitempty"`
 }
 
+// ParseEndpointPolicy decodes a traffic policy written as YAML or JSON. Unknown fields are rejected so typos
+// don't silently drop rules.
+func ParseEndpointPolicy(data []byte) (*EndpointPolicy, error) {
+	policy := &EndpointPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
 func (policy *EndpointPolicy) ToNgrok() *ngrok.EndpointPolicy {
 	if policy == nil {
 		return nil
@@ -25,6 +25,8 @@ SOFTWARE.
 package v1alpha1
 
 import (
+	"slices"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,6 +39,8 @@ type NgrokModuleSetModules struct {
 	Headers *EndpointHeaders `json:"headers,omitempty"`
 	// IPRestriction configuration for this module set
 	IPRestriction *EndpointIPPolicy `json:"ipRestriction,omitempty"`
+	// MutualTLS configuration for this module set. It applies to the whole edge of each host.
+	MutualTLS *EndpointMutualTLS `json:"mutualTls,omitempty"`
 	// OAuth configuration for this module set
 	OAuth *EndpointOAuth `json:"oauth,omitempty"`
 	// Policy configuration for this module set
@@ -49,6 +53,8 @@ type NgrokModuleSetModules struct {
 	TLSTermination *EndpointTLSTerminationAtEdge `json:"tlsTermination,omitempty"`
 	// WebhookVerification configuration for this module set
 	WebhookVerification *EndpointWebhookVerification `json:"webhookVerification,omitempty"`
+	// WebsocketTCPConverter configuration for this module set
+	WebsocketTCPConverter *EndpointWebsocketTCPConverter `json:"websocketTCPConverter,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -60,8 +66,32 @@ type NgrokModuleSet struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Modules NgrokModuleSetModules `json:"modules,omitempty"`
+
+	// MergeStrategy is how the modules are merged over the ones of the module sets applied before this one.
+	// "replace", the default, replaces each module as a whole. "merge" combines the headers modules, the IP
+	// restriction modules and the policy modules, while the other modules are still replaced.
+	MergeStrategy NgrokModuleSetMergeStrategy `json:"mergeStrategy,omitempty"`
 }
 
+// NgrokModuleSetMergeStrategy is how the modules of a module set are merged over the ones before it
+// +kubebuilder:validation:Enum=replace;merge
+type NgrokModuleSetMergeStrategy string
+
+const (
+	// MergeStrategyReplace replaces each module as a whole
+	MergeStrategyReplace NgrokModuleSetMergeStrategy = "replace"
+	// MergeStrategyMerge combines the headers, IP restriction and policy modules field by field
+	MergeStrategyMerge NgrokModuleSetMergeStrategy = "merge"
+)
+
+// Merge merges the modules of o into ms. The modules o doesn't configure are kept, so a later module set can
+// override a module but never remove it. Each module configured in o replaces the same module in ms as a whole,
+// unless o's MergeStrategy is MergeStrategyMerge, in which case:
+//   - the headers to add are combined, with o's value winning for a header added by both
+//   - the headers to remove and the IP policies are unioned, keeping their order
+//   - o's inbound and outbound policy rules are appended after ms's, and o's enabled flag wins when it is set
+//
+// ms's modules are copied before being combined, so they can be shared with the module sets they came from.
 func (ms *NgrokModuleSet) Merge(o *NgrokModuleSet) {
 	if o == nil {
 		return
@@ -69,6 +99,7 @@ func (ms *NgrokModuleSet) Merge(o *NgrokModuleSet) {
 
 	msmod := &ms.Modules
 	omod := o.Modules
+	combine := o.MergeStrategy == MergeStrategyMerge
 
 	if omod.CircuitBreaker != nil {
 		msmod.CircuitBreaker = omod.CircuitBreaker
@@ -77,16 +108,31 @@ func (ms *NgrokModuleSet) Merge(o *NgrokModuleSet) {
 		msmod.Compression = omod.Compression
 	}
 	if omod.Headers != nil {
-		msmod.Headers = omod.Headers
+		if combine {
+			msmod.Headers = mergeHeaders(msmod.Headers, omod.Headers)
+		} else {
+			msmod.Headers = omod.Headers
+		}
 	}
 	if omod.IPRestriction != nil {
-		msmod.IPRestriction = omod.IPRestriction
+		if combine {
+			msmod.IPRestriction = mergeIPRestriction(msmod.IPRestriction, omod.IPRestriction)
+		} else {
+			msmod.IPRestriction = omod.IPRestriction
+		}
+	}
+	if omod.MutualTLS != nil {
+		msmod.MutualTLS = omod.MutualTLS
 	}
 	if omod.OAuth != nil {
 		msmod.OAuth = omod.OAuth
 	}
 	if omod.Policy != nil {
-		msmod.Policy = omod.Policy
+		if combine {
+			msmod.Policy = mergePolicy(msmod.Policy, omod.Policy)
+		} else {
+			msmod.Policy = omod.Policy
+		}
 	}
 	if omod.OIDC != nil {
 		msmod.OIDC = omod.OIDC
@@ -100,6 +146,121 @@ func (ms *NgrokModuleSet) Merge(o *NgrokModuleSet) {
 	if omod.WebhookVerification != nil {
 		msmod.WebhookVerification = omod.WebhookVerification
 	}
+	if omod.WebsocketTCPConverter != nil {
+		msmod.WebsocketTCPConverter = omod.WebsocketTCPConverter
+	}
+}
+
+// MergeEnforced merges the modules of an enforced module set o into ms. It's the same as Merge, except that
+// when o's MergeStrategy is MergeStrategyMerge, ms can't weaken the modules of o it's combined with:
+//   - o's inbound and outbound policy rules are prepended to ms's, so a rule of ms with a terminal action can't
+//     skip them, and o's enabled flag wins when it is set
+//   - o's IP policies replace ms's rather than being unioned with them, which would allow more addresses
+func (ms *NgrokModuleSet) MergeEnforced(o *NgrokModuleSet) {
+	if o == nil {
+		return
+	}
+
+	policy := ms.Modules.Policy
+	ms.Merge(o)
+	if o.MergeStrategy != MergeStrategyMerge {
+		return
+	}
+
+	if o.Modules.IPRestriction != nil {
+		ms.Modules.IPRestriction = o.Modules.IPRestriction
+	}
+	if o.Modules.Policy != nil {
+		ms.Modules.Policy = prependPolicy(policy, o.Modules.Policy)
+	}
+}
+
+// mergeHeaders returns a copy of a with the headers of b added and removed
+func mergeHeaders(a, b *EndpointHeaders) *EndpointHeaders {
+	if a == nil {
+		return b
+	}
+
+	merged := a.DeepCopy()
+	if b.Request != nil {
+		if merged.Request == nil {
+			merged.Request = &EndpointRequestHeaders{}
+		}
+		merged.Request.Add = mergeStringMaps(merged.Request.Add, b.Request.Add)
+		merged.Request.Remove = unionStrings(merged.Request.Remove, b.Request.Remove)
+	}
+	if b.Response != nil {
+		if merged.Response == nil {
+			merged.Response = &EndpointResponseHeaders{}
+		}
+		merged.Response.Add = mergeStringMaps(merged.Response.Add, b.Response.Add)
+		merged.Response.Remove = unionStrings(merged.Response.Remove, b.Response.Remove)
+	}
+	return merged
+}
+
+// mergeIPRestriction returns a copy of a with the IP policies of b added
+func mergeIPRestriction(a, b *EndpointIPPolicy) *EndpointIPPolicy {
+	if a == nil {
+		return b
+	}
+
+	return &EndpointIPPolicy{
+		IPPolicies: unionStrings(append([]string{}, a.IPPolicies...), b.IPPolicies),
+	}
+}
+
+// mergePolicy returns a copy of a with the rules of b appended
+func mergePolicy(a, b *EndpointPolicy) *EndpointPolicy {
+	if a == nil {
+		return b
+	}
+
+	merged := a.DeepCopy()
+	b = b.DeepCopy()
+	if b.Enabled != nil {
+		merged.Enabled = b.Enabled
+	}
+	merged.Inbound = append(merged.Inbound, b.Inbound...)
+	merged.Outbound = append(merged.Outbound, b.Outbound...)
+	return merged
+}
+
+// prependPolicy returns a copy of a with the rules of b prepended
+func prependPolicy(a, b *EndpointPolicy) *EndpointPolicy {
+	if a == nil {
+		return b
+	}
+
+	merged := b.DeepCopy()
+	a = a.DeepCopy()
+	if merged.Enabled == nil {
+		merged.Enabled = a.Enabled
+	}
+	merged.Inbound = append(merged.Inbound, a.Inbound...)
+	merged.Outbound = append(merged.Outbound, a.Outbound...)
+	return merged
+}
+
+// mergeStringMaps adds the entries of b to a, which it may modify
+func mergeStringMaps(a, b map[string]string) map[string]string {
+	if a == nil && len(b) > 0 {
+		a = make(map[string]string, len(b))
+	}
+	for k, v := range b {
+		a[k] = v
+	}
+	return a
+}
+
+// unionStrings appends the values of b that aren't in a to a, which it may modify
+func unionStrings(a, b []string) []string {
+	for _, v := range b {
+		if !slices.Contains(a, v) {
+			a = append(a, v)
+		}
+	}
+	return a
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,158 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/ptr"
+)
+
+func TestNgrokModuleSetMerge(t *testing.T) {
+	base := func() *NgrokModuleSet {
+		return &NgrokModuleSet{
+			Modules: NgrokModuleSetModules{
+				Compression: &EndpointCompression{Enabled: true},
+				Headers: &EndpointHeaders{
+					Request: &EndpointRequestHeaders{
+						Add:    map[string]string{"X-Team": "platform", "X-Env": "prod"},
+						Remove: []string{"X-Debug"},
+					},
+				},
+				IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"office", "vpn"}},
+				Policy: &EndpointPolicy{
+					Inbound: []EndpointRule{{Name: "baseline"}},
+				},
+			},
+		}
+	}
+	app := NgrokModuleSetModules{
+		Compression: &EndpointCompression{Enabled: false},
+		Headers: &EndpointHeaders{
+			Request: &EndpointRequestHeaders{
+				Add:    map[string]string{"X-Env": "staging"},
+				Remove: []string{"X-Debug", "X-Internal"},
+			},
+			Response: &EndpointResponseHeaders{
+				Add: map[string]string{"X-Served-By": "ngrok"},
+			},
+		},
+		IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"vpn", "partner"}},
+		Policy: &EndpointPolicy{
+			Enabled:  ptr.To(true),
+			Inbound:  []EndpointRule{{Name: "app"}},
+			Outbound: []EndpointRule{{Name: "app-outbound"}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		strategy NgrokModuleSetMergeStrategy
+		expected NgrokModuleSetModules
+	}{
+		{
+			name:     "default replaces modules",
+			expected: app,
+		},
+		{
+			name:     "replace replaces modules",
+			strategy: MergeStrategyReplace,
+			expected: app,
+		},
+		{
+			name:     "merge combines modules",
+			strategy: MergeStrategyMerge,
+			expected: NgrokModuleSetModules{
+				Compression: &EndpointCompression{Enabled: false},
+				Headers: &EndpointHeaders{
+					Request: &EndpointRequestHeaders{
+						Add:    map[string]string{"X-Team": "platform", "X-Env": "staging"},
+						Remove: []string{"X-Debug", "X-Internal"},
+					},
+					Response: &EndpointResponseHeaders{
+						Add: map[string]string{"X-Served-By": "ngrok"},
+					},
+				},
+				IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"office", "vpn", "partner"}},
+				Policy: &EndpointPolicy{
+					Enabled:  ptr.To(true),
+					Inbound:  []EndpointRule{{Name: "baseline"}, {Name: "app"}},
+					Outbound: []EndpointRule{{Name: "app-outbound"}},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ms := base()
+			original := ms.DeepCopy()
+			shared := &NgrokModuleSet{Modules: ms.Modules}
+
+			ms.Merge(&NgrokModuleSet{Modules: app, MergeStrategy: c.strategy})
+			if !reflect.DeepEqual(ms.Modules, c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, ms.Modules)
+			}
+			// The modules merged into must not be modified, they can be shared with other module sets
+			if !reflect.DeepEqual(shared.Modules, original.Modules) {
+				t.Errorf("merging modified the original modules: %+v", shared.Modules)
+			}
+		})
+	}
+}
+
+func TestNgrokModuleSetMergeIntoEmpty(t *testing.T) {
+	ms := &NgrokModuleSet{}
+	o := &NgrokModuleSet{
+		Modules: NgrokModuleSetModules{
+			Headers:       &EndpointHeaders{Response: &EndpointResponseHeaders{Remove: []string{"Server"}}},
+			IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"office"}},
+			Policy:        &EndpointPolicy{Inbound: []EndpointRule{{Name: "app"}}},
+		},
+		MergeStrategy: MergeStrategyMerge,
+	}
+
+	ms.Merge(o)
+	if !reflect.DeepEqual(ms.Modules, o.Modules) {
+		t.Errorf("expected %+v, got %+v", o.Modules, ms.Modules)
+	}
+}
+
+func TestNgrokModuleSetMergeEnforced(t *testing.T) {
+	ms := &NgrokModuleSet{
+		Modules: NgrokModuleSetModules{
+			IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"public"}},
+			Policy: &EndpointPolicy{
+				Enabled: ptr.To(true),
+				Inbound: []EndpointRule{{Name: "app"}},
+			},
+		},
+	}
+	original := ms.DeepCopy()
+	shared := &NgrokModuleSet{Modules: ms.Modules}
+
+	ms.MergeEnforced(&NgrokModuleSet{
+		Modules: NgrokModuleSetModules{
+			IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"office"}},
+			Policy: &EndpointPolicy{
+				Inbound:  []EndpointRule{{Name: "audit"}},
+				Outbound: []EndpointRule{{Name: "audit-outbound"}},
+			},
+		},
+		MergeStrategy: MergeStrategyMerge,
+	})
+
+	expected := NgrokModuleSetModules{
+		IPRestriction: &EndpointIPPolicy{IPPolicies: []string{"office"}},
+		Policy: &EndpointPolicy{
+			Enabled:  ptr.To(true),
+			Inbound:  []EndpointRule{{Name: "audit"}, {Name: "app"}},
+			Outbound: []EndpointRule{{Name: "audit-outbound"}},
+		},
+	}
+	if !reflect.DeepEqual(ms.Modules, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ms.Modules)
+	}
+	if !reflect.DeepEqual(shared.Modules, original.Modules) {
+		t.Errorf("merging modified the original modules: %+v", shared.Modules)
+	}
+}
@@ -51,15 +51,95 @@ type TunnelSpec struct {
 }
 
 // BackendConfig defines the configuration for backend connections to services.
-// This can be extended to include ServerName, InsecureSkipVerify, etc. down the road.
 type BackendConfig struct {
 	Protocol string `json:"protocol,omitempty"`
+
+	// TLS configures connections to HTTPS backends
+	// +optional
+	TLS *BackendTLSConfig `json:"tls,omitempty"`
+
+	// ProxyProtocolVersion enables sending a PROXY protocol header with the client's address to the backend
+	// at the start of each connection. Either 1 or 2, leave unset to disable.
+	// +kubebuilder:validation:Enum=1;2
+	// +optional
+	ProxyProtocolVersion int `json:"proxyProtocolVersion,omitempty"`
+}
+
+// BackendTLSConfig configures how the tunnel connects to an HTTPS backend. Without it, the backend's
+// certificate is not verified.
+type BackendTLSConfig struct {
+	// Verify enables verification of the backend's certificate against CACertificates, or the system
+	// roots if no CA bundle is set. Defaults to true when CACertificates or ServerName is set, and to
+	// false otherwise.
+	// +optional
+	Verify *bool `json:"verify,omitempty"`
+
+	// ServerName overrides the name sent with SNI and used to verify the backend's certificate. Defaults
+	// to the host of forwardsTo.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+
+	// CACertificates is a PEM bundle of the CAs used to verify the backend's certificate
+	// +optional
+	CACertificates *BackendCABundle `json:"caCertificates,omitempty"`
+
+	// ClientCertificateSecret is the name of a kubernetes.io/tls Secret in the Tunnel's namespace with the
+	// client certificate to present to the backend
+	// +optional
+	ClientCertificateSecret string `json:"clientCertificateSecret,omitempty"`
+}
+
+// BackendCABundle references a PEM bundle of CA certificates in the Tunnel's namespace. Exactly one of
+// Secret or ConfigMap should be set.
+type BackendCABundle struct {
+	// Secret containing the CA bundle
+	// +optional
+	Secret *SecretKeyRef `json:"secret,omitempty"`
+
+	// ConfigMap containing the CA bundle
+	// +optional
+	ConfigMap *ConfigMapKeyRef `json:"configMap,omitempty"`
 }
 
 // TunnelStatus defines the observed state of Tunnel
 type TunnelStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Agents reports the state of the tunnel on each controller pod. Every pod runs its own copy of the
+	// tunnel, so each one reports and refreshes its own entry.
+	// +listType=map
+	// +listMapKey=podName
+	// +optional
+	Agents []TunnelAgentStatus `json:"agents,omitempty"`
+}
+
+// TunnelAgentStatus is the state of a tunnel on a single controller pod
+type TunnelAgentStatus struct {
+	// PodName is the name of the controller pod running the tunnel
+	PodName string `json:"podName"`
+
+	// TunnelID is the ngrok ID of the tunnel
+	TunnelID string `json:"tunnelID,omitempty"`
+
+	// SessionID is the ngrok ID of the agent session the tunnel is running on
+	SessionID string `json:"sessionID,omitempty"`
+
+	// Online is whether the tunnel is accepting connections on this pod. It's false while the pod's session
+	// is disconnected from ngrok or the tunnel is being restarted.
+	Online bool `json:"online"`
+
+	// ForwardsToAddr is the address forwardsTo resolved to on the last successful connection to the backend
+	ForwardsToAddr string `json:"forwardsToAddr,omitempty"`
+
+	// LastDialError is the last error encountered connecting to the backend
+	// +optional
+	LastDialError string `json:"lastDialError,omitempty"`
+
+	// LastDialErrorTime is when LastDialError happened
+	// +optional
+	LastDialErrorTime *metav1.Time `json:"lastDialErrorTime,omitempty"`
+
+	// LastUpdateTime is when this entry was last refreshed. Entries that haven't been refreshed in a while
+	// belong to pods that have gone away and are removed by the remaining pods.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 }
 
 //+kubebuilder:object:root=true
@@ -92,6 +172,18 @@ type TunnelGroupBackend struct {
 	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// WeightedTunnelGroupBackend is a tunnel group backend that receives a share of the traffic
+// proportional to its weight
+type WeightedTunnelGroupBackend struct {
+	// Labels to watch for tunnels on this backend
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Weight of this backend relative to the other backends
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10000
+	Weight int64 `json:"weight"`
+}
+
 type TunnelGroupBackendStatus struct {
 	// ID is the unique identifier for this backend
 	ID string `json:"id,omitempty"`
@@ -31,12 +31,43 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendCABundle) DeepCopyInto(out *BackendCABundle) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendCABundle.
+func (in *BackendCABundle) DeepCopy() *BackendCABundle {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendCABundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendConfig) DeepCopyInto(out *BackendConfig) {
 	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(BackendTLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendConfig.
@@ -49,6 +80,104 @@ func (in *BackendConfig) DeepCopy() *BackendConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendTLSConfig) DeepCopyInto(out *BackendTLSConfig) {
+	*out = *in
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CACertificates != nil {
+		in, out := &in.CACertificates, &out.CACertificates
+		*out = new(BackendCABundle)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendTLSConfig.
+func (in *BackendTLSConfig) DeepCopy() *BackendTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNgrokModuleSet) DeepCopyInto(out *ClusterNgrokModuleSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Modules.DeepCopyInto(&out.Modules)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNgrokModuleSet.
+func (in *ClusterNgrokModuleSet) DeepCopy() *ClusterNgrokModuleSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNgrokModuleSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterNgrokModuleSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNgrokModuleSetList) DeepCopyInto(out *ClusterNgrokModuleSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterNgrokModuleSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNgrokModuleSetList.
+func (in *ClusterNgrokModuleSetList) DeepCopy() *ClusterNgrokModuleSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNgrokModuleSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterNgrokModuleSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyRef) DeepCopyInto(out *ConfigMapKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyRef.
+func (in *ConfigMapKeyRef) DeepCopy() *ConfigMapKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Domain) DeepCopyInto(out *Domain) {
 	*out = *in
@@ -132,6 +261,13 @@ func (in *DomainStatus) DeepCopyInto(out *DomainStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainStatus.
@@ -250,6 +386,11 @@ func (in *EndpointMutualTLS) DeepCopyInto(out *EndpointMutualTLS) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CertificateAuthoritySecrets != nil {
+		in, out := &in.CertificateAuthoritySecrets, &out.CertificateAuthoritySecrets
+		*out = make([]SecretKeyRef, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointMutualTLS.
@@ -675,6 +816,21 @@ func (in *EndpointWebhookVerification) DeepCopy() *EndpointWebhookVerification {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointWebsocketTCPConverter) DeepCopyInto(out *EndpointWebsocketTCPConverter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointWebsocketTCPConverter.
+func (in *EndpointWebsocketTCPConverter) DeepCopy() *EndpointWebsocketTCPConverter {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointWebsocketTCPConverter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPSEdge) DeepCopyInto(out *HTTPSEdge) {
 	*out = *in
@@ -739,6 +895,13 @@ func (in *HTTPSEdgeRouteSpec) DeepCopyInto(out *HTTPSEdgeRouteSpec) {
 	*out = *in
 	out.ngrokAPICommon = in.ngrokAPICommon
 	in.Backend.DeepCopyInto(&out.Backend)
+	if in.WeightedBackends != nil {
+		in, out := &in.WeightedBackends, &out.WeightedBackends
+		*out = make([]WeightedTunnelGroupBackend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.CircuitBreaker != nil {
 		in, out := &in.CircuitBreaker, &out.CircuitBreaker
 		*out = new(EndpointCircuitBreaker)
@@ -784,6 +947,16 @@ func (in *HTTPSEdgeRouteSpec) DeepCopyInto(out *HTTPSEdgeRouteSpec) {
 		*out = new(EndpointPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PolicyConfigMapRef != nil {
+		in, out := &in.PolicyConfigMapRef, &out.PolicyConfigMapRef
+		*out = new(ConfigMapKeyRef)
+		**out = **in
+	}
+	if in.WebsocketTCPConverter != nil {
+		in, out := &in.WebsocketTCPConverter, &out.WebsocketTCPConverter
+		*out = new(EndpointWebsocketTCPConverter)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSEdgeRouteSpec.
@@ -833,6 +1006,11 @@ func (in *HTTPSEdgeSpec) DeepCopyInto(out *HTTPSEdgeSpec) {
 		*out = new(EndpointTLSTerminationAtEdge)
 		**out = **in
 	}
+	if in.MutualTLS != nil {
+		in, out := &in.MutualTLS, &out.MutualTLS
+		*out = new(EndpointMutualTLS)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSEdgeSpec.
@@ -853,6 +1031,13 @@ func (in *HTTPSEdgeStatus) DeepCopyInto(out *HTTPSEdgeStatus) {
 		*out = make([]HTTPSEdgeRouteStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSEdgeStatus.
@@ -984,6 +1169,13 @@ func (in *IPPolicyStatus) DeepCopyInto(out *IPPolicyStatus) {
 		*out = make([]IPPolicyRuleStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPPolicyStatus.
@@ -1077,6 +1269,11 @@ func (in *NgrokModuleSetModules) DeepCopyInto(out *NgrokModuleSetModules) {
 		*out = new(EndpointIPPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MutualTLS != nil {
+		in, out := &in.MutualTLS, &out.MutualTLS
+		*out = new(EndpointMutualTLS)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.OAuth != nil {
 		in, out := &in.OAuth, &out.OAuth
 		*out = new(EndpointOAuth)
@@ -1107,6 +1304,11 @@ func (in *NgrokModuleSetModules) DeepCopyInto(out *NgrokModuleSetModules) {
 		*out = new(EndpointWebhookVerification)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.WebsocketTCPConverter != nil {
+		in, out := &in.WebsocketTCPConverter, &out.WebsocketTCPConverter
+		*out = new(EndpointWebsocketTCPConverter)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NgrokModuleSetModules.
@@ -1272,6 +1474,13 @@ func (in *TCPEdgeStatus) DeepCopyInto(out *TCPEdgeStatus) {
 		copy(*out, *in)
 	}
 	out.Backend = in.Backend
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPEdgeStatus.
@@ -1394,6 +1603,13 @@ func (in *TLSEdgeStatus) DeepCopyInto(out *TLSEdgeStatus) {
 		copy(*out, *in)
 	}
 	out.Backend = in.Backend
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSEdgeStatus.
@@ -1412,7 +1628,7 @@ func (in *Tunnel) DeepCopyInto(out *Tunnel) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tunnel.
@@ -1433,6 +1649,26 @@ func (in *Tunnel) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelAgentStatus) DeepCopyInto(out *TunnelAgentStatus) {
+	*out = *in
+	if in.LastDialErrorTime != nil {
+		in, out := &in.LastDialErrorTime, &out.LastDialErrorTime
+		*out = (*in).DeepCopy()
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelAgentStatus.
+func (in *TunnelAgentStatus) DeepCopy() *TunnelAgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelAgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TunnelGroupBackend) DeepCopyInto(out *TunnelGroupBackend) {
 	*out = *in
@@ -1516,7 +1752,7 @@ func (in *TunnelSpec) DeepCopyInto(out *TunnelSpec) {
 	if in.BackendConfig != nil {
 		in, out := &in.BackendConfig, &out.BackendConfig
 		*out = new(BackendConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -1533,6 +1769,13 @@ func (in *TunnelSpec) DeepCopy() *TunnelSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TunnelStatus) DeepCopyInto(out *TunnelStatus) {
 	*out = *in
+	if in.Agents != nil {
+		in, out := &in.Agents, &out.Agents
+		*out = make([]TunnelAgentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelStatus.
@@ -1544,3 +1787,25 @@ func (in *TunnelStatus) DeepCopy() *TunnelStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedTunnelGroupBackend) DeepCopyInto(out *WeightedTunnelGroupBackend) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedTunnelGroupBackend.
+func (in *WeightedTunnelGroupBackend) DeepCopy() *WeightedTunnelGroupBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedTunnelGroupBackend)
+	in.DeepCopyInto(out)
+	return out
+}
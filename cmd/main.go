@@ -21,17 +21,21 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -43,6 +47,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"github.com/ngrok/ngrok-api-go/v5"
 
@@ -53,6 +58,7 @@ import (
 	"github.com/ngrok/kubernetes-ingress-controller/internal/ngrokapi"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/store"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/version"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/webhooks"
 	"github.com/ngrok/kubernetes-ingress-controller/pkg/tunneldriver"
 	//+kubebuilder:scaffold:imports
 )
@@ -65,6 +71,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1alpha2.AddToScheme(scheme))
 	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -82,11 +89,16 @@ type managerOpts struct {
 	electionID                string
 	probeAddr                 string
 	serverAddr                string
+	authtokenFile             string
 	controllerName            string
 	watchNamespace            string
 	metaData                  string
 	managerName               string
 	useExperimentalGatewayAPI bool
+	enableWebhooks            bool
+	resyncInterval            time.Duration
+	drainTimeout              time.Duration
+	tunnelConcurrency         int
 	zapOpts                   *zap.Options
 
 	// env vars
@@ -108,17 +120,24 @@ func cmd() *cobra.Command {
 	c.Flags().StringVar(&opts.metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to")
 	c.Flags().StringVar(&opts.probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	c.Flags().StringVar(&opts.electionID, "election-id", "ngrok-ingress-controller-leader", "The name of the configmap that is used for holding the leader lock")
-	c.Flags().StringVar(&opts.metaData, "metadata", "", "A comma separated list of key value pairs such as 'key1=value1,key2=value2' to be added to ngrok api resources as labels")
+	c.PersistentFlags().StringVar(&opts.metaData, "metadata", "", "A comma separated list of key value pairs such as 'key1=value1,key2=value2' to be added to ngrok api resources as labels")
 	c.Flags().StringVar(&opts.region, "region", "", "The region to use for ngrok tunnels")
 	c.Flags().StringVar(&opts.serverAddr, "server-addr", "", "The address of the ngrok server to use for tunnels")
-	c.Flags().StringVar(&opts.controllerName, "controller-name", "k8s.ngrok.com/ingress-controller", "The name of the controller to use for matching ingresses classes")
+	c.Flags().StringVar(&opts.authtokenFile, "authtoken-file", "", "A file to read the ngrok authtoken from, such as a key of a mounted Secret. The tunnel session is restarted when it changes. Defaults to the NGROK_AUTHTOKEN environment variable")
+	c.PersistentFlags().StringVar(&opts.controllerName, "controller-name", "k8s.ngrok.com/ingress-controller", "The name of the controller to use for matching ingresses classes")
 	c.Flags().StringVar(&opts.watchNamespace, "watch-namespace", "", "Namespace to watch for Kubernetes resources. Defaults to all namespaces.")
-	c.Flags().StringVar(&opts.managerName, "manager-name", "ngrok-ingress-controller-manager", "Manager name to identify unique ngrok ingress controller instances")
-	c.Flags().BoolVar(&opts.useExperimentalGatewayAPI, "use-experimental-gateway-api", false, "sets up experemental gatewayAPI")
+	c.PersistentFlags().StringVar(&opts.managerName, "manager-name", "ngrok-ingress-controller-manager", "Manager name to identify unique ngrok ingress controller instances")
+	c.PersistentFlags().BoolVar(&opts.useExperimentalGatewayAPI, "use-experimental-gateway-api", false, "sets up experemental gatewayAPI")
+	c.Flags().BoolVar(&opts.enableWebhooks, "enable-webhooks", false, "Serve the validating admission webhooks on port 9443. Requires a serving certificate in the webhook server's cert directory")
+	c.Flags().DurationVar(&opts.resyncInterval, "resync-interval", 5*time.Minute, "How often the driver recalculates and applies the desired state even when nothing has changed. Set to 0 to disable periodic resyncs")
+	c.Flags().DurationVar(&opts.drainTimeout, "drain-timeout", tunneldriver.DefaultDrainTimeout, "How long connections are given to finish when their tunnel is replaced or deleted, or the controller shuts down, before they're closed")
+	c.Flags().IntVar(&opts.tunnelConcurrency, "tunnel-max-concurrent-reconciles", 1, "How many Tunnels each pod reconciles at once. Raising it speeds up starting a large number of tunnels after a restart")
 	opts.zapOpts = &zap.Options{}
 	goFlagSet := flag.NewFlagSet("manager", flag.ContinueOnError)
 	opts.zapOpts.BindFlags(goFlagSet)
-	c.Flags().AddGoFlagSet(goFlagSet)
+	c.PersistentFlags().AddGoFlagSet(goFlagSet)
+
+	c.AddCommand(planCmd(&opts))
 
 	return c
 }
@@ -142,6 +161,7 @@ func runController(ctx context.Context, opts managerOpts) error {
 
 	clientConfigOpts := []ngrok.ClientConfigOption{
 		ngrok.WithUserAgent(version.GetUserAgent()),
+		ngrok.WithHTTPClient(ngrokapi.NewInstrumentedHTTPClient(http.DefaultClient)),
 	}
 
 	ngrokClientConfig := ngrok.NewClientConfig(opts.ngrokAPIKey, clientConfigOpts...)
@@ -165,6 +185,9 @@ func runController(ctx context.Context, opts managerOpts) error {
 		LeaderElection:         opts.electionID != "",
 		LeaderElectionID:       opts.electionID,
 	}
+	// Leave the tunnel driver time to drain its tunnels when the pod is stopped
+	gracefulShutdownTimeout := opts.drainTimeout + 10*time.Second
+	options.GracefulShutdownTimeout = &gracefulShutdownTimeout
 
 	if opts.watchNamespace != "" {
 		options.Cache = cache.Options{
@@ -179,11 +202,17 @@ func runController(ctx context.Context, opts managerOpts) error {
 		return fmt.Errorf("unable to start manager: %w", err)
 	}
 
-	driver, err := getDriver(ctx, mgr, opts)
+	driver, err := getDriver(mgr, opts)
 	if err != nil {
 		return fmt.Errorf("unable to create Driver: %w", err)
 	}
 
+	// The driver runs its own sync loop, reconcilers only update its store and mark it dirty
+	driver.WithEventRecorder(mgr.GetEventRecorderFor("ingress-controller"))
+	if err := mgr.Add(driver.WithSyncLoop(mgr.GetClient(), opts.resyncInterval)); err != nil {
+		return fmt.Errorf("unable to add driver sync loop to manager: %w", err)
+	}
+
 	if err := (&controllers.IngressReconciler{
 		Client:               mgr.GetClient(),
 		Log:                  ctrl.Log.WithName("controllers").WithName("ingress"),
@@ -208,23 +237,42 @@ func runController(ctx context.Context, opts managerOpts) error {
 	}
 
 	td, err := tunneldriver.New(ctrl.Log.WithName("drivers").WithName("tunnel"), tunneldriver.TunnelDriverOpts{
-		ServerAddr: opts.serverAddr,
-		Region:     opts.region,
+		ServerAddr:    opts.serverAddr,
+		Region:        opts.region,
+		AuthtokenFile: opts.authtokenFile,
+		DrainTimeout:  opts.drainTimeout,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to create tunnel driver: %w", err)
 	}
+	if err := mgr.Add(td); err != nil {
+		return fmt.Errorf("unable to add tunnel driver to manager: %w", err)
+	}
 
 	if err = (&controllers.TunnelReconciler{
-		Client:       mgr.GetClient(),
-		Log:          ctrl.Log.WithName("controllers").WithName("tunnel"),
-		Scheme:       mgr.GetScheme(),
-		Recorder:     mgr.GetEventRecorderFor("tunnel-controller"),
-		TunnelDriver: td,
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("tunnel"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("tunnel-controller"),
+		TunnelDriver:  td,
+		PodName:       podName(),
+		TunnelsClient: ngrokClientset.Tunnels(),
+		APIReader:     mgr.GetAPIReader(),
+
+		MaxConcurrentReconciles: opts.tunnelConcurrency,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Tunnel")
 		os.Exit(1)
 	}
+	if err = (&controllers.ServiceReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("service"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("service-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Service")
+		os.Exit(1)
+	}
 	if err = (&controllers.TCPEdgeReconciler{
 		Client:         mgr.GetClient(),
 		Log:            ctrl.Log.WithName("controllers").WithName("tcp-edge"),
@@ -235,12 +283,14 @@ func runController(ctx context.Context, opts managerOpts) error {
 		setupLog.Error(err, "unable to create controller", "controller", "TCPEdge")
 		os.Exit(1)
 	}
+	caResolver := controllers.NewCertificateAuthorityResolver(ngrokClientset, mgr.GetClient())
 	if err = (&controllers.TLSEdgeReconciler{
 		Client:         mgr.GetClient(),
 		Log:            ctrl.Log.WithName("controllers").WithName("tls-edge"),
 		Scheme:         mgr.GetScheme(),
 		Recorder:       mgr.GetEventRecorderFor("tls-edge-controller"),
 		NgrokClientset: ngrokClientset,
+		CAResolver:     caResolver,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TLSEdge")
 		os.Exit(1)
@@ -251,6 +301,7 @@ func runController(ctx context.Context, opts managerOpts) error {
 		Scheme:         mgr.GetScheme(),
 		Recorder:       mgr.GetEventRecorderFor("https-edge-controller"),
 		NgrokClientset: ngrokClientset,
+		CAResolver:     caResolver,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HTTPSEdge")
 		os.Exit(1)
@@ -277,6 +328,16 @@ func runController(ctx context.Context, opts managerOpts) error {
 		os.Exit(1)
 	}
 	if opts.useExperimentalGatewayAPI {
+		if err = (&gatewaycontroller.GatewayClassReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("GatewayClass"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
+			os.Exit(1)
+		}
+
 		if err = (&gatewaycontroller.GatewayReconciler{
 			Client:   mgr.GetClient(),
 			Log:      ctrl.Log.WithName("controllers").WithName("Gateway"),
@@ -298,13 +359,65 @@ func runController(ctx context.Context, opts managerOpts) error {
 			setupLog.Error(err, "unable to create controller", "controller", "HTTPRoute")
 			os.Exit(1)
 		}
+
+		// TLSRoutes and TCPRoutes are only in the experimental channel of the Gateway API
+		if hasAPIKind(mgr, gatewayv1alpha2.SchemeGroupVersion.WithKind("TLSRoute")) {
+			if err = (&gatewaycontroller.TLSRouteReconciler{
+				Client:   mgr.GetClient(),
+				Log:      ctrl.Log.WithName("controllers").WithName("TLSRoute"),
+				Scheme:   mgr.GetScheme(),
+				Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+				Driver:   driver,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "TLSRoute")
+				os.Exit(1)
+			}
+		} else {
+			setupLog.Info("TLSRoute CRD not installed, TLSRoutes will not be handled")
+		}
+
+		if hasAPIKind(mgr, gatewayv1alpha2.SchemeGroupVersion.WithKind("TCPRoute")) {
+			if err = (&gatewaycontroller.TCPRouteReconciler{
+				Client:   mgr.GetClient(),
+				Log:      ctrl.Log.WithName("controllers").WithName("TCPRoute"),
+				Scheme:   mgr.GetScheme(),
+				Recorder: mgr.GetEventRecorderFor("gateway-controller"),
+				Driver:   driver,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "TCPRoute")
+				os.Exit(1)
+			}
+		} else {
+			setupLog.Info("TCPRoute CRD not installed, TCPRoutes will not be handled")
+		}
+	}
+
+	if opts.enableWebhooks {
+		if err := webhooks.SetupWithManager(mgr, opts.controllerName); err != nil {
+			return fmt.Errorf("unable to set up webhooks: %w", err)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
+	apiHealthCheck := ngrokapi.NewAPIHealthCheck(ctrl.Log.WithName("ngrok-api-health"), ngrokClientset.Domains())
+	if err := mgr.Add(apiHealthCheck); err != nil {
+		return fmt.Errorf("unable to add ngrok API health check to manager: %w", err)
+	}
+
+	// Each check is served on its own path too, such as /readyz/ngrok-api, so they can be queried individually
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return fmt.Errorf("error setting up health check: %w", err)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddHealthzCheck("tunnel-session", td.Live); err != nil {
+		return fmt.Errorf("error setting up health check: %w", err)
+	}
+	if err := mgr.AddReadyzCheck("tunnel-session", td.Ready); err != nil {
+		return fmt.Errorf("error setting up readyz check: %w", err)
+	}
+	if err := mgr.AddReadyzCheck("driver-seeded", driver.Seeded); err != nil {
+		return fmt.Errorf("error setting up readyz check: %w", err)
+	}
+	if err := mgr.AddReadyzCheck("ngrok-api", apiHealthCheck.Check); err != nil {
 		return fmt.Errorf("error setting up readyz check: %w", err)
 	}
 
@@ -316,12 +429,45 @@ func runController(ctx context.Context, opts managerOpts) error {
 	return nil
 }
 
-// getDriver returns a new Driver instance that is seeded with the current state of the cluster.
-func getDriver(ctx context.Context, mgr manager.Manager, options managerOpts) (*store.Driver, error) {
-	logger := mgr.GetLogger().WithName("cache-store-driver")
+// podName returns the name of the pod the controller is running in, falling back to the hostname when
+// POD_NAME isn't set
+func podName() string {
+	if name := os.Getenv("POD_NAME"); name != "" {
+		return name
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		setupLog.Error(err, "unable to determine pod name")
+	}
+	return name
+}
+
+// hasAPIKind returns true if the cluster serves the given kind
+func hasAPIKind(mgr manager.Manager, gvk schema.GroupVersionKind) bool {
+	_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	return err == nil
+}
+
+// getDriver returns a new Driver instance that is seeded with the current state of the cluster once the
+// manager starts.
+func getDriver(mgr manager.Manager, options managerOpts) (*store.Driver, error) {
+	d, err := newDriver(mgr.GetLogger().WithName("cache-store-driver"), mgr.GetScheme(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mgr.Add(d.Seeder(mgr.GetAPIReader())); err != nil {
+		return nil, fmt.Errorf("unable to add driver seeder to manager: %w", err)
+	}
+
+	return d, nil
+}
+
+// newDriver returns a new, unseeded Driver configured from the manager options.
+func newDriver(logger logr.Logger, scheme *runtime.Scheme, options managerOpts) (*store.Driver, error) {
 	d := store.NewDriver(
 		logger,
-		mgr.GetScheme(),
+		scheme,
 		options.controllerName,
 		types.NamespacedName{
 			Namespace: options.namespace,
@@ -344,12 +490,5 @@ func getDriver(ctx context.Context, mgr manager.Manager, options managerOpts) (*
 		}
 		d.WithMetaData(customMetaData)
 	}
-
-	if err := d.Seed(ctx, mgr.GetAPIReader()); err != nil {
-		return nil, fmt.Errorf("unable to seed cache store: %w", err)
-	}
-
-	d.PrintState(setupLog)
-
 	return d, nil
 }
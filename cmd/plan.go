@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type planOpts struct {
+	kubeconfig string
+	output     string
+}
+
+// planCmd returns the plan subcommand. It seeds a driver from the cluster the same way the manager
+// does and prints the Domains, edges and Tunnels a sync would create, update, or delete,
+// without changing anything.
+func planCmd(opts *managerOpts) *cobra.Command {
+	var pOpts planOpts
+	c := &cobra.Command{
+		Use:   "plan",
+		Short: "Print the changes the controller would make to the cluster without applying them",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runPlan(c.Context(), c.OutOrStdout(), *opts, pOpts)
+		},
+	}
+
+	c.Flags().StringVar(&pOpts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Defaults to $KUBECONFIG, the in-cluster config, or ~/.kube/config")
+	c.Flags().StringVarP(&pOpts.output, "output", "o", "text", "Output format, one of 'text' or 'json'")
+	c.Flags().StringVar(&opts.namespace, "namespace", os.Getenv("POD_NAMESPACE"), "The namespace the controller is running in, used to find the resources it owns. Defaults to $POD_NAMESPACE")
+
+	return c
+}
+
+func runPlan(ctx context.Context, out io.Writer, opts managerOpts, pOpts planOpts) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(opts.zapOpts)))
+
+	if pOpts.output != "text" && pOpts.output != "json" {
+		return fmt.Errorf("unsupported output format %q, must be one of 'text' or 'json'", pOpts.output)
+	}
+
+	var cfg *rest.Config
+	var err error
+	if pOpts.kubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", pOpts.kubeconfig)
+	} else {
+		cfg, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	d, err := newDriver(ctrl.Log.WithName("cache-store-driver"), scheme, opts)
+	if err != nil {
+		return fmt.Errorf("unable to create Driver: %w", err)
+	}
+
+	if err := d.Seed(ctx, c); err != nil {
+		return fmt.Errorf("unable to seed cache store: %w", err)
+	}
+
+	plan, err := d.Plan(ctx, c)
+	if err != nil {
+		return fmt.Errorf("unable to calculate plan: %w", err)
+	}
+
+	if pOpts.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	return plan.WriteText(out)
+}
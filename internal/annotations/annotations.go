@@ -17,28 +17,61 @@ limitations under the License.
 package annotations
 
 import (
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/imdario/mergo"
 	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/circuit_breaker"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/compression"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/headers"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/ip_policies"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/mutual_tls"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/oauth"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/oidc"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/policy"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/saml"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/tls"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/webhook_verification"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/websocket_tcp_converter"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 )
 
 // DeniedKeyName name of the key that contains the reason to deny a location
 const DeniedKeyName = "Denied"
 
 type RouteModules struct {
-	Compression         *ingressv1alpha1.EndpointCompression
-	Headers             *ingressv1alpha1.EndpointHeaders
-	IPRestriction       *ingressv1alpha1.EndpointIPPolicy
-	TLSTermination      *ingressv1alpha1.EndpointTLSTerminationAtEdge
-	WebhookVerification *ingressv1alpha1.EndpointWebhookVerification
+	CircuitBreaker        *ingressv1alpha1.EndpointCircuitBreaker
+	Compression           *ingressv1alpha1.EndpointCompression
+	Headers               *ingressv1alpha1.EndpointHeaders
+	IPRestriction         *ingressv1alpha1.EndpointIPPolicy
+	MutualTLS             *ingressv1alpha1.EndpointMutualTLS
+	OAuth                 *ingressv1alpha1.EndpointOAuth
+	OIDC                  *ingressv1alpha1.EndpointOIDC
+	Policy                *ingressv1alpha1.EndpointPolicy
+	PolicyConfigMapRef    *ingressv1alpha1.ConfigMapKeyRef
+	SAML                  *ingressv1alpha1.EndpointSAML
+	TLSTermination        *ingressv1alpha1.EndpointTLSTerminationAtEdge
+	WebhookVerification   *ingressv1alpha1.EndpointWebhookVerification
+	WebsocketTCPConverter *ingressv1alpha1.EndpointWebsocketTCPConverter
+}
+
+// failClosedAnnotations are the annotations securing a route. When one of them is present but invalid,
+// publishing the route without it would expose the backend, so Extract returns an error instead of skipping it.
+// Traffic policies are included since they are commonly used to deny requests.
+var failClosedAnnotations = map[string]bool{
+	"MutualTLS":          true,
+	"OAuth":              true,
+	"OIDC":               true,
+	"Policy":             true,
+	"PolicyConfigMapRef": true,
+	"SAML":               true,
 }
 
 type Extractor struct {
@@ -48,18 +81,28 @@ type Extractor struct {
 func NewAnnotationsExtractor() Extractor {
 	return Extractor{
 		annotations: map[string]parser.IngressAnnotation{
-			"Compression":         compression.NewParser(),
-			"Headers":             headers.NewParser(),
-			"IPRestriction":       ip_policies.NewParser(),
-			"TLSTermination":      tls.NewParser(),
-			"WebhookVerification": webhook_verification.NewParser(),
+			"CircuitBreaker":        circuit_breaker.NewParser(),
+			"Compression":           compression.NewParser(),
+			"Headers":               headers.NewParser(),
+			"IPRestriction":         ip_policies.NewParser(),
+			"MutualTLS":             mutual_tls.NewParser(),
+			"OAuth":                 oauth.NewParser(),
+			"OIDC":                  oidc.NewParser(),
+			"Policy":                policy.NewParser(),
+			"PolicyConfigMapRef":    policy.NewConfigMapRefParser(),
+			"SAML":                  saml.NewParser(),
+			"TLSTermination":        tls.NewParser(),
+			"WebhookVerification":   webhook_verification.NewParser(),
+			"WebsocketTCPConverter": websocket_tcp_converter.NewParser(),
 		},
 	}
 }
 
-// Extract extracts the annotations from an Ingress
-func (e Extractor) Extract(ing *networking.Ingress) *RouteModules {
+// Extract extracts the annotations from an Ingress. Invalid annotations are logged and skipped, except for the
+// ones securing the route, which are returned as an error so the Ingress isn't published without them.
+func (e Extractor) Extract(ing *networking.Ingress) (*RouteModules, error) {
 	pia := &RouteModules{}
+	var failClosedErrs []error
 
 	data := make(map[string]interface{})
 	for name, annotationParser := range e.annotations {
@@ -70,7 +113,13 @@ func (e Extractor) Extract(ing *networking.Ingress) *RouteModules {
 				continue
 			}
 
+			if failClosedAnnotations[name] {
+				failClosedErrs = append(failClosedErrs, err)
+				continue
+			}
+
 			if !errors.IsLocationDenied(err) {
+				klog.ErrorS(err, "error reading Ingress annotation", "name", name, "ingress", klog.KObj(ing))
 				continue
 			}
 
@@ -95,7 +144,11 @@ func (e Extractor) Extract(ing *networking.Ingress) *RouteModules {
 		klog.ErrorS(err, "unexpected error merging extracted annotations")
 	}
 
-	return pia
+	// annotations are parsed in map order, keep the error stable for the Ingress' events
+	sort.Slice(failClosedErrs, func(i, j int) bool {
+		return failClosedErrs[i].Error() < failClosedErrs[j].Error()
+	})
+	return pia, goerrors.Join(failClosedErrs...)
 }
 
 // Extracts a list of moudule set names from the annotation
@@ -103,3 +156,55 @@ func (e Extractor) Extract(ing *networking.Ingress) *RouteModules {
 func ExtractNgrokModuleSetsFromAnnotations(ing *networking.Ingress) ([]string, error) {
 	return parser.GetStringSliceAnnotation("modules", ing)
 }
+
+// IsDefaultNgrokModuleSet returns true if the module set is marked as a default for every Ingress in its namespace
+// with the annotation
+// k8s.ngrok.com/is-default-module-set: "true"
+func IsDefaultNgrokModuleSet(ms *ingressv1alpha1.NgrokModuleSet) bool {
+	return ms.GetAnnotations()[parser.GetAnnotationWithPrefix("is-default-module-set")] == "true"
+}
+
+// PathNgrokModuleSets maps paths of an Ingress, optionally prefixed by one of its hosts, to the names of the
+// module sets applied to the routes of those paths
+type PathNgrokModuleSets map[string][]string
+
+// ForPath returns the module sets for the route of path in the rule for host. The module sets for the path on
+// any host come first, followed by the ones for the path on that host, so the more specific ones are merged last.
+func (p PathNgrokModuleSets) ForPath(host, path string) []string {
+	return append(append([]string{}, p[path]...), p[host+path]...)
+}
+
+// Extracts the module sets of individual paths from the annotation, a YAML map from a path or a host and path
+// to a list of module set names
+//
+//	k8s.ngrok.com/path-modules: |
+//	  /api: module1
+//	  admin.example.com/: module2,module3
+func ExtractPathNgrokModuleSetsFromAnnotations(ing *networking.Ingress) (PathNgrokModuleSets, error) {
+	name := parser.GetAnnotationWithPrefix("path-modules")
+	if _, err := parser.GetStringAnnotation("path-modules", ing); err != nil {
+		return nil, err
+	}
+	// GetStringAnnotation trims every line, which would lose the YAML indentation
+	val := ing.GetAnnotations()[name]
+
+	raw := map[string]string{}
+	if err := yaml.UnmarshalStrict([]byte(val), &raw); err != nil {
+		return nil, errors.NewInvalidAnnotationContent(name, err)
+	}
+
+	pathModuleSets := PathNgrokModuleSets{}
+	for path, modules := range raw {
+		if !strings.Contains(path, "/") {
+			return nil, errors.NewInvalidAnnotationContent(name, fmt.Sprintf("%q is not a path", path))
+		}
+		for _, module := range strings.Split(modules, ",") {
+			module = strings.TrimSpace(module)
+			if module == "" {
+				return nil, errors.NewInvalidAnnotationContent(name, fmt.Sprintf("empty module set name for %q", path))
+			}
+			pathModuleSets[path] = append(pathModuleSets[path], module)
+		}
+	}
+	return pathModuleSets, nil
+}
@@ -0,0 +1,78 @@
+package annotations
+
+import (
+	"testing"
+
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathNgrokModuleSetsWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	_, err := ExtractPathNgrokModuleSetsFromAnnotations(ing)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestPathNgrokModuleSetsWhenSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{
+		"k8s.ngrok.com/path-modules": `
+/api: api
+example.com/api: "admin, sso"
+`,
+	})
+
+	pathModuleSets, err := ExtractPathNgrokModuleSetsFromAnnotations(ing)
+	assert.NoError(t, err)
+	assert.Equal(t, PathNgrokModuleSets{
+		"/api":            {"api"},
+		"example.com/api": {"admin", "sso"},
+	}, pathModuleSets)
+	assert.Equal(t, []string{"api", "admin", "sso"}, pathModuleSets.ForPath("example.com", "/api"))
+	assert.Equal(t, []string{"api"}, pathModuleSets.ForPath("other.example.com", "/api"))
+	assert.Empty(t, pathModuleSets.ForPath("example.com", "/"))
+}
+
+func TestPathNgrokModuleSetsWhenInvalid(t *testing.T) {
+	for _, val := range []string{
+		"/api: [api]",
+		"api: api",
+		"/api: api,,sso",
+	} {
+		ing := testutil.NewIngress()
+		ing.SetAnnotations(map[string]string{"k8s.ngrok.com/path-modules": val})
+
+		_, err := ExtractPathNgrokModuleSetsFromAnnotations(ing)
+		assert.Error(t, err, val)
+		assert.False(t, errors.IsMissingAnnotations(err), val)
+	}
+}
+
+func TestExtractFailsClosedOnInvalidAuthAnnotations(t *testing.T) {
+	for _, annotations := range []map[string]string{
+		{"k8s.ngrok.com/oauth-provider": "not-a-provider"},
+		{"k8s.ngrok.com/oidc-issuer": "https://example.com"},
+		{"k8s.ngrok.com/saml-idp-metadata": "<EntityDescriptor/>", "k8s.ngrok.com/saml-force-authn": "maybe"},
+		{"k8s.ngrok.com/mutual-tls-ca-secrets": " "},
+	} {
+		ing := testutil.NewIngress()
+		ing.SetAnnotations(annotations)
+
+		_, err := NewAnnotationsExtractor().Extract(ing)
+		assert.Error(t, err, annotations)
+	}
+}
+
+func TestExtractSkipsOtherInvalidAnnotations(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{
+		"k8s.ngrok.com/circuit-breaker-error-threshold": "not-a-number",
+		"k8s.ngrok.com/websocket-tcp-converter":         "true",
+	})
+
+	routeModules, err := NewAnnotationsExtractor().Extract(ing)
+	assert.NoError(t, err)
+	assert.Nil(t, routeModules.CircuitBreaker)
+	assert.NotNil(t, routeModules.WebsocketTCPConverter)
+}
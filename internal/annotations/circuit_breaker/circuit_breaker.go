@@ -0,0 +1,66 @@
+package circuit_breaker
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type EndpointCircuitBreaker = ingressv1alpha1.EndpointCircuitBreaker
+
+// maxNumBuckets is the most buckets ngrok allows in a rolling window
+const maxNumBuckets = 128
+
+type circuitBreaker struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return circuitBreaker{}
+}
+
+// Parse reads the circuit breaker module from the circuit-breaker-* annotations. The module is only configured
+// when circuit-breaker-error-threshold, the fraction of failed requests between 0 and 1 that trips the
+// circuit, is set.
+func (cb circuitBreaker) Parse(ing *networking.Ingress) (interface{}, error) {
+	threshold, err := parser.GetStringAnnotation("circuit-breaker-error-threshold", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity, err := resource.ParseQuantity(threshold)
+	if err != nil || quantity.Sign() < 0 || quantity.Cmp(resource.MustParse("1")) > 0 {
+		return nil, errors.NewInvalidAnnotationContent(parser.GetAnnotationWithPrefix("circuit-breaker-error-threshold"), threshold)
+	}
+
+	parsed := &EndpointCircuitBreaker{ErrorThresholdPercentage: quantity}
+
+	if parsed.TrippedDuration.Duration, err = parser.GetDurationAnnotation("circuit-breaker-tripped-duration", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.RollingWindow.Duration, err = parser.GetDurationAnnotation("circuit-breaker-rolling-window", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	numBuckets, err := parser.GetIntAnnotation("circuit-breaker-num-buckets", ing)
+	if err == nil {
+		if numBuckets < 1 || numBuckets > maxNumBuckets {
+			return nil, errors.NewInvalidAnnotationContent(parser.GetAnnotationWithPrefix("circuit-breaker-num-buckets"), numBuckets)
+		}
+		parsed.NumBuckets = uint32(numBuckets)
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	volumeThreshold, err := parser.GetIntAnnotation("circuit-breaker-volume-threshold", ing)
+	if err == nil {
+		if volumeThreshold < 0 {
+			return nil, errors.NewInvalidAnnotationContent(parser.GetAnnotationWithPrefix("circuit-breaker-volume-threshold"), volumeThreshold)
+		}
+		parsed.VolumeThreshold = uint32(volumeThreshold)
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,79 @@
+package circuit_breaker
+
+import (
+	"testing"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestCircuitBreakerWhenAnnotationsAreProvided(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("circuit-breaker-error-threshold")] = "0.5"
+	annotations[parser.GetAnnotationWithPrefix("circuit-breaker-tripped-duration")] = "10s"
+	annotations[parser.GetAnnotationWithPrefix("circuit-breaker-rolling-window")] = "1m"
+	annotations[parser.GetAnnotationWithPrefix("circuit-breaker-num-buckets")] = "12"
+	annotations[parser.GetAnnotationWithPrefix("circuit-breaker-volume-threshold")] = "20"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	circuitBreaker, ok := parsed.(*ingressv1alpha1.EndpointCircuitBreaker)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointCircuitBreaker, got %T", parsed)
+	}
+
+	assert.Equal(t, 0.5, circuitBreaker.ErrorThresholdPercentage.AsApproximateFloat64())
+	assert.Equal(t, 10*time.Second, circuitBreaker.TrippedDuration.Duration)
+	assert.Equal(t, time.Minute, circuitBreaker.RollingWindow.Duration)
+	assert.Equal(t, uint32(12), circuitBreaker.NumBuckets)
+	assert.Equal(t, uint32(20), circuitBreaker.VolumeThreshold)
+}
+
+func TestCircuitBreakerWithInvalidValues(t *testing.T) {
+	for name, annotations := range map[string]map[string]string{
+		"threshold above 1": {
+			"circuit-breaker-error-threshold": "50",
+		},
+		"threshold not a number": {
+			"circuit-breaker-error-threshold": "half",
+		},
+		"too many buckets": {
+			"circuit-breaker-error-threshold": "0.5",
+			"circuit-breaker-num-buckets":     "129",
+		},
+		"invalid duration": {
+			"circuit-breaker-error-threshold":  "0.5",
+			"circuit-breaker-tripped-duration": "10",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ing := testutil.NewIngress()
+			prefixed := map[string]string{}
+			for k, v := range annotations {
+				prefixed[parser.GetAnnotationWithPrefix(k)] = v
+			}
+			ing.SetAnnotations(prefixed)
+
+			parsed, err := NewParser().Parse(ing)
+			assert.Nil(t, parsed)
+			assert.True(t, errors.IsInvalidContent(err), "expected invalid content, got %v", err)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package mutual_tls
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+)
+
+type EndpointMutualTLS = ingressv1alpha1.EndpointMutualTLS
+
+// caSecretKey is the key of the PEM encoded CA certificate in the Secrets named by mutual-tls-ca-secrets
+const caSecretKey = "ca.crt"
+
+type mutualTLS struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return mutualTLS{}
+}
+
+// Parse reads the mutual TLS module of the edges from the mutual-tls-certificate-authorities annotation, a
+// comma separated list of ngrok Certificate Authority IDs, and the mutual-tls-ca-secrets annotation, a comma
+// separated list of Secrets in the ingress' namespace holding a CA certificate under ca.crt. The module is only
+// configured when at least one of them is set.
+func (m mutualTLS) Parse(ing *networking.Ingress) (interface{}, error) {
+	parsed := &EndpointMutualTLS{}
+
+	cas, err := parser.GetStringSliceAnnotation("mutual-tls-certificate-authorities", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if err == nil {
+		parsed.CertificateAuthorities = cas
+	}
+
+	secrets, err := parser.GetStringSliceAnnotation("mutual-tls-ca-secrets", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		parsed.CertificateAuthoritySecrets = append(parsed.CertificateAuthoritySecrets, ingressv1alpha1.SecretKeyRef{
+			Name: secret,
+			Key:  caSecretKey,
+		})
+	}
+
+	if len(parsed.CertificateAuthorities) == 0 && len(parsed.CertificateAuthoritySecrets) == 0 {
+		return nil, errors.ErrMissingAnnotations
+	}
+	return parsed, nil
+}
@@ -0,0 +1,59 @@
+package mutual_tls
+
+import (
+	"testing"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutualTLSWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestMutualTLSWhenSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("mutual-tls-certificate-authorities")] = "ca_123, ca_456"
+	annotations[parser.GetAnnotationWithPrefix("mutual-tls-ca-secrets")] = "client-ca"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	mutualTLS, ok := parsed.(*EndpointMutualTLS)
+	if !ok {
+		t.Fatalf("expected *EndpointMutualTLS, got %T", parsed)
+	}
+	assert.Equal(t, []string{"ca_123", "ca_456"}, mutualTLS.CertificateAuthorities)
+	assert.Equal(t, []ingressv1alpha1.SecretKeyRef{{Name: "client-ca", Key: "ca.crt"}}, mutualTLS.CertificateAuthoritySecrets)
+}
+
+func TestMutualTLSWhenOnlySecretsSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("mutual-tls-ca-secrets")] = "client-ca,partner-ca"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	mutualTLS, ok := parsed.(*EndpointMutualTLS)
+	if !ok {
+		t.Fatalf("expected *EndpointMutualTLS, got %T", parsed)
+	}
+	assert.Empty(t, mutualTLS.CertificateAuthorities)
+	assert.Equal(t, []ingressv1alpha1.SecretKeyRef{
+		{Name: "client-ca", Key: "ca.crt"},
+		{Name: "partner-ca", Key: "ca.crt"},
+	}, mutualTLS.CertificateAuthoritySecrets)
+}
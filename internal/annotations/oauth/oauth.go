@@ -0,0 +1,141 @@
+package oauth
+
+import (
+	"strings"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+)
+
+type EndpointOAuth = ingressv1alpha1.EndpointOAuth
+type OAuthProviderCommon = ingressv1alpha1.OAuthProviderCommon
+type SecretKeyRef = ingressv1alpha1.SecretKeyRef
+
+// Providers are the values accepted by the oauth-provider annotation
+var Providers = []string{"amazon", "facebook", "github", "gitlab", "google", "linkedin", "microsoft", "twitch"}
+
+type oauth struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return oauth{}
+}
+
+// Parse reads the OAuth module from the oauth-* annotations. The module is only configured when
+// oauth-provider is set. Without oauth-client-id and the client secret annotations, ngrok's managed OAuth
+// application for the provider is used.
+func (o oauth) Parse(ing *networking.Ingress) (interface{}, error) {
+	provider, err := parser.GetStringAnnotation("oauth-provider", ing)
+	if err != nil {
+		return nil, err
+	}
+	provider = strings.ToLower(provider)
+
+	common, err := parseCommon(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &EndpointOAuth{}
+	switch provider {
+	case "amazon":
+		parsed.Amazon = &ingressv1alpha1.EndpointOAuthAmazon{OAuthProviderCommon: common}
+	case "facebook":
+		parsed.Facebook = &ingressv1alpha1.EndpointOAuthFacebook{OAuthProviderCommon: common}
+	case "github":
+		github := &ingressv1alpha1.EndpointOAuthGitHub{OAuthProviderCommon: common}
+		if github.Teams, err = getStringSlice("oauth-github-teams", ing); err != nil {
+			return nil, err
+		}
+		if github.Organizations, err = getStringSlice("oauth-github-organizations", ing); err != nil {
+			return nil, err
+		}
+		parsed.Github = github
+	case "gitlab":
+		parsed.Gitlab = &ingressv1alpha1.EndpointOAuthGitLab{OAuthProviderCommon: common}
+	case "google":
+		parsed.Google = &ingressv1alpha1.EndpointOAuthGoogle{OAuthProviderCommon: common}
+	case "linkedin":
+		parsed.Linkedin = &ingressv1alpha1.EndpointOAuthLinkedIn{OAuthProviderCommon: common}
+	case "microsoft":
+		parsed.Microsoft = &ingressv1alpha1.EndpointOAuthMicrosoft{OAuthProviderCommon: common}
+	case "twitch":
+		parsed.Twitch = &ingressv1alpha1.EndpointOAuthTwitch{OAuthProviderCommon: common}
+	default:
+		return nil, errors.NewInvalidAnnotationContent(parser.GetAnnotationWithPrefix("oauth-provider"), provider)
+	}
+
+	return parsed, nil
+}
+
+// parseCommon reads the options shared by every provider
+func parseCommon(ing *networking.Ingress) (OAuthProviderCommon, error) {
+	common := OAuthProviderCommon{}
+	var err error
+
+	if common.OptionsPassthrough, err = parser.GetBoolAnnotation("oauth-options-passthrough", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	if common.CookiePrefix, err = parser.GetStringAnnotation("oauth-cookie-prefix", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	if common.InactivityTimeout.Duration, err = parser.GetDurationAnnotation("oauth-inactivity-timeout", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	if common.MaximumDuration.Duration, err = parser.GetDurationAnnotation("oauth-maximum-duration", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	if common.AuthCheckInterval.Duration, err = parser.GetDurationAnnotation("oauth-auth-check-interval", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	if common.Scopes, err = getStringSlice("oauth-scopes", ing); err != nil {
+		return common, err
+	}
+	if common.EmailAddresses, err = getStringSlice("oauth-email-addresses", ing); err != nil {
+		return common, err
+	}
+	if common.EmailDomains, err = getStringSlice("oauth-email-domains", ing); err != nil {
+		return common, err
+	}
+
+	clientID, err := parser.GetStringAnnotation("oauth-client-id", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	secretName, err := parser.GetStringAnnotation("oauth-client-secret-name", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+	secretKey, err := parser.GetStringAnnotation("oauth-client-secret-key", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return common, err
+	}
+
+	switch {
+	case clientID == "" && secretName == "" && secretKey == "":
+		// ngrok's managed OAuth application
+	case clientID == "" || secretName == "" || secretKey == "":
+		return common, errors.NewInvalidAnnotationConfiguration(
+			parser.GetAnnotationWithPrefix("oauth-client-id"),
+			"oauth-client-id, oauth-client-secret-name and oauth-client-secret-key must be set together",
+		)
+	default:
+		common.ClientID = &clientID
+		common.ClientSecret = &SecretKeyRef{Name: secretName, Key: secretKey}
+	}
+
+	return common, nil
+}
+
+// getStringSlice reads an optional comma separated list, returning nil when it isn't set
+func getStringSlice(name string, ing *networking.Ingress) ([]string, error) {
+	v, err := parser.GetStringSliceAnnotation(name, ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return v, nil
+}
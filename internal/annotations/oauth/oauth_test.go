@@ -0,0 +1,112 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestOAuthWithManagedApplication(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("oauth-provider")] = "google"
+	annotations[parser.GetAnnotationWithPrefix("oauth-email-domains")] = "example.com, example.org"
+	annotations[parser.GetAnnotationWithPrefix("oauth-inactivity-timeout")] = "1h"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	oauth, ok := parsed.(*ingressv1alpha1.EndpointOAuth)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointOAuth, got %T", parsed)
+	}
+	if assert.NotNil(t, oauth.Google) {
+		assert.Equal(t, []string{"example.com", "example.org"}, oauth.Google.EmailDomains)
+		assert.Equal(t, time.Hour, oauth.Google.InactivityTimeout.Duration)
+		assert.Nil(t, oauth.Google.ClientID)
+		assert.Nil(t, oauth.Google.ClientSecret)
+	}
+	assert.Nil(t, oauth.Github)
+}
+
+func TestOAuthWithClientCredentials(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("oauth-provider")] = "GitHub"
+	annotations[parser.GetAnnotationWithPrefix("oauth-client-id")] = "my-client-id"
+	annotations[parser.GetAnnotationWithPrefix("oauth-client-secret-name")] = "github-oauth"
+	annotations[parser.GetAnnotationWithPrefix("oauth-client-secret-key")] = "CLIENT_SECRET"
+	annotations[parser.GetAnnotationWithPrefix("oauth-scopes")] = "read:org"
+	annotations[parser.GetAnnotationWithPrefix("oauth-github-organizations")] = "my-org"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	github := parsed.(*ingressv1alpha1.EndpointOAuth).Github
+	if assert.NotNil(t, github) {
+		assert.Equal(t, "my-client-id", *github.ClientID)
+		assert.Equal(t, &ingressv1alpha1.SecretKeyRef{Name: "github-oauth", Key: "CLIENT_SECRET"}, github.ClientSecret)
+		assert.Equal(t, []string{"read:org"}, github.Scopes)
+		assert.Equal(t, []string{"my-org"}, github.Organizations)
+		assert.Nil(t, github.Teams)
+	}
+}
+
+func TestOAuthWithPartialClientCredentials(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("oauth-provider")] = "google"
+	annotations[parser.GetAnnotationWithPrefix("oauth-client-id")] = "my-client-id"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+}
+
+func TestOAuthWithInvalidValues(t *testing.T) {
+	for name, annotations := range map[string]map[string]string{
+		"unknown provider": {
+			"oauth-provider": "myspace",
+		},
+		"invalid duration": {
+			"oauth-provider":         "google",
+			"oauth-maximum-duration": "forever",
+		},
+		"invalid bool": {
+			"oauth-provider":            "google",
+			"oauth-options-passthrough": "sometimes",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ing := testutil.NewIngress()
+			prefixed := map[string]string{}
+			for k, v := range annotations {
+				prefixed[parser.GetAnnotationWithPrefix(k)] = v
+			}
+			ing.SetAnnotations(prefixed)
+
+			parsed, err := NewParser().Parse(ing)
+			assert.Nil(t, parsed)
+			assert.True(t, errors.IsInvalidContent(err), "expected invalid content, got %v", err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+)
+
+type EndpointOIDC = ingressv1alpha1.EndpointOIDC
+type SecretKeyRef = ingressv1alpha1.SecretKeyRef
+
+type oidc struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return oidc{}
+}
+
+// Parse reads the OIDC module from the oidc-* annotations. The module is only configured when oidc-issuer is
+// set, and then the client ID and the Secret holding the client secret are required.
+func (o oidc) Parse(ing *networking.Ingress) (interface{}, error) {
+	issuer, err := parser.GetStringAnnotation("oidc-issuer", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &EndpointOIDC{Issuer: issuer}
+
+	for name, v := range map[string]*string{
+		"oidc-client-id":          &parsed.ClientID,
+		"oidc-client-secret-name": &parsed.ClientSecret.Name,
+		"oidc-client-secret-key":  &parsed.ClientSecret.Key,
+	} {
+		*v, err = parser.GetStringAnnotation(name, ing)
+		if err != nil {
+			if errors.IsMissingAnnotations(err) {
+				return nil, errors.NewInvalidAnnotationConfiguration(
+					parser.GetAnnotationWithPrefix("oidc-issuer"),
+					"oidc-client-id, oidc-client-secret-name and oidc-client-secret-key are required",
+				)
+			}
+			return nil, err
+		}
+	}
+
+	scopes, err := parser.GetStringSliceAnnotation("oidc-scopes", ing)
+	if err == nil {
+		parsed.Scopes = scopes
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if parsed.OptionsPassthrough, err = parser.GetBoolAnnotation("oidc-options-passthrough", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.CookiePrefix, err = parser.GetStringAnnotation("oidc-cookie-prefix", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.InactivityTimeout.Duration, err = parser.GetDurationAnnotation("oidc-inactivity-timeout", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.MaximumDuration.Duration, err = parser.GetDurationAnnotation("oidc-maximum-duration", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	return parsed, nil
+}
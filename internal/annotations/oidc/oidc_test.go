@@ -0,0 +1,61 @@
+package oidc
+
+import (
+	"testing"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestOIDCWhenClientSecretNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("oidc-issuer")] = "https://accounts.example.com"
+	annotations[parser.GetAnnotationWithPrefix("oidc-client-id")] = "my-client-id"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+}
+
+func TestOIDCWhenAnnotationsAreProvided(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("oidc-issuer")] = "https://accounts.example.com"
+	annotations[parser.GetAnnotationWithPrefix("oidc-client-id")] = "my-client-id"
+	annotations[parser.GetAnnotationWithPrefix("oidc-client-secret-name")] = "oidc-credentials"
+	annotations[parser.GetAnnotationWithPrefix("oidc-client-secret-key")] = "CLIENT_SECRET"
+	annotations[parser.GetAnnotationWithPrefix("oidc-scopes")] = "openid,profile,email"
+	annotations[parser.GetAnnotationWithPrefix("oidc-maximum-duration")] = "12h"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	oidc, ok := parsed.(*ingressv1alpha1.EndpointOIDC)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointOIDC, got %T", parsed)
+	}
+
+	assert.Equal(t, "https://accounts.example.com", oidc.Issuer)
+	assert.Equal(t, "my-client-id", oidc.ClientID)
+	assert.Equal(t, ingressv1alpha1.SecretKeyRef{Name: "oidc-credentials", Key: "CLIENT_SECRET"}, oidc.ClientSecret)
+	assert.Equal(t, []string{"openid", "profile", "email"}, oidc.Scopes)
+	assert.Equal(t, 12*time.Hour, oidc.MaximumDuration.Duration)
+}
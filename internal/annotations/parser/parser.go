@@ -22,6 +22,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
 	networking "k8s.io/api/networking/v1"
@@ -126,6 +127,18 @@ func (a ingAnnotations) parseFloat32(name string) (float32, error) {
 	return 0, errors.ErrMissingAnnotations
 }
 
+func (a ingAnnotations) parseDuration(name string) (time.Duration, error) {
+	val, ok := a[name]
+	if ok {
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil || d < 0 {
+			return 0, errors.NewInvalidAnnotationContent(name, val)
+		}
+		return d, nil
+	}
+	return 0, errors.ErrMissingAnnotations
+}
+
 func checkAnnotation(name string, ing *networking.Ingress) error {
 	if ing == nil || len(ing.GetAnnotations()) == 0 {
 		return errors.ErrMissingAnnotations
@@ -198,6 +211,16 @@ func GetFloatAnnotation(name string, ing *networking.Ingress) (float32, error) {
 	return ingAnnotations(ing.GetAnnotations()).parseFloat32(v)
 }
 
+// GetDurationAnnotation extracts a duration such as "30s" or "2h45m" from an Ingress annotation
+func GetDurationAnnotation(name string, ing *networking.Ingress) (time.Duration, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return 0, err
+	}
+	return ingAnnotations(ing.GetAnnotations()).parseDuration(v)
+}
+
 // GetAnnotationWithPrefix returns the prefix of ingress annotations
 func GetAnnotationWithPrefix(suffix string) string {
 	return fmt.Sprintf("%v/%v", AnnotationsPrefix, suffix)
@@ -0,0 +1,67 @@
+package policy
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+)
+
+type EndpointPolicy = ingressv1alpha1.EndpointPolicy
+type ConfigMapKeyRef = ingressv1alpha1.ConfigMapKeyRef
+
+type policy struct{}
+
+// NewParser returns a parser for a traffic policy written inline as YAML or JSON in the policy annotation
+func NewParser() parser.IngressAnnotation {
+	return policy{}
+}
+
+func (p policy) Parse(ing *networking.Ingress) (interface{}, error) {
+	name := parser.GetAnnotationWithPrefix("policy")
+	if _, err := parser.GetStringAnnotation("policy", ing); err != nil {
+		return nil, err
+	}
+	// GetStringAnnotation trims every line, which would lose the YAML indentation
+	val := ing.GetAnnotations()[name]
+
+	if _, ok := ing.GetAnnotations()[parser.GetAnnotationWithPrefix("policy-configmap-name")]; ok {
+		return nil, errors.NewInvalidAnnotationConfiguration(name, "only one of policy and policy-configmap-name may be set")
+	}
+
+	parsed, err := ingressv1alpha1.ParseEndpointPolicy([]byte(val))
+	if err != nil {
+		return nil, errors.NewInvalidAnnotationContent(name, err)
+	}
+	return parsed, nil
+}
+
+type configMapRef struct{}
+
+// NewConfigMapRefParser returns a parser for a reference to a ConfigMap key holding the traffic policy. The
+// ConfigMap is read when the edge is reconciled, so its contents aren't validated here.
+func NewConfigMapRefParser() parser.IngressAnnotation {
+	return configMapRef{}
+}
+
+func (c configMapRef) Parse(ing *networking.Ingress) (interface{}, error) {
+	name, err := parser.GetStringAnnotation("policy-configmap-name", ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			if _, ok := ing.GetAnnotations()[parser.GetAnnotationWithPrefix("policy-configmap-key")]; ok {
+				return nil, errors.NewInvalidAnnotationConfiguration(parser.GetAnnotationWithPrefix("policy-configmap-key"), "policy-configmap-name is required")
+			}
+		}
+		return nil, err
+	}
+
+	key, err := parser.GetStringAnnotation("policy-configmap-key", ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return nil, errors.NewInvalidAnnotationConfiguration(parser.GetAnnotationWithPrefix("policy-configmap-name"), "policy-configmap-key is required")
+		}
+		return nil, err
+	}
+
+	return &ConfigMapKeyRef{Name: name, Key: key}, nil
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"testing"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.True(t, errors.IsMissingAnnotations(err))
+
+	parsed, err = NewConfigMapRefParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestPolicyFromYAML(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("policy")] = `
+inbound:
+- name: deny-bots
+  expressions:
+  - "req.UserAgent.contains('bot')"
+  actions:
+  - type: deny
+    config:
+      status_code: 403
+outbound:
+- actions:
+  - type: add-headers
+`
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	policy, ok := parsed.(*ingressv1alpha1.EndpointPolicy)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointPolicy, got %T", parsed)
+	}
+
+	assert.Len(t, policy.Inbound, 1)
+	assert.Equal(t, "deny-bots", policy.Inbound[0].Name)
+	assert.Equal(t, []string{"req.UserAgent.contains('bot')"}, policy.Inbound[0].Expressions)
+	assert.Equal(t, "deny", policy.Inbound[0].Actions[0].Type)
+	assert.JSONEq(t, `{"status_code": 403}`, string(policy.Inbound[0].Actions[0].Config))
+	assert.Len(t, policy.Outbound, 1)
+}
+
+func TestPolicyFromJSON(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("policy")] = `{"inbound": [{"actions": [{"type": "deny"}]}]}`
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+	assert.Equal(t, "deny", parsed.(*ingressv1alpha1.EndpointPolicy).Inbound[0].Actions[0].Type)
+}
+
+func TestPolicyWithInvalidContent(t *testing.T) {
+	for name, val := range map[string]string{
+		"malformed":     "inbound: [",
+		"unknown field": "inbond: []",
+	} {
+		t.Run(name, func(t *testing.T) {
+			ing := testutil.NewIngress()
+			ing.SetAnnotations(map[string]string{parser.GetAnnotationWithPrefix("policy"): val})
+
+			parsed, err := NewParser().Parse(ing)
+			assert.Nil(t, parsed)
+			assert.True(t, errors.IsInvalidContent(err), "expected invalid content, got %v", err)
+		})
+	}
+}
+
+func TestPolicyInlineAndConfigMap(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("policy")] = `{"inbound": []}`
+	annotations[parser.GetAnnotationWithPrefix("policy-configmap-name")] = "traffic-policy"
+	annotations[parser.GetAnnotationWithPrefix("policy-configmap-key")] = "policy.yaml"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+}
+
+func TestPolicyConfigMapRef(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("policy-configmap-name")] = "traffic-policy"
+	ing.SetAnnotations(annotations)
+
+	// The key is required
+	parsed, err := NewConfigMapRefParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+
+	annotations[parser.GetAnnotationWithPrefix("policy-configmap-key")] = "policy.yaml"
+	parsed, err = NewConfigMapRefParser().Parse(ing)
+	assert.NoError(t, err)
+	assert.Equal(t, &ingressv1alpha1.ConfigMapKeyRef{Name: "traffic-policy", Key: "policy.yaml"}, parsed)
+}
+
+func TestPolicyConfigMapKeyWithoutName(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{parser.GetAnnotationWithPrefix("policy-configmap-key"): "policy.yaml"})
+
+	parsed, err := NewConfigMapRefParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+}
@@ -0,0 +1,62 @@
+package saml
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	networking "k8s.io/api/networking/v1"
+)
+
+type EndpointSAML = ingressv1alpha1.EndpointSAML
+
+type saml struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return saml{}
+}
+
+// Parse reads the SAML module from the saml-* annotations. The module is only configured when
+// saml-idp-metadata, the IdP's XML EntityDescriptor, is set.
+func (s saml) Parse(ing *networking.Ingress) (interface{}, error) {
+	metadata, err := parser.GetStringAnnotation("saml-idp-metadata", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &EndpointSAML{IdPMetadata: metadata}
+
+	groups, err := parser.GetStringSliceAnnotation("saml-authorized-groups", ing)
+	if err == nil {
+		parsed.AuthorizedGroups = groups
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	allowIdPInitiated, err := parser.GetBoolAnnotation("saml-allow-idp-initiated", ing)
+	if err == nil {
+		parsed.AllowIdPInitiated = &allowIdPInitiated
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if parsed.ForceAuthn, err = parser.GetBoolAnnotation("saml-force-authn", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.NameIDFormat, err = parser.GetStringAnnotation("saml-nameid-format", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.OptionsPassthrough, err = parser.GetBoolAnnotation("saml-options-passthrough", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.CookiePrefix, err = parser.GetStringAnnotation("saml-cookie-prefix", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.InactivityTimeout.Duration, err = parser.GetDurationAnnotation("saml-inactivity-timeout", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if parsed.MaximumDuration.Duration, err = parser.GetDurationAnnotation("saml-maximum-duration", ing); err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,59 @@
+package saml
+
+import (
+	"testing"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSAMLWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestSAMLWhenAnnotationsAreProvided(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("saml-idp-metadata")] = "<EntityDescriptor></EntityDescriptor>"
+	annotations[parser.GetAnnotationWithPrefix("saml-authorized-groups")] = "admins,developers"
+	annotations[parser.GetAnnotationWithPrefix("saml-allow-idp-initiated")] = "false"
+	annotations[parser.GetAnnotationWithPrefix("saml-force-authn")] = "true"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	saml, ok := parsed.(*ingressv1alpha1.EndpointSAML)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointSAML, got %T", parsed)
+	}
+
+	assert.Equal(t, "<EntityDescriptor></EntityDescriptor>", saml.IdPMetadata)
+	assert.Equal(t, []string{"admins", "developers"}, saml.AuthorizedGroups)
+	if assert.NotNil(t, saml.AllowIdPInitiated) {
+		assert.False(t, *saml.AllowIdPInitiated)
+	}
+	assert.True(t, saml.ForceAuthn)
+	assert.Empty(t, saml.NameIDFormat)
+}
+
+func TestSAMLWithInvalidForceAuthn(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("saml-idp-metadata")] = "<EntityDescriptor></EntityDescriptor>"
+	annotations[parser.GetAnnotationWithPrefix("saml-force-authn")] = "maybe"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.True(t, errors.IsInvalidContent(err))
+}
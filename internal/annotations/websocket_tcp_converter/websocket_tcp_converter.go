@@ -0,0 +1,27 @@
+package websocket_tcp_converter
+
+import (
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	networking "k8s.io/api/networking/v1"
+)
+
+type websocketTCPConverter struct{}
+
+func NewParser() parser.IngressAnnotation {
+	return websocketTCPConverter{}
+}
+
+// Parse parses the annotations contained in the ingress and returns a
+// websocket TCP converter configuration or an error. If the annotation is not
+// found, the returned error an errors.ErrMissingAnnotations.
+func (w websocketTCPConverter) Parse(ing *networking.Ingress) (interface{}, error) {
+	v, err := parser.GetBoolAnnotation("websocket-tcp-converter", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingressv1alpha1.EndpointWebsocketTCPConverter{
+		Enabled: v,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package websocket_tcp_converter
+
+import (
+	"testing"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/testutil"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebsocketTCPConverterWhenNotSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	ing.SetAnnotations(map[string]string{})
+	parsed, err := NewParser().Parse(ing)
+
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.True(t, errors.IsMissingAnnotations(err))
+}
+
+func TestWebsocketTCPConverterWhenSupplied(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("websocket-tcp-converter")] = "true"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.NoError(t, err)
+
+	converter, ok := parsed.(*ingressv1alpha1.EndpointWebsocketTCPConverter)
+	if !ok {
+		t.Fatalf("expected *ingressv1alpha1.EndpointWebsocketTCPConverter, got %T", parsed)
+	}
+	assert.Equal(t, true, converter.Enabled)
+}
+
+func TestWebsocketTCPConverterWhenInvalid(t *testing.T) {
+	ing := testutil.NewIngress()
+	annotations := map[string]string{}
+	annotations[parser.GetAnnotationWithPrefix("websocket-tcp-converter")] = "yes please"
+	ing.SetAnnotations(annotations)
+
+	parsed, err := NewParser().Parse(ing)
+	assert.Nil(t, parsed)
+	assert.Error(t, err)
+	assert.False(t, errors.IsMissingAnnotations(err))
+}
@@ -75,3 +75,24 @@ func (r *SecretResolver) GetSecret(ctx context.Context, namespace, name, key str
 	}
 	return string(value), nil
 }
+
+type ConfigMapResolver struct {
+	Client client.Reader
+}
+
+func (r *ConfigMapResolver) GetConfigMap(ctx context.Context, namespace, name, key string) (string, error) {
+	configMap := &v1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
+	}, configMap)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("configmap '%s/%s' does not contain key '%s'", namespace, name, key)
+	}
+	return value, nil
+}
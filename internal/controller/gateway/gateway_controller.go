@@ -40,7 +40,7 @@ import (
 )
 
 const (
-	ControllerName gatewayv1.GatewayController = "ngrok.com/gateway-controller"
+	ControllerName gatewayv1.GatewayController = store.GatewayControllerName
 )
 
 // GatewayReconciler reconciles a Gateway object
@@ -77,12 +77,7 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			return ctrl.Result{}, err
 		}
 
-		err = r.Driver.Sync(ctx, r.Client)
-		if err != nil {
-			log.Error(err, "Failed to sync after removing gateway from store")
-			return ctrl.Result{}, err
-		}
-
+		r.Driver.MarkDirty()
 		return ctrl.Result{}, nil
 	default:
 		return ctrl.Result{}, err
@@ -126,11 +121,7 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	if err := r.Driver.Sync(ctx, r.Client); err != nil {
-		log.Error(err, "Failed to sync")
-		return ctrl.Result{}, err
-	}
-
+	r.Driver.MarkDirty()
 	return ctrl.Result{}, nil
 }
 
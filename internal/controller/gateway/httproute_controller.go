@@ -69,12 +69,7 @@ func (r *HTTPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			return ctrl.Result{}, err
 		}
 
-		err = r.Driver.Sync(ctx, r.Client)
-		if err != nil {
-			log.Error(err, "Failed to sync after removing httproute from store")
-			return ctrl.Result{}, err
-		}
-
+		r.Driver.MarkDirty()
 		return ctrl.Result{}, nil
 	default:
 		return ctrl.Result{}, err
@@ -106,11 +101,7 @@ func (r *HTTPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
-	if err := r.Driver.Sync(ctx, r.Client); err != nil {
-		log.Error(err, "Failed to sync")
-		return ctrl.Result{}, err
-	}
-
+	r.Driver.MarkDirty()
 	return ctrl.Result{}, nil
 }
 
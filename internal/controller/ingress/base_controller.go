@@ -8,9 +8,13 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/controller/controllers"
 	"github.com/ngrok/ngrok-api-go/v5"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,12 +33,13 @@ type baseController[T client.Object] struct {
 	Log      logr.Logger
 	Recorder record.EventRecorder
 
-	kubeType  string
-	statusID  func(ct T) string
-	create    func(ctx context.Context, cr T) error
-	update    func(ctx context.Context, cr T) error
-	delete    func(ctx context.Context, cr T) error
-	errResult func(op baseControllerOp, cr T, err error) (ctrl.Result, error)
+	kubeType   string
+	statusID   func(ct T) string
+	conditions func(cr T) *[]metav1.Condition
+	create     func(ctx context.Context, cr T) error
+	update     func(ctx context.Context, cr T) error
+	delete     func(ctx context.Context, cr T) error
+	errResult  func(op baseControllerOp, cr T, err error) (ctrl.Result, error)
 }
 
 func (r *baseController[T]) reconcile(ctx context.Context, req ctrl.Request, cr T) (ctrl.Result, error) {
@@ -55,6 +60,9 @@ func (r *baseController[T]) reconcile(ctx context.Context, req ctrl.Request, cr
 			r.Recorder.Event(cr, v1.EventTypeNormal, "Creating", fmt.Sprintf("Creating %s: %s", r.kubeType, crName))
 			if err := r.create(ctx, cr); err != nil {
 				r.Recorder.Event(cr, v1.EventTypeWarning, "CreateError", fmt.Sprintf("Failed to create %s %s: %s", r.kubeType, crName, err.Error()))
+				if cerr := r.updateConditions(ctx, cr, err); cerr != nil {
+					log.Error(cerr, "failed to update status conditions")
+				}
 				if r.errResult != nil {
 					return r.errResult(createOp, cr, err)
 				}
@@ -65,6 +73,9 @@ func (r *baseController[T]) reconcile(ctx context.Context, req ctrl.Request, cr
 			r.Recorder.Event(cr, v1.EventTypeNormal, "Updating", fmt.Sprintf("Updating %s: %s", r.kubeType, crName))
 			if err := r.update(ctx, cr); err != nil {
 				r.Recorder.Event(cr, v1.EventTypeWarning, "UpdateError", fmt.Sprintf("Failed to update %s %s: %s", r.kubeType, crName, err.Error()))
+				if cerr := r.updateConditions(ctx, cr, err); cerr != nil {
+					log.Error(cerr, "failed to update status conditions")
+				}
 				if r.errResult != nil {
 					return r.errResult(updateOp, cr, err)
 				}
@@ -72,6 +83,10 @@ func (r *baseController[T]) reconcile(ctx context.Context, req ctrl.Request, cr
 			}
 			r.Recorder.Event(cr, v1.EventTypeNormal, "Updated", fmt.Sprintf("Updated %s: %s", r.kubeType, crName))
 		}
+
+		if err := r.updateConditions(ctx, cr, nil); err != nil {
+			return ctrl.Result{}, err
+		}
 	} else {
 		if controllers.HasFinalizer(cr) {
 			if r.statusID != nil && r.statusID(cr) != "" {
@@ -99,6 +114,86 @@ func (r *baseController[T]) reconcile(ctx context.Context, req ctrl.Request, cr
 	return ctrl.Result{}, nil
 }
 
+// updateConditions records the outcome of a create or update in the object's status conditions. Only the
+// conditions are patched, so status fields the create or update changed in memory but didn't persist are left
+// untouched.
+func (r *baseController[T]) updateConditions(ctx context.Context, cr T, err error) error {
+	if r.conditions == nil {
+		return nil
+	}
+
+	base := cr.DeepCopyObject().(T)
+	programmed := r.statusID != nil && r.statusID(cr) != ""
+	setReconcileConditions(r.conditions(cr), cr.GetGeneration(), programmed, err)
+	if equality.Semantic.DeepEqual(*r.conditions(base), *r.conditions(cr)) {
+		return nil
+	}
+	return r.Kube.Status().Patch(ctx, cr, client.MergeFrom(base))
+}
+
+// setReconcileConditions sets the Programmed, Degraded, and Ready conditions. programmed is whether the
+// object exists in ngrok, and err is the error from the last create or update, if any.
+func setReconcileConditions(conditions *[]metav1.Condition, generation int64, programmed bool, err error) {
+	programmedCond := metav1.Condition{
+		Type:               ingressv1alpha1.ConditionProgrammed,
+		Status:             metav1.ConditionTrue,
+		Reason:             ingressv1alpha1.ReasonProgrammed,
+		Message:            "Programmed in ngrok",
+		ObservedGeneration: generation,
+	}
+	degraded := metav1.Condition{
+		Type:               ingressv1alpha1.ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             ingressv1alpha1.ReasonReconciled,
+		Message:            "Reconciled successfully",
+		ObservedGeneration: generation,
+	}
+	ready := metav1.Condition{
+		Type:               ingressv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             ingressv1alpha1.ReasonReady,
+		Message:            "Ready",
+		ObservedGeneration: generation,
+	}
+
+	if err != nil {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = conditionReasonFromError(err)
+		degraded.Message = err.Error()
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = degraded.Reason
+		ready.Message = degraded.Message
+	}
+
+	if !programmed {
+		programmedCond.Status = metav1.ConditionFalse
+		programmedCond.Reason = ingressv1alpha1.ReasonPending
+		programmedCond.Message = "Not created in ngrok yet"
+		if err != nil {
+			programmedCond.Reason = degraded.Reason
+			programmedCond.Message = degraded.Message
+		} else {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = programmedCond.Reason
+			ready.Message = programmedCond.Message
+		}
+	}
+
+	meta.SetStatusCondition(conditions, programmedCond)
+	meta.SetStatusCondition(conditions, degraded)
+	meta.SetStatusCondition(conditions, ready)
+}
+
+// conditionReasonFromError returns the ngrok error code of err, such as ERR_NGROK_446, or a generic reason if
+// err didn't come from the ngrok API
+func conditionReasonFromError(err error) string {
+	var nerr *ngrok.Error
+	if errors.As(err, &nerr) && nerr.ErrorCode != "" {
+		return nerr.ErrorCode
+	}
+	return ingressv1alpha1.ReasonReconcileError
+}
+
 func reconcileResultFromError(err error) (ctrl.Result, error) {
 	var nerr *ngrok.Error
 	if errors.As(err, &nerr) {
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/ngrok-api-go/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("setReconcileConditions", func() {
+	var conditions []metav1.Condition
+
+	BeforeEach(func() {
+		conditions = nil
+	})
+
+	expectCondition := func(conditionType string, status metav1.ConditionStatus, reason string) {
+		cond := meta.FindStatusCondition(conditions, conditionType)
+		Expect(cond).ToNot(BeNil())
+		Expect(cond.Status).To(Equal(status))
+		Expect(cond.Reason).To(Equal(reason))
+		Expect(cond.ObservedGeneration).To(Equal(int64(3)))
+	}
+
+	It("is ready after a successful reconcile", func() {
+		setReconcileConditions(&conditions, 3, true, nil)
+		expectCondition(ingressv1alpha1.ConditionProgrammed, metav1.ConditionTrue, ingressv1alpha1.ReasonProgrammed)
+		expectCondition(ingressv1alpha1.ConditionDegraded, metav1.ConditionFalse, ingressv1alpha1.ReasonReconciled)
+		expectCondition(ingressv1alpha1.ConditionReady, metav1.ConditionTrue, ingressv1alpha1.ReasonReady)
+	})
+
+	It("reports the ngrok error code when a create fails", func() {
+		err := &ngrok.Error{ErrorCode: "ERR_NGROK_446", StatusCode: 400, Msg: "domain in use"}
+		setReconcileConditions(&conditions, 3, false, err)
+		expectCondition(ingressv1alpha1.ConditionProgrammed, metav1.ConditionFalse, "ERR_NGROK_446")
+		expectCondition(ingressv1alpha1.ConditionDegraded, metav1.ConditionTrue, "ERR_NGROK_446")
+		expectCondition(ingressv1alpha1.ConditionReady, metav1.ConditionFalse, "ERR_NGROK_446")
+	})
+
+	It("stays programmed but degraded when an update fails", func() {
+		setReconcileConditions(&conditions, 3, true, errors.New("secret not found"))
+		expectCondition(ingressv1alpha1.ConditionProgrammed, metav1.ConditionTrue, ingressv1alpha1.ReasonProgrammed)
+		expectCondition(ingressv1alpha1.ConditionDegraded, metav1.ConditionTrue, ingressv1alpha1.ReasonReconcileError)
+		expectCondition(ingressv1alpha1.ConditionReady, metav1.ConditionFalse, ingressv1alpha1.ReasonReconcileError)
+		Expect(meta.FindStatusCondition(conditions, ingressv1alpha1.ConditionReady).Message).To(Equal("secret not found"))
+	})
+
+	It("recovers once a reconcile succeeds", func() {
+		setReconcileConditions(&conditions, 3, true, errors.New("boom"))
+		setReconcileConditions(&conditions, 3, true, nil)
+		expectCondition(ingressv1alpha1.ConditionDegraded, metav1.ConditionFalse, ingressv1alpha1.ReasonReconciled)
+		expectCondition(ingressv1alpha1.ConditionReady, metav1.ConditionTrue, ingressv1alpha1.ReasonReady)
+	})
+})
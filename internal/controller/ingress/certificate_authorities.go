@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/controller/controllers"
+	ierr "github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/ngrokapi"
+	"github.com/ngrok/ngrok-api-go/v5"
+	"github.com/ngrok/ngrok-api-go/v5/certificate_authorities"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Metadata keys recording which Secret a Certificate Authority was created from
+const (
+	caSecretNamespaceMetadataKey = "secret-namespace"
+	caSecretNameMetadataKey      = "secret-name"
+	caSecretKeyMetadataKey       = "secret-key"
+)
+
+// caSecretRef identifies the Secret key a Certificate Authority was created from
+type caSecretRef struct {
+	namespace, name, key string
+}
+
+// ownedCertificateAuthority is a Certificate Authority created by the controller
+type ownedCertificateAuthority struct {
+	id  string
+	pem string
+	ref caSecretRef
+	// owner is the edge metadata, without the secret keys, the Certificate Authority was created with. It
+	// keeps controllers sharing an ngrok account from adopting or deleting each other's Certificate Authorities.
+	owner map[string]string
+}
+
+// CertificateAuthorityResolver resolves the certificate authorities of a mutual TLS module to ngrok Certificate
+// Authority IDs. A Certificate Authority is created for each referenced Secret key, tagged with the Secret in
+// its metadata, and reused as long as the Secret holds the same certificate. Certificate Authorities that no
+// edge references anymore, or that were replaced because their Secret changed, are deleted by DeleteUnused.
+//
+// The Certificate Authorities created by the controller are listed once and cached, so it's shared between
+// the edge controllers.
+type CertificateAuthorityResolver struct {
+	client         *certificate_authorities.Client
+	kube           client.Reader
+	secretResolver controllers.SecretResolver
+
+	mu     sync.Mutex
+	loaded bool
+	owned  []*ownedCertificateAuthority
+}
+
+// NewCertificateAuthorityResolver creates a CertificateAuthorityResolver
+func NewCertificateAuthorityResolver(clientset ngrokapi.Clientset, kube client.Reader) *CertificateAuthorityResolver {
+	return &CertificateAuthorityResolver{
+		client:         clientset.CertificateAuthorities(),
+		kube:           kube,
+		secretResolver: controllers.SecretResolver{Client: kube},
+	}
+}
+
+func (r *CertificateAuthorityResolver) resolveIDs(ctx context.Context, namespace, metadata string, mutualTLS *ingressv1alpha1.EndpointMutualTLS) ([]string, error) {
+	ids := append([]string{}, mutualTLS.CertificateAuthorities...)
+	if len(mutualTLS.CertificateAuthoritySecrets) == 0 {
+		return ids, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+
+	owner := caOwner(metadata)
+	for _, secretRef := range mutualTLS.CertificateAuthoritySecrets {
+		ref := caSecretRef{namespace: namespace, name: secretRef.Name, key: secretRef.Key}
+		pem, err := r.secretResolver.GetSecret(ctx, ref.namespace, ref.name, ref.key)
+		if err != nil {
+			return nil, err
+		}
+		pem = strings.TrimSpace(pem)
+
+		if ca := r.find(owner, ref, pem); ca != nil {
+			ids = append(ids, ca.id)
+			continue
+		}
+
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(pem)) {
+			return nil, ierr.NewErrInvalidConfiguration(fmt.Errorf("no CA certificates found in secret '%s/%s' key '%s'", namespace, ref.name, ref.key))
+		}
+
+		caMetadata, err := json.Marshal(caMetadata(owner, ref))
+		if err != nil {
+			return nil, err
+		}
+
+		ctrl.LoggerFrom(ctx).Info("Creating Certificate Authority", "secret", ref.name, "key", ref.key)
+		ca, err := r.client.Create(ctx, &ngrok.CertificateAuthorityCreate{
+			Description: fmt.Sprintf("Created by the ngrok ingress controller from secret %s/%s", namespace, ref.name),
+			Metadata:    string(caMetadata),
+			CAPEM:       pem,
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.owned = append(r.owned, &ownedCertificateAuthority{id: ca.ID, pem: pem, ref: ref, owner: owner})
+		ids = append(ids, ca.ID)
+	}
+
+	return ids, nil
+}
+
+// DeleteUnused deletes the Certificate Authorities created with the given edge metadata that aren't used by
+// any HTTPS or TLS edge anymore: either no edge references their Secret, or the Secret now holds a different
+// certificate. Deletion is best effort, a Certificate Authority that's still attached to an edge that hasn't
+// been reconciled yet is retried the next time this runs.
+func (r *CertificateAuthorityResolver) DeleteUnused(ctx context.Context, metadata string) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(ctx); err != nil {
+		return err
+	}
+
+	referenced, err := r.referencedSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	owner := caOwner(metadata)
+	current := map[caSecretRef]string{}
+	kept := r.owned[:0]
+	for _, ca := range r.owned {
+		if !maps.Equal(ca.owner, owner) {
+			kept = append(kept, ca)
+			continue
+		}
+
+		if referenced[ca.ref] {
+			pem, ok := current[ca.ref]
+			if !ok {
+				pem, err = r.secretResolver.GetSecret(ctx, ca.ref.namespace, ca.ref.name, ca.ref.key)
+				if err != nil {
+					// The edges referencing it will fail to reconcile until the Secret is fixed, keep the
+					// Certificate Authority in case it comes back unchanged
+					kept = append(kept, ca)
+					continue
+				}
+				pem = strings.TrimSpace(pem)
+				current[ca.ref] = pem
+			}
+			if pem == ca.pem {
+				kept = append(kept, ca)
+				continue
+			}
+		}
+
+		log.Info("Deleting unused Certificate Authority", "id", ca.id, "secret", ca.ref.name, "key", ca.ref.key)
+		if err := r.client.Delete(ctx, ca.id); err != nil && !ngrok.IsNotFound(err) {
+			log.V(1).Info("Unable to delete Certificate Authority, will retry", "id", ca.id, "error", err.Error())
+			kept = append(kept, ca)
+		}
+	}
+	r.owned = kept
+
+	return nil
+}
+
+// load lists the Certificate Authorities created by the controller, the first time it's called
+func (r *CertificateAuthorityResolver) load(ctx context.Context) error {
+	if r.loaded {
+		return nil
+	}
+
+	owned := []*ownedCertificateAuthority{}
+	iter := r.client.List(&ngrok.Paging{})
+	for iter.Next(ctx) {
+		if ca := parseOwnedCertificateAuthority(iter.Item()); ca != nil {
+			owned = append(owned, ca)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	r.owned = owned
+	r.loaded = true
+	return nil
+}
+
+func (r *CertificateAuthorityResolver) find(owner map[string]string, ref caSecretRef, pem string) *ownedCertificateAuthority {
+	for _, ca := range r.owned {
+		if ca.ref == ref && ca.pem == pem && maps.Equal(ca.owner, owner) {
+			return ca
+		}
+	}
+	return nil
+}
+
+// referencedSecrets returns the Secret keys referenced by the mutual TLS modules of the HTTPS and TLS edges
+// that aren't being deleted
+func (r *CertificateAuthorityResolver) referencedSecrets(ctx context.Context) (map[caSecretRef]bool, error) {
+	referenced := map[caSecretRef]bool{}
+	add := func(namespace string, mutualTLS *ingressv1alpha1.EndpointMutualTLS) {
+		if mutualTLS == nil {
+			return
+		}
+		for _, ref := range mutualTLS.CertificateAuthoritySecrets {
+			referenced[caSecretRef{namespace: namespace, name: ref.Name, key: ref.Key}] = true
+		}
+	}
+
+	httpsEdges := &ingressv1alpha1.HTTPSEdgeList{}
+	if err := r.kube.List(ctx, httpsEdges); err != nil {
+		return nil, err
+	}
+	for _, edge := range httpsEdges.Items {
+		if edge.DeletionTimestamp.IsZero() {
+			add(edge.Namespace, edge.Spec.MutualTLS)
+		}
+	}
+
+	tlsEdges := &ingressv1alpha1.TLSEdgeList{}
+	if err := r.kube.List(ctx, tlsEdges); err != nil {
+		return nil, err
+	}
+	for _, edge := range tlsEdges.Items {
+		if edge.DeletionTimestamp.IsZero() {
+			add(edge.Namespace, edge.Spec.MutualTLS)
+		}
+	}
+
+	return referenced, nil
+}
+
+// caOwner returns the edge metadata as a map. Metadata that isn't a JSON object is kept as is under a
+// "metadata" key.
+func caOwner(metadata string) map[string]string {
+	owner := map[string]string{}
+	if metadata == "" {
+		return owner
+	}
+	if err := json.Unmarshal([]byte(metadata), &owner); err != nil {
+		return map[string]string{"metadata": metadata}
+	}
+	delete(owner, caSecretNamespaceMetadataKey)
+	delete(owner, caSecretNameMetadataKey)
+	delete(owner, caSecretKeyMetadataKey)
+	return owner
+}
+
+// caMetadata returns the metadata of a Certificate Authority created for ref
+func caMetadata(owner map[string]string, ref caSecretRef) map[string]string {
+	metadata := maps.Clone(owner)
+	metadata[caSecretNamespaceMetadataKey] = ref.namespace
+	metadata[caSecretNameMetadataKey] = ref.name
+	metadata[caSecretKeyMetadataKey] = ref.key
+	return metadata
+}
+
+// parseOwnedCertificateAuthority returns the Certificate Authority if it was created by the controller,
+// based on its metadata, or nil otherwise
+func parseOwnedCertificateAuthority(ca *ngrok.CertificateAuthority) *ownedCertificateAuthority {
+	metadata := map[string]string{}
+	if err := json.Unmarshal([]byte(ca.Metadata), &metadata); err != nil {
+		return nil
+	}
+
+	ref := caSecretRef{
+		namespace: metadata[caSecretNamespaceMetadataKey],
+		name:      metadata[caSecretNameMetadataKey],
+		key:       metadata[caSecretKeyMetadataKey],
+	}
+	if ref.namespace == "" || ref.name == "" || ref.key == "" {
+		return nil
+	}
+
+	return &ownedCertificateAuthority{
+		id:    ca.ID,
+		pem:   strings.TrimSpace(ca.CAPEM),
+		ref:   ref,
+		owner: caOwner(ca.Metadata),
+	}
+}
+
+// refIDs returns the IDs of refs
+func refIDs(refs []ngrok.Ref) []string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+	}
+	return ids
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/ngrokapi"
+	"github.com/ngrok/ngrok-api-go/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeCertificateAuthorityAPI implements the certificate authorities endpoints of the ngrok API
+type fakeCertificateAuthorityAPI struct {
+	mu    sync.Mutex
+	cas   map[string]ngrok.CertificateAuthority
+	lists int
+	next  int
+}
+
+func (f *fakeCertificateAuthorityAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/certificate_authorities":
+		// Everything fits on the first page, the iterator stops at the empty page after it
+		list := ngrok.CertificateAuthorityList{CertificateAuthorities: []ngrok.CertificateAuthority{}}
+		if r.URL.Query().Get("before_id") == "" {
+			f.lists++
+			for _, ca := range f.cas {
+				list.CertificateAuthorities = append(list.CertificateAuthorities, ca)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPost && r.URL.Path == "/certificate_authorities":
+		create := ngrok.CertificateAuthorityCreate{}
+		_ = json.NewDecoder(r.Body).Decode(&create)
+		f.next++
+		ca := ngrok.CertificateAuthority{
+			ID:       fmt.Sprintf("ca_%d", f.next),
+			Metadata: create.Metadata,
+			CAPEM:    create.CAPEM,
+		}
+		f.cas[ca.ID] = ca
+		_ = json.NewEncoder(w).Encode(ca)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/certificate_authorities/"):
+		delete(f.cas, strings.TrimPrefix(r.URL.Path, "/certificate_authorities/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeCertificateAuthorityAPI) ids() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := []string{}
+	for id := range f.cas {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func testCAPEM() string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+var _ = Describe("CertificateAuthorityResolver", func() {
+	const metadata = `{"owned-by":"kubernetes-ingress-controller"}`
+
+	var (
+		ctx      context.Context
+		api      *fakeCertificateAuthorityAPI
+		kube     client.Client
+		resolver *CertificateAuthorityResolver
+		secret   *corev1.Secret
+		edge     *ingressv1alpha1.HTTPSEdge
+	)
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		api = &fakeCertificateAuthorityAPI{cas: map[string]ngrok.CertificateAuthority{}}
+		server := httptest.NewServer(api)
+		DeferCleanup(server.Close)
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "client-ca", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"ca.crt": []byte(testCAPEM())},
+		}
+		edge = &ingressv1alpha1.HTTPSEdge{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge", Namespace: "test-namespace"},
+			Spec: ingressv1alpha1.HTTPSEdgeSpec{
+				MutualTLS: &ingressv1alpha1.EndpointMutualTLS{
+					CertificateAuthoritySecrets: []ingressv1alpha1.SecretKeyRef{{Name: "client-ca", Key: "ca.crt"}},
+				},
+			},
+		}
+		kube = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, edge).Build()
+
+		clientset := ngrokapi.NewClientSet(ngrok.NewClientConfig("api-key", ngrok.WithBaseURL(server.URL)))
+		resolver = NewCertificateAuthorityResolver(clientset, kube)
+	})
+
+	It("creates a certificate authority tagged with its secret and reuses it", func() {
+		ids, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(HaveLen(1))
+
+		ca := api.cas[ids[0]]
+		Expect(ca.Metadata).To(MatchJSON(`{
+			"owned-by": "kubernetes-ingress-controller",
+			"secret-namespace": "test-namespace",
+			"secret-name": "client-ca",
+			"secret-key": "ca.crt"
+		}`))
+
+		again, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again).To(Equal(ids))
+		Expect(api.lists).To(Equal(1))
+	})
+
+	It("adopts certificate authorities it created before restarting", func() {
+		ids, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+
+		restarted := &CertificateAuthorityResolver{client: resolver.client, kube: kube, secretResolver: resolver.secretResolver}
+		again, err := restarted.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again).To(Equal(ids))
+		Expect(api.ids()).To(HaveLen(1))
+	})
+
+	It("doesn't adopt certificate authorities created with other metadata", func() {
+		_, err := resolver.resolveIDs(ctx, edge.Namespace, `{"owned-by":"other-cluster"}`, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(api.ids()).To(HaveLen(2))
+
+		Expect(kube.Delete(ctx, edge)).To(Succeed())
+		Expect(resolver.DeleteUnused(ctx, metadata)).To(Succeed())
+		Expect(api.ids()).To(HaveLen(1))
+	})
+
+	It("deletes the certificate authority when its secret changes", func() {
+		ids, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+
+		secret.Data["ca.crt"] = []byte(testCAPEM())
+		Expect(kube.Update(ctx, secret)).To(Succeed())
+		rotated, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rotated).ToNot(Equal(ids))
+
+		Expect(resolver.DeleteUnused(ctx, metadata)).To(Succeed())
+		Expect(api.ids()).To(ConsistOf(rotated))
+	})
+
+	It("deletes the certificate authority once no edge references its secret", func() {
+		_, err := resolver.resolveIDs(ctx, edge.Namespace, metadata, edge.Spec.MutualTLS)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resolver.DeleteUnused(ctx, metadata)).To(Succeed())
+		Expect(api.ids()).To(HaveLen(1))
+
+		edge.Spec.MutualTLS = nil
+		Expect(kube.Update(ctx, edge)).To(Succeed())
+		Expect(resolver.DeleteUnused(ctx, metadata)).To(Succeed())
+		Expect(api.ids()).To(BeEmpty())
+		Expect(api.lists).To(Equal(1))
+	})
+})
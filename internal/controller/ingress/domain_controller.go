@@ -29,6 +29,7 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -64,11 +65,12 @@ func (r *DomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Log:      r.Log,
 		Recorder: r.Recorder,
 
-		kubeType: "v1alpha1.Domain",
-		statusID: func(cr *ingressv1alpha1.Domain) string { return cr.Status.ID },
-		create:   r.create,
-		update:   r.update,
-		delete:   r.delete,
+		kubeType:   "v1alpha1.Domain",
+		statusID:   func(cr *ingressv1alpha1.Domain) string { return cr.Status.ID },
+		conditions: func(cr *ingressv1alpha1.Domain) *[]metav1.Condition { return &cr.Status.Conditions },
+		create:     r.create,
+		update:     r.update,
+		delete:     r.delete,
 		errResult: func(op baseControllerOp, cr *ingressv1alpha1.Domain, err error) (reconcile.Result, error) {
 			// Domain still attached to an edge, probably a race condition.
 			// Schedule for retry, and hopefully the edge will be gone
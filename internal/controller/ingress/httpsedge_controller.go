@@ -34,12 +34,15 @@ import (
 	"golang.org/x/exp/slices"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
@@ -49,6 +52,7 @@ import (
 	"github.com/ngrok/kubernetes-ingress-controller/internal/ngrokapi"
 	"github.com/ngrok/ngrok-api-go/v5"
 	"github.com/ngrok/ngrok-api-go/v5/backends/tunnel_group"
+	"github.com/ngrok/ngrok-api-go/v5/backends/weighted"
 )
 
 type routeModuleComparision string
@@ -68,6 +72,9 @@ type HTTPSEdgeReconciler struct {
 	Recorder record.EventRecorder
 
 	NgrokClientset ngrokapi.Clientset
+	// CAResolver creates the Certificate Authorities of mutual TLS modules. It's shared with the other edge
+	// controllers so they agree on which Certificate Authorities are still in use.
+	CAResolver *CertificateAuthorityResolver
 
 	controller *baseController[*ingressv1alpha1.HTTPSEdge]
 }
@@ -79,11 +86,12 @@ func (r *HTTPSEdgeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Log:      r.Log,
 		Recorder: r.Recorder,
 
-		kubeType: "v1alpha1.HTTPSEdge",
-		statusID: func(cr *ingressv1alpha1.HTTPSEdge) string { return cr.Status.ID },
-		create:   r.create,
-		update:   r.update,
-		delete:   r.delete,
+		kubeType:   "v1alpha1.HTTPSEdge",
+		statusID:   func(cr *ingressv1alpha1.HTTPSEdge) string { return cr.Status.ID },
+		conditions: func(cr *ingressv1alpha1.HTTPSEdge) *[]metav1.Condition { return &cr.Status.Conditions },
+		create:     r.create,
+		update:     r.update,
+		delete:     r.delete,
 		errResult: func(op baseControllerOp, cr *ingressv1alpha1.HTTPSEdge, err error) (ctrl.Result, error) {
 			if errors.As(err, &ierr.ErrInvalidConfiguration{}) {
 				return ctrl.Result{}, nil
@@ -96,14 +104,18 @@ func (r *HTTPSEdgeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ingressv1alpha1.HTTPSEdge{}).
-		WithEventFilter(commonPredicateFilters).
+		For(&ingressv1alpha1.HTTPSEdge{}, builder.WithPredicates(commonPredicateFilters)).
+		// Reload route policies and mutual TLS certificate authorities when the ConfigMaps and Secrets they
+		// reference change. These have no generation, so their updates can't go through commonPredicateFilters.
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.findHTTPSEdgesForConfigMap)).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findHTTPSEdgesForSecret)).
 		Complete(r)
 }
 
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=httpsedges,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=httpsedges/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=httpsedges/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -168,6 +180,10 @@ func (r *HTTPSEdgeReconciler) upsert(ctx context.Context, edge *ingressv1alpha1.
 		return err
 	}
 
+	if err := r.setEdgeMutualTLS(ctx, edge, remoteEdge); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -176,7 +192,13 @@ func (r *HTTPSEdgeReconciler) delete(ctx context.Context, edge *ingressv1alpha1.
 	if err == nil || ngrok.IsNotFound(err) {
 		edge.Status.ID = ""
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if edge.Spec.MutualTLS == nil {
+		return nil
+	}
+	return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
 }
 
 // TODO: This is going to be a bit messy right now, come back and make this cleaner
@@ -188,12 +210,17 @@ func (r *HTTPSEdgeReconciler) reconcileRoutes(ctx context.Context, edge *ingress
 	if err != nil {
 		return err
 	}
+	weightedReconciler, err := newWeightedBackendReconciler(r.NgrokClientset.WeightedBackends())
+	if err != nil {
+		return err
+	}
 
 	routeModuleUpdater := &edgeRouteModuleUpdater{
-		edge:             edge,
-		clientset:        r.NgrokClientset.EdgeModules().HTTPS().Routes(),
-		ipPolicyResolver: controllers.IpPolicyResolver{Client: r.Client},
-		secretResolver:   controllers.SecretResolver{Client: r.Client},
+		edge:              edge,
+		clientset:         r.NgrokClientset.EdgeModules().HTTPS().Routes(),
+		ipPolicyResolver:  controllers.IpPolicyResolver{Client: r.Client},
+		secretResolver:    controllers.SecretResolver{Client: r.Client},
+		configMapResolver: controllers.ConfigMapResolver{Client: r.Client},
 	}
 
 	edgeRoutes := r.NgrokClientset.HTTPSEdgeRoutes()
@@ -272,11 +299,21 @@ func (r *HTTPSEdgeReconciler) reconcileRoutes(ctx context.Context, edge *ingress
 		}
 
 		// The route modules were successfully applied, so now we update the route with its specified backend
-		backend, err := tunnelGroupReconciler.findOrCreate(routeCtx, routeSpec.Backend)
-		if err != nil {
-			return err
+		var backendID string
+		if len(routeSpec.WeightedBackends) > 0 {
+			backend, err := weightedReconciler.findOrCreate(routeCtx, tunnelGroupReconciler, routeSpec.WeightedBackends)
+			if err != nil {
+				return err
+			}
+			backendID = backend.ID
+		} else {
+			backend, err := tunnelGroupReconciler.findOrCreate(routeCtx, routeSpec.Backend)
+			if err != nil {
+				return err
+			}
+			backendID = backend.ID
 		}
-		routeLog.Info("Updating route", "ngrok.backend.id", backend.ID)
+		routeLog.Info("Updating route", "ngrok.backend.id", backendID)
 
 		// TODO: Do an entropy check here to avoid unnecessary updates
 		req := &ngrok.HTTPSEdgeRouteUpdate{
@@ -285,7 +322,7 @@ func (r *HTTPSEdgeReconciler) reconcileRoutes(ctx context.Context, edge *ingress
 			Match:     routeSpec.Match,
 			MatchType: routeSpec.MatchType,
 			Backend: &ngrok.EndpointBackendMutate{
-				BackendID: backend.ID,
+				BackendID: backendID,
 			},
 		}
 		route, err = edgeRoutes.Update(routeCtx, req)
@@ -349,6 +386,47 @@ func (r *HTTPSEdgeReconciler) setEdgeTLSTermination(ctx context.Context, edge *n
 	return err
 }
 
+func (r *HTTPSEdgeReconciler) setEdgeMutualTLS(ctx context.Context, edge *ingressv1alpha1.HTTPSEdge, remoteEdge *ngrok.HTTPSEdge) error {
+	log := ctrl.LoggerFrom(ctx)
+	mutualTLS := edge.Spec.MutualTLS
+
+	client := r.NgrokClientset.EdgeModules().HTTPS().MutualTLS()
+	if mutualTLS == nil {
+		if remoteEdge.MutualTls == nil {
+			log.V(1).Info("Edge Mutual TLS matches spec")
+			return nil
+		}
+
+		log.Info("Deleting Edge Mutual TLS")
+		if err := client.Delete(ctx, remoteEdge.ID); err != nil {
+			return err
+		}
+		return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
+	}
+
+	caIDs, err := r.CAResolver.resolveIDs(ctx, edge.Namespace, edge.Spec.Metadata, mutualTLS)
+	if err != nil {
+		return err
+	}
+
+	if remoteEdge.MutualTls != nil && slices.Equal(caIDs, refIDs(remoteEdge.MutualTls.CertificateAuthorities)) {
+		log.V(1).Info("Edge Mutual TLS matches spec")
+		return nil
+	}
+
+	log.Info("Updating Edge Mutual TLS", "certificateAuthorities", caIDs)
+	_, err = client.Replace(ctx, &ngrok.EdgeMutualTLSReplace{
+		ID: remoteEdge.ID,
+		Module: ngrok.EndpointMutualTLSMutate{
+			CertificateAuthorityIDs: caIDs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
+}
+
 func (r *HTTPSEdgeReconciler) findEdgeByHostports(ctx context.Context, hostports []string) (*ngrok.HTTPSEdge, error) {
 	iter := r.NgrokClientset.HTTPSEdges().List(&ngrok.Paging{})
 	for iter.Next(ctx) {
@@ -460,6 +538,51 @@ func (r *HTTPSEdgeReconciler) listHTTPSEdgesForIPPolicy(obj client.Object) []rec
 	return recs
 }
 
+// findHTTPSEdgesForConfigMap returns reconcile requests for the edges with a route whose traffic policy is in
+// the ConfigMap
+func (r *HTTPSEdgeReconciler) findHTTPSEdgesForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	edges := &ingressv1alpha1.HTTPSEdgeList{}
+	if err := r.Client.List(ctx, edges, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list HTTPSEdges", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, edge := range edges.Items {
+		for _, route := range edge.Spec.Routes {
+			if route.Policy == nil && route.PolicyConfigMapRef != nil && route.PolicyConfigMapRef.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&edge)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// findHTTPSEdgesForSecret returns reconcile requests for the edges whose mutual TLS certificate authorities are
+// in the Secret
+func (r *HTTPSEdgeReconciler) findHTTPSEdgesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	edges := &ingressv1alpha1.HTTPSEdgeList{}
+	if err := r.Client.List(ctx, edges, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list HTTPSEdges", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, edge := range edges.Items {
+		if edge.Spec.MutualTLS == nil {
+			continue
+		}
+		for _, ref := range edge.Spec.MutualTLS.CertificateAuthoritySecrets {
+			if ref.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&edge)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
 // Tunnel Group Backend planner
 type tunnelGroupBackendReconciler struct {
 	client   *tunnel_group.Client
@@ -504,13 +627,66 @@ func (r *tunnelGroupBackendReconciler) findOrCreate(ctx context.Context, backend
 	return be, nil
 }
 
+// Weighted Backend planner
+type weightedBackendReconciler struct {
+	client   *weighted.Client
+	backends []*ngrok.WeightedBackend
+}
+
+func newWeightedBackendReconciler(client *weighted.Client) (*weightedBackendReconciler, error) {
+	backends := make([]*ngrok.WeightedBackend, 0)
+	iter := client.List(&ngrok.Paging{})
+	for iter.Next(context.Background()) {
+		backends = append(backends, iter.Item())
+	}
+
+	return &weightedBackendReconciler{
+		client:   client,
+		backends: backends,
+	}, iter.Err()
+}
+
+// findOrCreate finds or creates a tunnel group backend for each of the weighted backends, and then a weighted
+// backend splitting traffic between them
+func (r *weightedBackendReconciler) findOrCreate(ctx context.Context, tunnelGroups *tunnelGroupBackendReconciler, weightedBackends []ingressv1alpha1.WeightedTunnelGroupBackend) (*ngrok.WeightedBackend, error) {
+	weights := make(map[string]int64, len(weightedBackends))
+	for _, wb := range weightedBackends {
+		be, err := tunnelGroups.findOrCreate(ctx, ingressv1alpha1.TunnelGroupBackend{Labels: wb.Labels})
+		if err != nil {
+			return nil, err
+		}
+		weights[be.ID] = wb.Weight
+	}
+
+	log := ctrl.LoggerFrom(ctx).WithValues("backend.weights", weights)
+	log.V(3).Info("Searching for weighted backend with matching weights")
+	for _, b := range r.backends {
+		if maps.Equal(b.Backends, weights) {
+			log.V(3).Info("Found matching weighted backend", "id", b.ID)
+			return b, nil
+		}
+	}
+
+	log.V(3).Info("No matching weighted backend found, creating a new one")
+	be, err := r.client.Create(ctx, &ngrok.WeightedBackendCreate{
+		Backends: weights,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.V(3).Info("Created new weighted backend", "id", be.ID)
+	r.backends = append(r.backends, be)
+	return be, nil
+}
+
 type edgeRouteModuleUpdater struct {
 	edge *ingressv1alpha1.HTTPSEdge
 
 	clientset ngrokapi.HTTPSEdgeRouteModulesClientset
 
-	ipPolicyResolver controllers.IpPolicyResolver
-	secretResolver   controllers.SecretResolver
+	ipPolicyResolver  controllers.IpPolicyResolver
+	secretResolver    controllers.SecretResolver
+	configMapResolver controllers.ConfigMapResolver
 }
 
 func (u *edgeRouteModuleUpdater) updateModulesForRoute(ctx context.Context, route *ngrok.HTTPSEdgeRoute, routeSpec *ingressv1alpha1.HTTPSEdgeRouteSpec) error {
@@ -524,6 +700,7 @@ func (u *edgeRouteModuleUpdater) updateModulesForRoute(ctx context.Context, rout
 		u.setEdgeRouteOIDC,
 		u.setEdgeRouteSAML,
 		u.setEdgeRouteWebhookVerification,
+		u.setEdgeRouteWebsocketTCPConverter,
 		u.setEdgeRoutePolicy,
 	}
 
@@ -613,6 +790,41 @@ func (u *edgeRouteModuleUpdater) setEdgeRouteCompression(ctx context.Context, ro
 	return err
 }
 
+func (u *edgeRouteModuleUpdater) setEdgeRouteWebsocketTCPConverter(ctx context.Context, route *ngrok.HTTPSEdgeRoute, routeSpec *ingressv1alpha1.HTTPSEdgeRouteSpec) error {
+	log := ctrl.LoggerFrom(ctx)
+	converter := routeSpec.WebsocketTCPConverter
+
+	client := u.clientset.WebsocketTCPConverter()
+
+	// Early return if nothing to be done
+	if converter == nil {
+		if route.WebsocketTCPConverter == nil {
+			u.logMatches(log, "WebsocketTCPConverter", routeModuleComparisonBothNil)
+			return nil
+		}
+
+		log.Info("Deleting WebsocketTCPConverter module")
+		return client.Delete(ctx, edgeRouteItem(route))
+	}
+
+	module := ngrok.EndpointWebsocketTCPConverter{
+		Enabled: ptr.To(converter.Enabled),
+	}
+
+	if reflect.DeepEqual(&module, route.WebsocketTCPConverter) {
+		u.logMatches(log, "WebsocketTCPConverter", routeModuleComparisonDeepEqual)
+		return nil
+	}
+
+	log.Info("Updating WebsocketTCPConverter", "module", module)
+	_, err := client.Replace(ctx, &ngrok.EdgeRouteWebsocketTCPConverterReplace{
+		EdgeID: route.EdgeID,
+		ID:     route.ID,
+		Module: module,
+	})
+	return err
+}
+
 func (u *edgeRouteModuleUpdater) setEdgeRouteIPRestriction(ctx context.Context, route *ngrok.HTTPSEdgeRoute, routeSpec *ingressv1alpha1.HTTPSEdgeRouteSpec) error {
 	log := ctrl.LoggerFrom(ctx)
 	ipRestriction := routeSpec.IPRestriction
@@ -952,6 +1164,20 @@ func (u *edgeRouteModuleUpdater) getSecret(ctx context.Context, secretRef ingres
 	return &secret, err
 }
 
+// getConfigMapPolicy reads the traffic policy from a ConfigMap in the edge's namespace
+func (u *edgeRouteModuleUpdater) getConfigMapPolicy(ctx context.Context, ref ingressv1alpha1.ConfigMapKeyRef) (*ingressv1alpha1.EndpointPolicy, error) {
+	data, err := u.configMapResolver.GetConfigMap(ctx, u.edge.Namespace, ref.Name, ref.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ingressv1alpha1.ParseEndpointPolicy([]byte(data))
+	if err != nil {
+		return nil, ierr.NewErrInvalidConfiguration(fmt.Errorf("invalid traffic policy in configmap '%s/%s' key '%s': %w", u.edge.Namespace, ref.Name, ref.Key, err))
+	}
+	return policy, nil
+}
+
 type OAuthProvider interface {
 	ClientSecretKeyRef() *ingressv1alpha1.SecretKeyRef
 	ToNgrok(*string) *ngrok.EndpointOAuth
@@ -1036,6 +1262,13 @@ func (u *edgeRouteModuleUpdater) setEdgeRoutePolicy(ctx context.Context, route *
 	policy := routeSpec.Policy
 	client := u.clientset.Policy()
 
+	if policy == nil && routeSpec.PolicyConfigMapRef != nil {
+		var err error
+		if policy, err = u.getConfigMapPolicy(ctx, *routeSpec.PolicyConfigMapRef); err != nil {
+			return err
+		}
+	}
+
 	endpointPolicy := policy.ToNgrok()
 
 	// Early return if nothing to be done
@@ -37,6 +37,7 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		&ingressv1alpha1.HTTPSEdge{},
 		&ingressv1alpha1.Tunnel{},
 		&ingressv1alpha1.NgrokModuleSet{},
+		&ingressv1alpha1.ClusterNgrokModuleSet{},
 	}
 
 	builder := ctrl.NewControllerManagedBy(mgr).For(&netv1.Ingress{})
@@ -57,6 +58,7 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/status,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingressclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=ngrokmodulesets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=clusterngrokmodulesets,verbs=get;list;watch
 
 // This reconcile function is called by the controller-runtime manager.
 // It is invoked whenever there is an event that occurs for a resource
@@ -77,12 +79,7 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			return ctrl.Result{}, err
 		}
 
-		err = r.Driver.Sync(ctx, r.Client)
-		if err != nil {
-			log.Error(err, "Failed to sync after removing ingress from store")
-			return ctrl.Result{}, err
-		}
-
+		r.Driver.MarkDirty()
 		return ctrl.Result{}, nil
 	default:
 		return ctrl.Result{}, err
@@ -111,6 +108,12 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			log.Error(err, "Failed to register finalizer")
 			return ctrl.Result{}, err
 		}
+
+		// The driver skips the rules of an ingress with invalid security annotations, let the user know why
+		if _, err := r.AnnotationsExtractor.Extract(ingress); err != nil {
+			log.Error(err, "Ingress has invalid security annotations, its rules are not published")
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, "InvalidAnnotations", err.Error())
+		}
 	} else {
 		log.Info("Deleting ingress from store")
 		if controllers.HasFinalizer(ingress) {
@@ -126,10 +129,6 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	err = r.Driver.Sync(ctx, r.Client)
-	if err != nil {
-		log.Error(err, "Failed to sync")
-	}
-
-	return ctrl.Result{}, err
+	r.Driver.MarkDirty()
+	return ctrl.Result{}, nil
 }
@@ -29,6 +29,7 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
@@ -75,11 +76,12 @@ func (r *IPPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Log:      r.Log,
 		Recorder: r.Recorder,
 
-		kubeType: "v1alpha1.IPPolicy",
-		statusID: func(cr *ingressv1alpha1.IPPolicy) string { return cr.Status.ID },
-		create:   r.create,
-		update:   r.update,
-		delete:   r.delete,
+		kubeType:   "v1alpha1.IPPolicy",
+		statusID:   func(cr *ingressv1alpha1.IPPolicy) string { return cr.Status.ID },
+		conditions: func(cr *ingressv1alpha1.IPPolicy) *[]metav1.Condition { return &cr.Status.Conditions },
+		create:     r.create,
+		update:     r.update,
+		delete:     r.delete,
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
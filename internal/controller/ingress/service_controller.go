@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/store"
+)
+
+const (
+	// NgrokLoadBalancerClass is the spec.loadBalancerClass of the Services this controller exposes
+	NgrokLoadBalancerClass = "ngrok"
+
+	// annotationServiceDomain, when set on a LoadBalancer Service, exposes it with a TLSEdge on the
+	// given domain instead of a TCPEdge on a reserved TCP address
+	annotationServiceDomain = "k8s.ngrok.com/domain"
+)
+
+// ServiceReconciler exposes Services of type LoadBalancer with the ngrok loadBalancerClass. Each one
+// gets a Tunnel and either a TCPEdge, or a TLSEdge when annotated with a domain. Once the edge has
+// an address, it is written back to the Service's load balancer status.
+type ServiceReconciler struct {
+	client.Client
+
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Service{}, builder.WithPredicates(ngrokLoadBalancerPredicate)).
+		Owns(&ingressv1alpha1.TCPEdge{}).
+		Owns(&ingressv1alpha1.TLSEdge{}).
+		Owns(&ingressv1alpha1.Tunnel{}).
+		Complete(r)
+}
+
+// ngrokLoadBalancerPredicate filters out Services that are not, and were not, ngrok LoadBalancers.
+// Updates are let through when either side is one so that we can clean up after a Service stops
+// being a ngrok LoadBalancer.
+var ngrokLoadBalancerPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return isNgrokLoadBalancer(e.Object)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isNgrokLoadBalancer(e.ObjectOld) || isNgrokLoadBalancer(e.ObjectNew)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return isNgrokLoadBalancer(e.Object)
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return isNgrokLoadBalancer(e.Object)
+	},
+}
+
+func isNgrokLoadBalancer(obj client.Object) bool {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return false
+	}
+	return svc.Spec.Type == corev1.ServiceTypeLoadBalancer &&
+		svc.Spec.LoadBalancerClass != nil &&
+		*svc.Spec.LoadBalancerClass == NgrokLoadBalancerClass
+}
+
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tcpedges,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tlsedges,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=domains,verbs=get;list;watch;create
+
+// Reconcile creates or updates the edge and tunnel for a ngrok LoadBalancer Service, and removes
+// them once the Service no longer is one. The edge and tunnel are owned by the Service, so they are
+// garbage collected when it is deleted.
+func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("service", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	svc := &corev1.Service{}
+	if err := r.Client.Get(ctx, req.NamespacedName, svc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !isNgrokLoadBalancer(svc) || svc.DeletionTimestamp != nil {
+		log.V(1).Info("Service is not a ngrok LoadBalancer, cleaning up")
+		return ctrl.Result{}, r.cleanup(ctx, svc)
+	}
+
+	if len(svc.Spec.Ports) != 1 {
+		r.Recorder.Eventf(svc, corev1.EventTypeWarning, "UnsupportedPorts", "ngrok LoadBalancer Services must have exactly 1 port, found %d", len(svc.Spec.Ports))
+		return ctrl.Result{}, r.cleanup(ctx, svc)
+	}
+	port := svc.Spec.Ports[0]
+	if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+		r.Recorder.Eventf(svc, corev1.EventTypeWarning, "UnsupportedProtocol", "ngrok LoadBalancer Services only support TCP, found %s", port.Protocol)
+		return ctrl.Result{}, r.cleanup(ctx, svc)
+	}
+
+	if err := r.reconcileTunnel(ctx, svc, port); err != nil {
+		if r.isConflict(svc, err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to reconcile tunnel")
+		return ctrl.Result{}, err
+	}
+
+	var hostports []string
+	if domain := svc.Annotations[annotationServiceDomain]; domain != "" {
+		edge, err := r.reconcileTLSEdge(ctx, svc, port, domain)
+		if err != nil {
+			if r.isConflict(svc, err) {
+				return ctrl.Result{}, nil
+			}
+			log.Error(err, "Failed to reconcile TLSEdge")
+			return ctrl.Result{}, err
+		}
+		if err := r.deleteOwned(ctx, svc, &ingressv1alpha1.TCPEdge{}); err != nil {
+			return ctrl.Result{}, err
+		}
+		hostports = edge.Status.Hostports
+	} else {
+		edge, err := r.reconcileTCPEdge(ctx, svc, port)
+		if err != nil {
+			if r.isConflict(svc, err) {
+				return ctrl.Result{}, nil
+			}
+			log.Error(err, "Failed to reconcile TCPEdge")
+			return ctrl.Result{}, err
+		}
+		if err := r.deleteOwned(ctx, svc, &ingressv1alpha1.TLSEdge{}); err != nil {
+			return ctrl.Result{}, err
+		}
+		hostports = edge.Status.Hostports
+	}
+
+	// The edge status is filled in asynchronously by the edge controllers, we own the edges so we
+	// will be reconciled again once they have an address
+	return ctrl.Result{}, r.updateStatus(ctx, svc, loadBalancerIngressFromHostports(hostports))
+}
+
+func (r *ServiceReconciler) reconcileTunnel(ctx context.Context, svc *corev1.Service, port corev1.ServicePort) error {
+	tunnel := &ingressv1alpha1.Tunnel{
+		ObjectMeta: metav1.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, tunnel, func() error {
+		if err := checkControlledBy(svc, tunnel); err != nil {
+			return err
+		}
+		tunnel.Spec.ForwardsTo = store.ServiceAddress(svc.Name, svc.Namespace, port.Port)
+		tunnel.Spec.Labels = serviceBackendLabels(svc, port)
+		return controllerutil.SetControllerReference(svc, tunnel, r.Scheme)
+	})
+	return err
+}
+
+func (r *ServiceReconciler) reconcileTCPEdge(ctx context.Context, svc *corev1.Service, port corev1.ServicePort) (*ingressv1alpha1.TCPEdge, error) {
+	edge := &ingressv1alpha1.TCPEdge{
+		ObjectMeta: metav1.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, edge, func() error {
+		if err := checkControlledBy(svc, edge); err != nil {
+			return err
+		}
+		edge.Spec.Backend.Labels = serviceBackendLabels(svc, port)
+		return controllerutil.SetControllerReference(svc, edge, r.Scheme)
+	})
+	return edge, err
+}
+
+func (r *ServiceReconciler) reconcileTLSEdge(ctx context.Context, svc *corev1.Service, port corev1.ServicePort, domain string) (*ingressv1alpha1.TLSEdge, error) {
+	if err := r.ensureDomain(ctx, svc, domain); err != nil {
+		return nil, err
+	}
+
+	edge := &ingressv1alpha1.TLSEdge{
+		ObjectMeta: metav1.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, edge, func() error {
+		if err := checkControlledBy(svc, edge); err != nil {
+			return err
+		}
+		edge.Spec.Backend.Labels = serviceBackendLabels(svc, port)
+		edge.Spec.Hostports = []string{net.JoinHostPort(domain, "443")}
+		return controllerutil.SetControllerReference(svc, edge, r.Scheme)
+	})
+	return edge, err
+}
+
+// resourceConflictError is returned when an object the Service would be exposed with already exists and
+// isn't controlled by it
+type resourceConflictError struct {
+	kind string
+	name string
+}
+
+func (e *resourceConflictError) Error() string {
+	return fmt.Sprintf("%s %q already exists and is not controlled by the service", e.kind, e.name)
+}
+
+// checkControlledBy returns a resourceConflictError if obj exists and the Service doesn't control it, so that
+// objects created by users, or by other controllers, are never taken over
+func checkControlledBy(svc *corev1.Service, obj client.Object) error {
+	if obj.GetResourceVersion() == "" || metav1.IsControlledBy(obj, svc) {
+		return nil
+	}
+	return &resourceConflictError{kind: reflect.TypeOf(obj).Elem().Name(), name: obj.GetName()}
+}
+
+// isConflict records an event on the Service if err is a resourceConflictError, and reports whether it is one.
+// Conflicts aren't retried, the conflicting object isn't watched so the Service is only reconciled again once it changes.
+func (r *ServiceReconciler) isConflict(svc *corev1.Service, err error) bool {
+	conflict := &resourceConflictError{}
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	r.Recorder.Eventf(svc, corev1.EventTypeWarning, "ResourceConflict", "Not exposing service: %s", conflict.Error())
+	return true
+}
+
+// ensureDomain makes sure the domain for a TLSEdge is reserved. Like the domains the store driver
+// creates for ingresses, it is not owned by the Service and never deleted, so that DNS doesn't
+// have to be set up again.
+func (r *ServiceReconciler) ensureDomain(ctx context.Context, svc *corev1.Service, domain string) error {
+	d := &ingressv1alpha1.Domain{}
+	key := types.NamespacedName{Name: strings.ReplaceAll(domain, ".", "-"), Namespace: svc.Namespace}
+	err := r.Client.Get(ctx, key, d)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	d = &ingressv1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Spec:       ingressv1alpha1.DomainSpec{Domain: domain},
+	}
+	return r.Client.Create(ctx, d)
+}
+
+// cleanup removes the edges and tunnel of a Service that is no longer a ngrok LoadBalancer
+func (r *ServiceReconciler) cleanup(ctx context.Context, svc *corev1.Service) error {
+	for _, obj := range []client.Object{&ingressv1alpha1.TCPEdge{}, &ingressv1alpha1.TLSEdge{}, &ingressv1alpha1.Tunnel{}} {
+		if err := r.deleteOwned(ctx, svc, obj); err != nil {
+			return err
+		}
+	}
+
+	if svc.DeletionTimestamp != nil {
+		return nil
+	}
+	return r.updateStatus(ctx, svc, nil)
+}
+
+// deleteOwned deletes the object of obj's type with the same name as the Service, if the Service controls it
+func (r *ServiceReconciler) deleteOwned(ctx context.Context, svc *corev1.Service, obj client.Object) error {
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(svc), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, svc) {
+		return nil
+	}
+	r.Log.Info("Deleting object for service", "kind", reflect.TypeOf(obj).Elem().Name(), "namespace", svc.Namespace, "name", obj.GetName())
+	return client.IgnoreNotFound(r.Client.Delete(ctx, obj))
+}
+
+func (r *ServiceReconciler) updateStatus(ctx context.Context, svc *corev1.Service, ingress []corev1.LoadBalancerIngress) error {
+	if reflect.DeepEqual(svc.Status.LoadBalancer.Ingress, ingress) {
+		return nil
+	}
+	svc.Status.LoadBalancer.Ingress = ingress
+	return r.Status().Update(ctx, svc)
+}
+
+// serviceBackendLabels returns the labels matching the tunnel group backend of the edge to the tunnel, these
+// are the same labels the store driver uses for tunnels it generates from ingresses
+func serviceBackendLabels(svc *corev1.Service, port corev1.ServicePort) map[string]string {
+	return store.NgrokLabels(svc.Namespace, string(svc.UID), svc.Name, port.Port)
+}
+
+// loadBalancerIngressFromHostports converts edge hostports, such as 1.tcp.ngrok.io:12345, into
+// load balancer ingress entries. Hostports that can't be parsed are skipped.
+func loadBalancerIngressFromHostports(hostports []string) []corev1.LoadBalancerIngress {
+	var ingress []corev1.LoadBalancerIngress
+	for _, hostport := range hostports {
+		host, portStr, err := net.SplitHostPort(hostport)
+		if err != nil {
+			continue
+		}
+		port, err := strconv.ParseInt(portStr, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		lbIngress := corev1.LoadBalancerIngress{
+			Ports: []corev1.PortStatus{{Port: int32(port), Protocol: corev1.ProtocolTCP}},
+		}
+		if net.ParseIP(host) != nil {
+			lbIngress.IP = host
+		} else {
+			lbIngress.Hostname = host
+		}
+		ingress = append(ingress, lbIngress)
+	}
+	return ingress
+}
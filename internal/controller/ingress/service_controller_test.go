@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("ServiceController", func() {
+	DescribeTable("isNgrokLoadBalancer", func(svcType corev1.ServiceType, class *string, expected bool) {
+		svc := &corev1.Service{
+			Spec: corev1.ServiceSpec{
+				Type:              svcType,
+				LoadBalancerClass: class,
+			},
+		}
+		Expect(isNgrokLoadBalancer(svc)).To(Equal(expected))
+	},
+		Entry("ngrok LoadBalancer", corev1.ServiceTypeLoadBalancer, ptr.To(NgrokLoadBalancerClass), true),
+		Entry("LoadBalancer without class", corev1.ServiceTypeLoadBalancer, nil, false),
+		Entry("LoadBalancer with other class", corev1.ServiceTypeLoadBalancer, ptr.To("other"), false),
+		Entry("ClusterIP with ngrok class", corev1.ServiceTypeClusterIP, ptr.To(NgrokLoadBalancerClass), false),
+	)
+
+	DescribeTable("loadBalancerIngressFromHostports", func(hostports []string, expected []corev1.LoadBalancerIngress) {
+		Expect(loadBalancerIngressFromHostports(hostports)).To(Equal(expected))
+	},
+		Entry("no hostports", nil, nil),
+		Entry("tcp address", []string{"1.tcp.ngrok.io:12345"}, []corev1.LoadBalancerIngress{
+			{Hostname: "1.tcp.ngrok.io", Ports: []corev1.PortStatus{{Port: 12345, Protocol: corev1.ProtocolTCP}}},
+		}),
+		Entry("ip address", []string{"10.0.0.1:443"}, []corev1.LoadBalancerIngress{
+			{IP: "10.0.0.1", Ports: []corev1.PortStatus{{Port: 443, Protocol: corev1.ProtocolTCP}}},
+		}),
+		Entry("invalid hostport is skipped", []string{"example.com", "example.com:443"}, []corev1.LoadBalancerIngress{
+			{Hostname: "example.com", Ports: []corev1.PortStatus{{Port: 443, Protocol: corev1.ProtocolTCP}}},
+		}),
+	)
+})
+
+var _ = Describe("ServiceReconciler", func() {
+	var (
+		ctx        context.Context
+		svc        *corev1.Service
+		reconciler *ServiceReconciler
+		recorder   *record.FakeRecorder
+		key        types.NamespacedName
+	)
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
+
+	build := func(objs ...client.Object) {
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objs...).
+			WithStatusSubresource(&corev1.Service{}, &ingressv1alpha1.TCPEdge{}, &ingressv1alpha1.TLSEdge{}).
+			Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &ServiceReconciler{
+			Client:   c,
+			Log:      logr.Discard(),
+			Scheme:   scheme,
+			Recorder: recorder,
+		}
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	getService := func() *corev1.Service {
+		current := &corev1.Service{}
+		Expect(reconciler.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Name: "example", Namespace: "test-namespace"}
+		svc = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "svc-uid"},
+			Spec: corev1.ServiceSpec{
+				Type:              corev1.ServiceTypeLoadBalancer,
+				LoadBalancerClass: ptr.To(NgrokLoadBalancerClass),
+				Ports:             []corev1.ServicePort{{Port: 8080, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+	})
+
+	It("creates a tunnel and a TCP edge owned by the service", func() {
+		build(svc)
+		reconcile()
+
+		tunnel := &ingressv1alpha1.Tunnel{}
+		Expect(reconciler.Get(ctx, key, tunnel)).To(Succeed())
+		Expect(tunnel.Spec.ForwardsTo).To(Equal("example.test-namespace.svc.cluster.local:8080"))
+		Expect(metav1.IsControlledBy(tunnel, svc)).To(BeTrue())
+
+		edge := &ingressv1alpha1.TCPEdge{}
+		Expect(reconciler.Get(ctx, key, edge)).To(Succeed())
+		Expect(edge.Spec.Backend.Labels).To(Equal(tunnel.Spec.Labels))
+		Expect(edge.Spec.Backend.Labels).To(HaveKeyWithValue("k8s.ngrok.com/service-uid", "svc-uid"))
+		Expect(metav1.IsControlledBy(edge, svc)).To(BeTrue())
+
+		Expect(getService().Status.LoadBalancer.Ingress).To(BeEmpty())
+	})
+
+	It("creates a domain and a TLS edge for a service with a domain", func() {
+		svc.Annotations = map[string]string{annotationServiceDomain: "example.ngrok.app"}
+		build(svc)
+		reconcile()
+
+		edge := &ingressv1alpha1.TLSEdge{}
+		Expect(reconciler.Get(ctx, key, edge)).To(Succeed())
+		Expect(edge.Spec.Hostports).To(Equal([]string{"example.ngrok.app:443"}))
+		Expect(metav1.IsControlledBy(edge, svc)).To(BeTrue())
+
+		domain := &ingressv1alpha1.Domain{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "example-ngrok-app", Namespace: key.Namespace}, domain)).To(Succeed())
+		Expect(domain.Spec.Domain).To(Equal("example.ngrok.app"))
+
+		err := reconciler.Get(ctx, key, &ingressv1alpha1.TCPEdge{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("writes the edge address into the load balancer status", func() {
+		build(svc)
+		reconcile()
+
+		edge := &ingressv1alpha1.TCPEdge{}
+		Expect(reconciler.Get(ctx, key, edge)).To(Succeed())
+		edge.Status.Hostports = []string{"1.tcp.ngrok.io:12345"}
+		Expect(reconciler.Status().Update(ctx, edge)).To(Succeed())
+		reconcile()
+
+		Expect(getService().Status.LoadBalancer.Ingress).To(Equal([]corev1.LoadBalancerIngress{
+			{Hostname: "1.tcp.ngrok.io", Ports: []corev1.PortStatus{{Port: 12345, Protocol: corev1.ProtocolTCP}}},
+		}))
+	})
+
+	It("replaces the TCP edge with a TLS edge when a domain is added", func() {
+		build(svc)
+		reconcile()
+		Expect(reconciler.Get(ctx, key, &ingressv1alpha1.TCPEdge{})).To(Succeed())
+
+		current := getService()
+		current.Annotations = map[string]string{annotationServiceDomain: "example.ngrok.app"}
+		Expect(reconciler.Update(ctx, current)).To(Succeed())
+		reconcile()
+
+		Expect(reconciler.Get(ctx, key, &ingressv1alpha1.TLSEdge{})).To(Succeed())
+		err := reconciler.Get(ctx, key, &ingressv1alpha1.TCPEdge{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("cleans up when the service is no longer a ngrok load balancer", func() {
+		build(svc)
+		reconcile()
+
+		edge := &ingressv1alpha1.TCPEdge{}
+		Expect(reconciler.Get(ctx, key, edge)).To(Succeed())
+		edge.Status.Hostports = []string{"1.tcp.ngrok.io:12345"}
+		Expect(reconciler.Status().Update(ctx, edge)).To(Succeed())
+		reconcile()
+		Expect(getService().Status.LoadBalancer.Ingress).To(HaveLen(1))
+
+		current := getService()
+		current.Spec.Type = corev1.ServiceTypeClusterIP
+		current.Spec.LoadBalancerClass = nil
+		Expect(reconciler.Update(ctx, current)).To(Succeed())
+		reconcile()
+
+		for _, obj := range []client.Object{&ingressv1alpha1.TCPEdge{}, &ingressv1alpha1.Tunnel{}} {
+			err := reconciler.Get(ctx, key, obj)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}
+		Expect(getService().Status.LoadBalancer.Ingress).To(BeEmpty())
+	})
+
+	It("cleans up when the service is being deleted", func() {
+		svc.Finalizers = []string{"example.com/finalizer"}
+		build(svc)
+		reconcile()
+		Expect(reconciler.Get(ctx, key, &ingressv1alpha1.Tunnel{})).To(Succeed())
+
+		Expect(reconciler.Delete(ctx, getService())).To(Succeed())
+		reconcile()
+
+		for _, obj := range []client.Object{&ingressv1alpha1.TCPEdge{}, &ingressv1alpha1.Tunnel{}} {
+			err := reconciler.Get(ctx, key, obj)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}
+	})
+
+	It("leaves objects it doesn't control alone", func() {
+		tunnel := &ingressv1alpha1.Tunnel{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		build(svc, tunnel)
+		reconcile()
+
+		Expect(reconciler.Get(ctx, key, &ingressv1alpha1.Tunnel{})).To(Succeed())
+	})
+
+	It("doesn't expose a service with multiple ports", func() {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Port: 9090})
+		build(svc)
+		reconcile()
+
+		Expect(recorder.Events).To(Receive(ContainSubstring("UnsupportedPorts")))
+		err := reconciler.Get(ctx, key, &ingressv1alpha1.Tunnel{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("cleans up when a port is added to an exposed service", func() {
+		build(svc)
+		reconcile()
+
+		edge := &ingressv1alpha1.TCPEdge{}
+		Expect(reconciler.Get(ctx, key, edge)).To(Succeed())
+		edge.Status.Hostports = []string{"1.tcp.ngrok.io:12345"}
+		Expect(reconciler.Status().Update(ctx, edge)).To(Succeed())
+		reconcile()
+		Expect(getService().Status.LoadBalancer.Ingress).To(HaveLen(1))
+
+		current := getService()
+		current.Spec.Ports = append(current.Spec.Ports, corev1.ServicePort{Port: 9090})
+		Expect(reconciler.Update(ctx, current)).To(Succeed())
+		reconcile()
+
+		Expect(recorder.Events).To(Receive(ContainSubstring("UnsupportedPorts")))
+		for _, obj := range []client.Object{&ingressv1alpha1.TCPEdge{}, &ingressv1alpha1.Tunnel{}} {
+			err := reconciler.Get(ctx, key, obj)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}
+		Expect(getService().Status.LoadBalancer.Ingress).To(BeEmpty())
+	})
+
+	It("doesn't take over objects it doesn't control", func() {
+		tunnel := &ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec:       ingressv1alpha1.TunnelSpec{ForwardsTo: "other.test-namespace.svc.cluster.local:80"},
+		}
+		build(svc, tunnel)
+		reconcile()
+
+		Expect(recorder.Events).To(Receive(ContainSubstring("ResourceConflict")))
+		current := &ingressv1alpha1.Tunnel{}
+		Expect(reconciler.Get(ctx, key, current)).To(Succeed())
+		Expect(current.Spec.ForwardsTo).To(Equal("other.test-namespace.svc.cluster.local:80"))
+		Expect(current.OwnerReferences).To(BeEmpty())
+
+		err := reconciler.Get(ctx, key, &ingressv1alpha1.TCPEdge{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
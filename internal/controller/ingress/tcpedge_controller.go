@@ -30,6 +30,7 @@ import (
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -70,11 +71,12 @@ func (r *TCPEdgeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Log:      r.Log,
 		Recorder: r.Recorder,
 
-		kubeType: "v1alpha1.TCPEdge",
-		statusID: func(cr *ingressv1alpha1.TCPEdge) string { return cr.Status.ID },
-		create:   r.create,
-		update:   r.update,
-		delete:   r.delete,
+		kubeType:   "v1alpha1.TCPEdge",
+		statusID:   func(cr *ingressv1alpha1.TCPEdge) string { return cr.Status.ID },
+		conditions: func(cr *ingressv1alpha1.TCPEdge) *[]metav1.Condition { return &cr.Status.Conditions },
+		create:     r.create,
+		update:     r.update,
+		delete:     r.delete,
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
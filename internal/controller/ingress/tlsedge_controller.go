@@ -30,6 +30,7 @@ import (
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -58,6 +59,9 @@ type TLSEdgeReconciler struct {
 	controllers.IpPolicyResolver
 
 	NgrokClientset ngrokapi.Clientset
+	// CAResolver creates the Certificate Authorities of mutual TLS modules. It's shared with the other edge
+	// controllers so they agree on which Certificate Authorities are still in use.
+	CAResolver *CertificateAuthorityResolver
 
 	controller *baseController[*ingressv1alpha1.TLSEdge]
 }
@@ -71,11 +75,12 @@ func (r *TLSEdgeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Log:      r.Log,
 		Recorder: r.Recorder,
 
-		kubeType: "v1alpha1.TLSEdge",
-		statusID: func(cr *ingressv1alpha1.TLSEdge) string { return cr.Status.ID },
-		create:   r.create,
-		update:   r.update,
-		delete:   r.delete,
+		kubeType:   "v1alpha1.TLSEdge",
+		statusID:   func(cr *ingressv1alpha1.TLSEdge) string { return cr.Status.ID },
+		conditions: func(cr *ingressv1alpha1.TLSEdge) *[]metav1.Condition { return &cr.Status.Conditions },
+		create:     r.create,
+		update:     r.update,
+		delete:     r.delete,
 		errResult: func(op baseControllerOp, cr *ingressv1alpha1.TLSEdge, err error) (ctrl.Result, error) {
 			if errors.As(err, &ierr.ErrInvalidConfiguration{}) {
 				return ctrl.Result{}, nil
@@ -190,7 +195,7 @@ func (r *TLSEdgeReconciler) updateEdge(ctx context.Context, edge *ingressv1alpha
 		return err
 	}
 
-	if err := r.setMutualTLS(ctx, resp, edge.Spec.MutualTLS); err != nil {
+	if err := r.setMutualTLS(ctx, edge, resp); err != nil {
 		return err
 	}
 
@@ -210,7 +215,13 @@ func (r *TLSEdgeReconciler) delete(ctx context.Context, edge *ingressv1alpha1.TL
 	if err == nil || ngrok.IsNotFound(err) {
 		edge.Status.ID = ""
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if edge.Spec.MutualTLS == nil {
+		return nil
+	}
+	return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
 }
 
 func (r *TLSEdgeReconciler) reconcileTunnelGroupBackend(ctx context.Context, edge *ingressv1alpha1.TLSEdge) error {
@@ -258,27 +269,39 @@ func (r *TLSEdgeReconciler) reconcileTunnelGroupBackend(ctx context.Context, edg
 	return r.Status().Update(ctx, edge)
 }
 
-func (r *TLSEdgeReconciler) setMutualTLS(ctx context.Context, edge *ngrok.TLSEdge, mutualTls *ingressv1alpha1.EndpointMutualTLS) error {
+func (r *TLSEdgeReconciler) setMutualTLS(ctx context.Context, edge *ingressv1alpha1.TLSEdge, remoteEdge *ngrok.TLSEdge) error {
 	log := ctrl.LoggerFrom(ctx)
+	mutualTls := edge.Spec.MutualTLS
 
 	client := r.NgrokClientset.EdgeModules().TLS().MutualTLS()
 	if mutualTls == nil {
-		if edge.MutualTls == nil {
+		if remoteEdge.MutualTls == nil {
 			log.V(1).Info("Edge Mutual TLS matches spec")
 			return nil
 		}
 
 		log.Info("Deleting Edge Mutual TLS")
-		return client.Delete(ctx, edge.ID)
+		if err := client.Delete(ctx, remoteEdge.ID); err != nil {
+			return err
+		}
+		return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
 	}
 
-	_, err := client.Replace(ctx, &ngrok.EdgeMutualTLSReplace{
-		ID: edge.ID,
+	caIDs, err := r.CAResolver.resolveIDs(ctx, edge.Namespace, edge.Spec.Metadata, mutualTls)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Replace(ctx, &ngrok.EdgeMutualTLSReplace{
+		ID: remoteEdge.ID,
 		Module: ngrok.EndpointMutualTLSMutate{
-			CertificateAuthorityIDs: mutualTls.CertificateAuthorities,
+			CertificateAuthorityIDs: caIDs,
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	return r.CAResolver.DeleteUnused(ctx, edge.Spec.Metadata)
 }
 
 func (r *TLSEdgeReconciler) setTLSTermination(ctx context.Context, edge *ngrok.TLSEdge, tlsTermination *ingressv1alpha1.EndpointTLSTermination) error {
@@ -26,13 +26,23 @@ package controllers
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 	"github.com/ngrok/kubernetes-ingress-controller/pkg/tunneldriver"
+	"github.com/ngrok/ngrok-api-go/v5/tunnels"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -41,6 +51,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+const (
+	// tunnelStatusInterval is how often each pod refreshes its entries in the status of the tunnels it runs
+	tunnelStatusInterval = 30 * time.Second
+	// tunnelStatusStaleAge is how long an entry can go without being refreshed before it's considered to belong
+	// to a pod that has gone away
+	tunnelStatusStaleAge = 5 * time.Minute
+)
+
 // TunnelReconciler reconciles a Tunnel object
 type TunnelReconciler struct {
 	client.Client
@@ -49,8 +67,29 @@ type TunnelReconciler struct {
 	Scheme       *runtime.Scheme
 	Recorder     record.EventRecorder
 	TunnelDriver *tunneldriver.TunnelDriver
+	// PodName is the name of the pod the controller is running in. It identifies the pod's entry in each
+	// Tunnel's status.
+	PodName string
+	// TunnelsClient is used to look up the session each tunnel is running on. Session IDs aren't reported
+	// when it's nil.
+	TunnelsClient *tunnels.Client
+	// MaxConcurrentReconciles is how many tunnels are reconciled at once. Defaults to 1.
+	MaxConcurrentReconciles int
+	// APIReader reads the Secrets and ConfigMaps referenced by backend TLS settings straight from the API
+	// server. The tunnel controller runs on every pod, reading them through the cache would make each pod cache
+	// every Secret in the cluster. Defaults to Client.
+	APIReader client.Reader
 
 	controller *baseController[*ingressv1alpha1.Tunnel]
+
+	sessionsMu sync.Mutex
+	sessions   map[string]tunnelSession
+}
+
+// tunnelSession caches the ngrok session a tunnel is running on, keyed by the tunnel's name
+type tunnelSession struct {
+	tunnelID  string
+	sessionID string
 }
 
 // SetupWithManager sets up the controller with the Manager
@@ -73,7 +112,8 @@ func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	cont, err := controller.NewUnmanaged("tunnel-controller", mgr, controller.Options{
-		Reconciler: r,
+		Reconciler:              r,
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		LogConstructor: func(_ *reconcile.Request) logr.Logger {
 			return r.Log
 		},
@@ -92,12 +132,32 @@ func (r *TunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return mgr.Add(cont)
+	// Reload the backend TLS settings when the Secrets and ConfigMaps they reference change. Only their
+	// metadata is watched, so their contents aren't cached on every pod, and backendTLSConfig reads them from
+	// the API server.
+	for _, kind := range []string{"Secret", "ConfigMap"} {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+		if err := cont.Watch(
+			source.Kind(mgr.GetCache(), obj),
+			handler.EnqueueRequestsFromMapFunc(r.tunnelsForBackendTLSObject(kind == "Secret")),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := mgr.Add(cont); err != nil {
+		return err
+	}
+
+	return mgr.Add(tunnelStatusReporter{r})
 }
 
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ingress.k8s.ngrok.com,resources=tunnels/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -110,12 +170,268 @@ func (r *TunnelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 func (r *TunnelReconciler) update(ctx context.Context, tunnel *ingressv1alpha1.Tunnel) error {
 	tunnelName := r.statusID(tunnel)
-	return r.TunnelDriver.CreateTunnel(ctx, tunnelName, tunnel.Spec)
+	backendTLS, err := r.backendTLSConfig(ctx, tunnel)
+	if err != nil {
+		return err
+	}
+	if err := r.TunnelDriver.CreateTunnel(ctx, tunnelName, tunnel.Spec, backendTLS); err != nil {
+		return err
+	}
+	return r.updateAgentStatus(ctx, tunnel)
 }
 
 func (r *TunnelReconciler) delete(ctx context.Context, tunnel *ingressv1alpha1.Tunnel) error {
 	tunnelName := r.statusID(tunnel)
-	return r.TunnelDriver.DeleteTunnel(ctx, tunnelName)
+	if err := r.TunnelDriver.DeleteTunnel(ctx, tunnelName); err != nil {
+		return err
+	}
+
+	r.sessionsMu.Lock()
+	delete(r.sessions, tunnelName)
+	r.sessionsMu.Unlock()
+	return nil
+}
+
+// backendTLSConfig builds the TLS config for connections to the tunnel's backend, loading the CA bundle and
+// client certificate it references. It returns nil if the tunnel doesn't configure backend TLS.
+func (r *TunnelReconciler) backendTLSConfig(ctx context.Context, tunnel *ingressv1alpha1.Tunnel) (*tls.Config, error) {
+	if tunnel.Spec.BackendConfig == nil || tunnel.Spec.BackendConfig.TLS == nil {
+		return nil, nil
+	}
+	backendTLS := tunnel.Spec.BackendConfig.TLS
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	// A CA bundle or server name is only useful to verify the backend's certificate, so unless verification
+	// is explicitly disabled setting either one enables it rather than being silently ignored
+	verify := backendTLS.CACertificates != nil || backendTLS.ServerName != ""
+	if backendTLS.Verify != nil {
+		verify = *backendTLS.Verify
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !verify,
+		ServerName:         backendTLS.ServerName,
+	}
+
+	if bundle := backendTLS.CACertificates; bundle != nil {
+		var pem string
+		switch {
+		case bundle.Secret != nil:
+			secret := &corev1.Secret{}
+			if err := reader.Get(ctx, client.ObjectKey{Namespace: tunnel.Namespace, Name: bundle.Secret.Name}, secret); err != nil {
+				return nil, err
+			}
+			pem = string(secret.Data[bundle.Secret.Key])
+		case bundle.ConfigMap != nil:
+			configMap := &corev1.ConfigMap{}
+			if err := reader.Get(ctx, client.ObjectKey{Namespace: tunnel.Namespace, Name: bundle.ConfigMap.Name}, configMap); err != nil {
+				return nil, err
+			}
+			pem = configMap.Data[bundle.ConfigMap.Key]
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(pem)) {
+			return nil, fmt.Errorf("no CA certificates found in the backend CA bundle of tunnel %s", r.statusID(tunnel))
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if name := backendTLS.ClientCertificateSecret; name != "" {
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: tunnel.Namespace, Name: name}, secret); err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in secret %s/%s: %w", tunnel.Namespace, name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// tunnelsForBackendTLSObject returns a map func that returns reconcile requests for the tunnels whose backend
+// TLS settings reference the given Secret, or ConfigMap when isSecret is false
+func (r *TunnelReconciler) tunnelsForBackendTLSObject(isSecret bool) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		return r.findTunnelsForBackendTLSObject(ctx, obj, isSecret)
+	}
+}
+
+func (r *TunnelReconciler) findTunnelsForBackendTLSObject(ctx context.Context, obj client.Object, isSecret bool) []reconcile.Request {
+	tunnelList := &ingressv1alpha1.TunnelList{}
+	if err := r.Client.List(ctx, tunnelList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list tunnels", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, tunnel := range tunnelList.Items {
+		if tunnel.Spec.BackendConfig == nil || tunnel.Spec.BackendConfig.TLS == nil {
+			continue
+		}
+		backendTLS := tunnel.Spec.BackendConfig.TLS
+
+		referenced := false
+		if isSecret {
+			referenced = backendTLS.ClientCertificateSecret == obj.GetName() ||
+				(backendTLS.CACertificates != nil && backendTLS.CACertificates.Secret != nil && backendTLS.CACertificates.Secret.Name == obj.GetName())
+		} else {
+			referenced = backendTLS.CACertificates != nil && backendTLS.CACertificates.ConfigMap != nil && backendTLS.CACertificates.ConfigMap.Name == obj.GetName()
+		}
+		if referenced {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&tunnel)})
+		}
+	}
+	return requests
+}
+
+// updateAgentStatus writes this pod's entry in the tunnel's status. Every pod writes its own entry, so the
+// status is re-read and retried on conflicts rather than patched from the reconciled object.
+func (r *TunnelReconciler) updateAgentStatus(ctx context.Context, tunnel *ingressv1alpha1.Tunnel) error {
+	tunnelName := r.statusID(tunnel)
+	status, ok := r.TunnelDriver.TunnelStatus(tunnelName)
+	if !ok {
+		return nil
+	}
+
+	agent := ingressv1alpha1.TunnelAgentStatus{
+		PodName:        r.PodName,
+		TunnelID:       status.TunnelID,
+		SessionID:      r.sessionID(ctx, tunnelName, status.TunnelID),
+		Online:         status.Online,
+		ForwardsToAddr: status.ForwardsToAddr,
+		LastDialError:  status.LastDialError,
+	}
+	if !status.LastDialErrorTime.IsZero() {
+		t := metav1.NewTime(status.LastDialErrorTime).Rfc3339Copy()
+		agent.LastDialErrorTime = &t
+	}
+
+	key := client.ObjectKeyFromObject(tunnel)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &ingressv1alpha1.Tunnel{}
+		if err := r.Client.Get(ctx, key, current); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !setAgentStatus(&current.Status, agent, time.Now()) {
+			return nil
+		}
+		return r.Client.Status().Update(ctx, current)
+	})
+}
+
+// sessionID returns the ID of the ngrok session the tunnel is running on. The ngrok agent library doesn't
+// expose it, so it's looked up through the API once per tunnel ID and cached.
+func (r *TunnelReconciler) sessionID(ctx context.Context, tunnelName, tunnelID string) string {
+	if r.TunnelsClient == nil || tunnelID == "" {
+		return ""
+	}
+
+	r.sessionsMu.Lock()
+	defer r.sessionsMu.Unlock()
+	if cached, ok := r.sessions[tunnelName]; ok && cached.tunnelID == tunnelID {
+		return cached.sessionID
+	}
+
+	tun, err := r.TunnelsClient.Get(ctx, tunnelID)
+	if err != nil {
+		r.Log.V(1).Info("unable to look up tunnel session", "tunnel", tunnelName, "tunnelID", tunnelID, "error", err.Error())
+		return ""
+	}
+
+	if r.sessions == nil {
+		r.sessions = make(map[string]tunnelSession)
+	}
+	r.sessions[tunnelName] = tunnelSession{tunnelID: tunnelID, sessionID: tun.TunnelSession.ID}
+	return tun.TunnelSession.ID
+}
+
+// setAgentStatus upserts agent into the status and removes entries of other pods that haven't been refreshed
+// within tunnelStatusStaleAge. An unchanged entry is only rewritten once it's older than half of
+// tunnelStatusStaleAge, so the status is only written on every tick when the tunnel's state changes. It returns true if status was changed.
+func setAgentStatus(status *ingressv1alpha1.TunnelStatus, agent ingressv1alpha1.TunnelAgentStatus, now time.Time) bool {
+	changed := false
+	found := false
+	agents := status.Agents[:0]
+	for _, existing := range status.Agents {
+		if existing.PodName != agent.PodName {
+			if now.Sub(existing.LastUpdateTime.Time) > tunnelStatusStaleAge {
+				changed = true
+				continue
+			}
+			agents = append(agents, existing)
+			continue
+		}
+
+		found = true
+		agent.LastUpdateTime = existing.LastUpdateTime
+		if !equality.Semantic.DeepEqual(existing, agent) || now.Sub(existing.LastUpdateTime.Time) > tunnelStatusStaleAge/2 {
+			agent.LastUpdateTime = metav1.NewTime(now).Rfc3339Copy()
+			changed = true
+		}
+		agents = append(agents, agent)
+	}
+
+	if !found {
+		agent.LastUpdateTime = metav1.NewTime(now).Rfc3339Copy()
+		agents = append(agents, agent)
+		changed = true
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].PodName < agents[j].PodName
+	})
+	status.Agents = agents
+	return changed
+}
+
+// tunnelStatusReporter periodically refreshes this pod's entry in the status of every tunnel it runs, so
+// reconnects and dial errors are reported between reconciles. Connection counts change too often to be written
+// to the status, they're only exported as metrics. Like the tunnels themselves, it runs on every pod.
+type tunnelStatusReporter struct {
+	r *TunnelReconciler
+}
+
+func (s tunnelStatusReporter) NeedLeaderElection() bool {
+	return false
+}
+
+func (s tunnelStatusReporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(tunnelStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.report(ctx)
+		}
+	}
+}
+
+func (s tunnelStatusReporter) report(ctx context.Context) {
+	tunnelList := &ingressv1alpha1.TunnelList{}
+	if err := s.r.Client.List(ctx, tunnelList); err != nil {
+		s.r.Log.Error(err, "unable to list tunnels to report status")
+		return
+	}
+
+	for i := range tunnelList.Items {
+		tunnel := &tunnelList.Items[i]
+		if tunnel.DeletionTimestamp != nil {
+			continue
+		}
+		if err := s.r.updateAgentStatus(ctx, tunnel); err != nil {
+			s.r.Log.Error(err, "unable to update tunnel status", "tunnel", s.r.statusID(tunnel))
+		}
+	}
 }
 
 func (r *TunnelReconciler) statusID(tunnel *ingressv1alpha1.Tunnel) string {
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("setAgentStatus", func() {
+	var (
+		now    time.Time
+		status ingressv1alpha1.TunnelStatus
+	)
+
+	agentAt := func(podName string, updated time.Time) ingressv1alpha1.TunnelAgentStatus {
+		return ingressv1alpha1.TunnelAgentStatus{
+			PodName:        podName,
+			TunnelID:       "tn_" + podName,
+			LastUpdateTime: metav1.NewTime(updated),
+		}
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+		status = ingressv1alpha1.TunnelStatus{
+			Agents: []ingressv1alpha1.TunnelAgentStatus{
+				agentAt("pod-b", now.Add(-time.Minute)),
+				agentAt("pod-c", now.Add(-10*time.Minute)),
+			},
+		}
+	})
+
+	It("adds an entry for a new pod and removes stale entries", func() {
+		Expect(setAgentStatus(&status, ingressv1alpha1.TunnelAgentStatus{PodName: "pod-a"}, now)).To(BeTrue())
+		Expect(status.Agents).To(HaveLen(2))
+		Expect(status.Agents[0].PodName).To(Equal("pod-a"))
+		Expect(status.Agents[0].LastUpdateTime.Time).To(Equal(now))
+		Expect(status.Agents[1].PodName).To(Equal("pod-b"))
+	})
+
+	It("doesn't rewrite an unchanged, recently refreshed entry", func() {
+		status.Agents = status.Agents[:1]
+		agent := agentAt("pod-b", time.Time{})
+		Expect(setAgentStatus(&status, agent, now)).To(BeFalse())
+		Expect(status.Agents[0].LastUpdateTime.Time).To(Equal(now.Add(-time.Minute)))
+	})
+
+	It("refreshes an entry when it changes", func() {
+		status.Agents = status.Agents[:1]
+		agent := agentAt("pod-b", time.Time{})
+		agent.Online = true
+		Expect(setAgentStatus(&status, agent, now)).To(BeTrue())
+		Expect(status.Agents[0].Online).To(BeTrue())
+		Expect(status.Agents[0].LastUpdateTime.Time).To(Equal(now))
+	})
+
+	It("refreshes an unchanged entry before it would be considered stale", func() {
+		status.Agents = status.Agents[:1]
+		agent := agentAt("pod-b", time.Time{})
+		Expect(setAgentStatus(&status, agent, now.Add(2*time.Minute))).To(BeTrue())
+		Expect(status.Agents[0].LastUpdateTime.Time).To(Equal(now.Add(2 * time.Minute)))
+	})
+})
+
+var _ = Describe("backendTLSConfig", func() {
+	var reconciler *TunnelReconciler
+
+	BeforeEach(func() {
+		caSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend-ca", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"ca.crt": []byte(testCAPEM())},
+		}
+		// Secrets are read from the API server rather than through the cached client
+		reconciler = &TunnelReconciler{
+			Client:    fake.NewClientBuilder().Build(),
+			APIReader: fake.NewClientBuilder().WithObjects(caSecret).Build(),
+		}
+	})
+
+	DescribeTable("verifies the backend's certificate", func(backendTLS *ingressv1alpha1.BackendTLSConfig, verify bool) {
+		tunnel := &ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test-namespace"},
+			Spec: ingressv1alpha1.TunnelSpec{
+				BackendConfig: &ingressv1alpha1.BackendConfig{TLS: backendTLS},
+			},
+		}
+		tlsConfig, err := reconciler.backendTLSConfig(context.Background(), tunnel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsConfig.InsecureSkipVerify).To(Equal(!verify))
+	},
+		Entry("by default", &ingressv1alpha1.BackendTLSConfig{}, false),
+		Entry("when enabled", &ingressv1alpha1.BackendTLSConfig{Verify: ptr.To(true)}, true),
+		Entry("when a server name is set", &ingressv1alpha1.BackendTLSConfig{ServerName: "example.internal"}, true),
+		Entry("when a CA bundle is set", &ingressv1alpha1.BackendTLSConfig{
+			CACertificates: &ingressv1alpha1.BackendCABundle{
+				Secret: &ingressv1alpha1.SecretKeyRef{Name: "backend-ca", Key: "ca.crt"},
+			},
+		}, true),
+		Entry("unless disabled", &ingressv1alpha1.BackendTLSConfig{
+			Verify:     ptr.To(false),
+			ServerName: "example.internal",
+		}, false),
+	)
+})
+
+var _ = Describe("findTunnelsForBackendTLSObject", func() {
+	var reconciler *TunnelReconciler
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
+
+		tunnel := &ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test-namespace"},
+			Spec: ingressv1alpha1.TunnelSpec{
+				BackendConfig: &ingressv1alpha1.BackendConfig{TLS: &ingressv1alpha1.BackendTLSConfig{
+					CACertificates: &ingressv1alpha1.BackendCABundle{
+						Secret: &ingressv1alpha1.SecretKeyRef{Name: "backend-ca", Key: "ca.crt"},
+					},
+				}},
+			},
+		}
+		reconciler = &TunnelReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(tunnel).Build()}
+	})
+
+	It("matches the metadata of the objects the tunnels reference", func() {
+		obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "backend-ca", Namespace: "test-namespace"}}
+		Expect(reconciler.findTunnelsForBackendTLSObject(context.Background(), obj, true)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "example", Namespace: "test-namespace"}},
+		))
+		Expect(reconciler.findTunnelsForBackendTLSObject(context.Background(), obj, false)).To(BeEmpty())
+	})
+})
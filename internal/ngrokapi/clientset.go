@@ -3,6 +3,8 @@ package ngrokapi
 import (
 	"github.com/ngrok/ngrok-api-go/v5"
 	tunnel_group_backends "github.com/ngrok/ngrok-api-go/v5/backends/tunnel_group"
+	weighted_backends "github.com/ngrok/ngrok-api-go/v5/backends/weighted"
+	"github.com/ngrok/ngrok-api-go/v5/certificate_authorities"
 	https_edges "github.com/ngrok/ngrok-api-go/v5/edges/https"
 	https_edge_routes "github.com/ngrok/ngrok-api-go/v5/edges/https_routes"
 	tcp_edges "github.com/ngrok/ngrok-api-go/v5/edges/tcp"
@@ -11,9 +13,11 @@ import (
 	"github.com/ngrok/ngrok-api-go/v5/ip_policy_rules"
 	"github.com/ngrok/ngrok-api-go/v5/reserved_addrs"
 	"github.com/ngrok/ngrok-api-go/v5/reserved_domains"
+	"github.com/ngrok/ngrok-api-go/v5/tunnels"
 )
 
 type Clientset interface {
+	CertificateAuthorities() *certificate_authorities.Client
 	Domains() *reserved_domains.Client
 	EdgeModules() EdgeModulesClientset
 	HTTPSEdges() *https_edges.Client
@@ -24,37 +28,49 @@ type Clientset interface {
 	TCPEdges() *tcp_edges.Client
 	TLSEdges() *tls_edges.Client
 	TunnelGroupBackends() *tunnel_group_backends.Client
+	Tunnels() *tunnels.Client
+	WeightedBackends() *weighted_backends.Client
 }
 
 type DefaultClientset struct {
-	domainsClient             *reserved_domains.Client
-	edgeModulesClientset      *defaultEdgeModulesClientset
-	httpsEdgesClient          *https_edges.Client
-	httpsEdgeRoutesClient     *https_edge_routes.Client
-	ipPoliciesClient          *ip_policies.Client
-	ipPolicyRulesClient       *ip_policy_rules.Client
-	tcpAddrsClient            *reserved_addrs.Client
-	tcpEdgesClient            *tcp_edges.Client
-	tlsEdgesClient            *tls_edges.Client
-	tunnelGroupBackendsClient *tunnel_group_backends.Client
+	certificateAuthoritiesClient *certificate_authorities.Client
+	domainsClient                *reserved_domains.Client
+	edgeModulesClientset         *defaultEdgeModulesClientset
+	httpsEdgesClient             *https_edges.Client
+	httpsEdgeRoutesClient        *https_edge_routes.Client
+	ipPoliciesClient             *ip_policies.Client
+	ipPolicyRulesClient          *ip_policy_rules.Client
+	tcpAddrsClient               *reserved_addrs.Client
+	tcpEdgesClient               *tcp_edges.Client
+	tlsEdgesClient               *tls_edges.Client
+	tunnelGroupBackendsClient    *tunnel_group_backends.Client
+	tunnelsClient                *tunnels.Client
+	weightedBackendsClient       *weighted_backends.Client
 }
 
 // NewClientSet creates a new ClientSet from an ngrok client config.
 func NewClientSet(config *ngrok.ClientConfig) *DefaultClientset {
 	return &DefaultClientset{
-		domainsClient:             reserved_domains.NewClient(config),
-		edgeModulesClientset:      newEdgeModulesClientset(config),
-		httpsEdgesClient:          https_edges.NewClient(config),
-		httpsEdgeRoutesClient:     https_edge_routes.NewClient(config),
-		ipPoliciesClient:          ip_policies.NewClient(config),
-		ipPolicyRulesClient:       ip_policy_rules.NewClient(config),
-		tcpAddrsClient:            reserved_addrs.NewClient(config),
-		tcpEdgesClient:            tcp_edges.NewClient(config),
-		tlsEdgesClient:            tls_edges.NewClient(config),
-		tunnelGroupBackendsClient: tunnel_group_backends.NewClient(config),
+		certificateAuthoritiesClient: certificate_authorities.NewClient(config),
+		domainsClient:                reserved_domains.NewClient(config),
+		edgeModulesClientset:         newEdgeModulesClientset(config),
+		httpsEdgesClient:             https_edges.NewClient(config),
+		httpsEdgeRoutesClient:        https_edge_routes.NewClient(config),
+		ipPoliciesClient:             ip_policies.NewClient(config),
+		ipPolicyRulesClient:          ip_policy_rules.NewClient(config),
+		tcpAddrsClient:               reserved_addrs.NewClient(config),
+		tcpEdgesClient:               tcp_edges.NewClient(config),
+		tlsEdgesClient:               tls_edges.NewClient(config),
+		tunnelGroupBackendsClient:    tunnel_group_backends.NewClient(config),
+		tunnelsClient:                tunnels.NewClient(config),
+		weightedBackendsClient:       weighted_backends.NewClient(config),
 	}
 }
 
+func (c *DefaultClientset) CertificateAuthorities() *certificate_authorities.Client {
+	return c.certificateAuthoritiesClient
+}
+
 func (c *DefaultClientset) Domains() *reserved_domains.Client {
 	return c.domainsClient
 }
@@ -94,3 +110,11 @@ func (c *DefaultClientset) TCPEdges() *tcp_edges.Client {
 func (c *DefaultClientset) TunnelGroupBackends() *tunnel_group_backends.Client {
 	return c.tunnelGroupBackendsClient
 }
+
+func (c *DefaultClientset) Tunnels() *tunnels.Client {
+	return c.tunnelsClient
+}
+
+func (c *DefaultClientset) WeightedBackends() *weighted_backends.Client {
+	return c.weightedBackendsClient
+}
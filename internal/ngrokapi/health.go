@@ -0,0 +1,102 @@
+package ngrokapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ngrok/ngrok-api-go/v5"
+	"github.com/ngrok/ngrok-api-go/v5/reserved_domains"
+)
+
+const (
+	// apiHealthInterval is how often the API health check calls the ngrok API
+	apiHealthInterval = 30 * time.Second
+	// apiHealthMaxAge is how long ago the last successful call can be before the check fails. It allows a couple
+	// of failed calls in a row so a single slow or rate limited request doesn't make the pod unready.
+	apiHealthMaxAge = 2 * time.Minute
+)
+
+// APIHealthCheck periodically makes a cheap authenticated call to the ngrok API, so readiness reflects whether
+// the API is reachable and the API key is still valid. It's a manager.Runnable that runs on every pod, leader
+// or not.
+type APIHealthCheck struct {
+	log   logr.Logger
+	probe func(context.Context) error
+
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastError   error
+}
+
+// NewAPIHealthCheck creates an APIHealthCheck that lists a single reserved domain
+func NewAPIHealthCheck(log logr.Logger, domains *reserved_domains.Client) *APIHealthCheck {
+	limit := "1"
+	return &APIHealthCheck{
+		log: log,
+		probe: func(ctx context.Context) error {
+			iter := domains.List(&ngrok.Paging{Limit: &limit})
+			iter.Next(ctx)
+			return iter.Err()
+		},
+	}
+}
+
+// Start calls the ngrok API right away and then every apiHealthInterval until ctx is done
+func (c *APIHealthCheck) Start(ctx context.Context) error {
+	ticker := time.NewTicker(apiHealthInterval)
+	defer ticker.Stop()
+	for {
+		c.run(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every pod checks the API
+func (c *APIHealthCheck) NeedLeaderElection() bool {
+	return false
+}
+
+func (c *APIHealthCheck) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, apiHealthInterval)
+	defer cancel()
+	err := c.probe(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastError = err
+	if err != nil {
+		c.log.Error(err, "ngrok API health check failed")
+		return
+	}
+	c.lastSuccess = time.Now()
+}
+
+// Check is a readiness check that fails if no ngrok API call has succeeded within apiHealthMaxAge
+func (c *APIHealthCheck) Check(_ *http.Request) error {
+	return c.check(time.Now())
+}
+
+func (c *APIHealthCheck) check(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.lastSuccess.IsZero() && now.Sub(c.lastSuccess) <= apiHealthMaxAge {
+		return nil
+	}
+
+	msg := "no successful ngrok API call yet"
+	if !c.lastSuccess.IsZero() {
+		msg = fmt.Sprintf("no successful ngrok API call since %s", c.lastSuccess.Format(time.RFC3339))
+	}
+	if c.lastError != nil {
+		return fmt.Errorf("%s: %w", msg, c.lastError)
+	}
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,54 @@
+package ngrokapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ngrok/ngrok-api-go/v5"
+	"github.com/ngrok/ngrok-api-go/v5/reserved_domains"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIHealthCheck(t *testing.T) {
+	var probeErr error
+	c := &APIHealthCheck{
+		log:   logr.Discard(),
+		probe: func(context.Context) error { return probeErr },
+	}
+	assert.ErrorContains(t, c.Check(nil), "no successful ngrok API call yet")
+
+	c.run(context.Background())
+	assert.NoError(t, c.Check(nil))
+
+	// A failed call doesn't fail the check until the last success is too old
+	probeErr = errors.New("ERR_NGROK_105: authentication failed")
+	c.run(context.Background())
+	assert.NoError(t, c.Check(nil))
+	err := c.check(time.Now().Add(apiHealthMaxAge + time.Second))
+	assert.ErrorContains(t, err, "no successful ngrok API call since")
+	assert.ErrorContains(t, err, "ERR_NGROK_105")
+}
+
+func TestAPIHealthCheckProbe(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		assert.Equal(t, "/reserved_domains", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("limit"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"reserved_domains":[],"uri":"","next_page_uri":null}`))
+	}))
+	defer server.Close()
+
+	config := ngrok.NewClientConfig("api-key", ngrok.WithBaseURL(server.URL))
+	c := NewAPIHealthCheck(logr.Discard(), reserved_domains.NewClient(config))
+
+	c.run(context.Background())
+	assert.NoError(t, c.Check(nil))
+	assert.Equal(t, "Bearer api-key", authorization)
+}
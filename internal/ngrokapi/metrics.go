@@ -0,0 +1,114 @@
+package ngrokapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var apiMetricLabels = []string{"resource", "operation"}
+
+var (
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok",
+		Subsystem: "api",
+		Name:      "requests_total",
+		Help:      "Number of requests to the ngrok API by resource, operation and status code. Requests that failed without a response have the code \"error\".",
+	}, append(apiMetricLabels, "code"))
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ngrok",
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests to the ngrok API by resource and operation",
+		Buckets:   prometheus.DefBuckets,
+	}, apiMetricLabels)
+
+	apiRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrok",
+		Subsystem: "api",
+		Name:      "rate_limited_requests_total",
+		Help:      "Number of requests to the ngrok API that were rejected with 429 Too Many Requests",
+	}, apiMetricLabels)
+)
+
+func init() {
+	metrics.Registry.MustRegister(apiRequests, apiRequestDuration, apiRateLimited)
+}
+
+// NewInstrumentedHTTPClient returns a copy of client that records metrics for each request it makes to the
+// ngrok API. Use it with ngrok.WithHTTPClient so every client in a Clientset is instrumented.
+func NewInstrumentedHTTPClient(client *http.Client) *http.Client {
+	instrumented := *client
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	instrumented.Transport = &metricsTransport{next: next}
+	return &instrumented
+}
+
+// metricsTransport is an http.RoundTripper that records metrics for the requests it makes
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource, operation := apiOperation(req.Method, req.URL.Path)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	apiRequestDuration.WithLabelValues(resource, operation).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiRateLimited.WithLabelValues(resource, operation).Inc()
+		}
+	}
+	apiRequests.WithLabelValues(resource, operation, code).Inc()
+
+	return resp, err
+}
+
+// apiIDPattern matches ngrok resource IDs like rd_2YpF0c1k3LW3SKVJbxwkvR6Wr2n
+var apiIDPattern = regexp.MustCompile(`^[a-z]+_[0-9A-Za-z]{16,}$`)
+
+// apiOperation derives the resource type and operation of an ngrok API request from its method and path. IDs
+// are dropped from the path so the labels stay low cardinality, e.g. PATCH /edges/https/edghts_123/routes/edghtsrt_456
+// is the "update" operation on "edges/https/routes".
+func apiOperation(method, path string) (string, string) {
+	var segments []string
+	endsWithID := false
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		endsWithID = apiIDPattern.MatchString(segment)
+		if !endsWithID && segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	resource := strings.Join(segments, "/")
+
+	switch method {
+	case http.MethodGet:
+		if endsWithID {
+			return resource, "get"
+		}
+		return resource, "list"
+	case http.MethodPost:
+		return resource, "create"
+	case http.MethodPatch:
+		return resource, "update"
+	case http.MethodPut:
+		return resource, "replace"
+	case http.MethodDelete:
+		return resource, "delete"
+	default:
+		return resource, strings.ToLower(method)
+	}
+}
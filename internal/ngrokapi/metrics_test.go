@@ -0,0 +1,46 @@
+package ngrokapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIOperation(t *testing.T) {
+	cases := []struct {
+		method    string
+		path      string
+		resource  string
+		operation string
+	}{
+		{"GET", "/reserved_domains", "reserved_domains", "list"},
+		{"GET", "/reserved_domains/rd_2YpF0c1k3LW3SKVJbxwkvR6Wr2n", "reserved_domains", "get"},
+		{"POST", "/edges/https", "edges/https", "create"},
+		{"PATCH", "/edges/https/edghts_2YpF0c1k3LW3SKVJbxwkvR6Wr2n/routes/edghtsrt_2YpF0c1k3LW3SKVJbxwkvR6Wr2n", "edges/https/routes", "update"},
+		{"PUT", "/edges/https/edghts_2YpF0c1k3LW3SKVJbxwkvR6Wr2n/routes/edghtsrt_2YpF0c1k3LW3SKVJbxwkvR6Wr2n/compression", "edges/https/routes/compression", "replace"},
+		{"DELETE", "/backends/tunnel_group/bkdtg_2YpF0c1k3LW3SKVJbxwkvR6Wr2n", "backends/tunnel_group", "delete"},
+	}
+	for _, c := range cases {
+		resource, operation := apiOperation(c.method, c.path)
+		assert.Equal(t, c.resource, resource, c.path)
+		assert.Equal(t, c.operation, operation, c.path)
+	}
+}
+
+func TestInstrumentedHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewInstrumentedHTTPClient(server.Client())
+	resp, err := client.Get(server.URL + "/ip_policies")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(apiRequests.WithLabelValues("ip_policies", "list", "429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(apiRateLimited.WithLabelValues("ip_policies", "list")))
+}
@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/cache"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // CacheStores stores cache.Store for all Kinds of k8s objects that
@@ -36,14 +37,18 @@ type CacheStores struct {
 	ServiceV1      cache.Store
 
 	// Gateway API Stores
-	Gateway   cache.Store
-	HTTPRoute cache.Store
+	GatewayClass cache.Store
+	Gateway      cache.Store
+	HTTPRoute    cache.Store
+	TLSRoute     cache.Store
+	TCPRoute     cache.Store
 
 	// Ngrok Stores
-	DomainV1      cache.Store
-	TunnelV1      cache.Store
-	HTTPSEdgeV1   cache.Store
-	NgrokModuleV1 cache.Store
+	DomainV1             cache.Store
+	TunnelV1             cache.Store
+	HTTPSEdgeV1          cache.Store
+	NgrokModuleV1        cache.Store
+	ClusterNgrokModuleV1 cache.Store
 
 	log logr.Logger
 	l   *sync.RWMutex
@@ -57,15 +62,19 @@ func NewCacheStores(logger logr.Logger) CacheStores {
 		IngressClassV1: cache.NewStore(clusterResourceKeyFunc),
 		ServiceV1:      cache.NewStore(keyFunc),
 		// Gateway API Stores
-		Gateway:   cache.NewStore(keyFunc),
-		HTTPRoute: cache.NewStore(keyFunc),
+		GatewayClass: cache.NewStore(clusterResourceKeyFunc),
+		Gateway:      cache.NewStore(keyFunc),
+		HTTPRoute:    cache.NewStore(keyFunc),
+		TLSRoute:     cache.NewStore(keyFunc),
+		TCPRoute:     cache.NewStore(keyFunc),
 		// Ngrok Stores
-		DomainV1:      cache.NewStore(keyFunc),
-		TunnelV1:      cache.NewStore(keyFunc),
-		HTTPSEdgeV1:   cache.NewStore(keyFunc),
-		NgrokModuleV1: cache.NewStore(keyFunc),
-		l:             &sync.RWMutex{},
-		log:           logger,
+		DomainV1:             cache.NewStore(keyFunc),
+		TunnelV1:             cache.NewStore(keyFunc),
+		HTTPSEdgeV1:          cache.NewStore(keyFunc),
+		NgrokModuleV1:        cache.NewStore(keyFunc),
+		ClusterNgrokModuleV1: cache.NewStore(clusterResourceKeyFunc),
+		l:                    &sync.RWMutex{},
+		log:                  logger,
 	}
 }
 
@@ -107,8 +116,14 @@ func (c CacheStores) Get(obj runtime.Object) (item interface{}, exists bool, err
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Get(obj)
+	case *gatewayv1.GatewayClass:
+		return c.GatewayClass.Get(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Get(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Get(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Get(obj)
 
 	// ----------------------------------------------------------------------------
 	// Ngrok API Support
@@ -121,6 +136,8 @@ func (c CacheStores) Get(obj runtime.Object) (item interface{}, exists bool, err
 		return c.HTTPSEdgeV1.Get(obj)
 	case *ingressv1alpha1.NgrokModuleSet:
 		return c.NgrokModuleV1.Get(obj)
+	case *ingressv1alpha1.ClusterNgrokModuleSet:
+		return c.ClusterNgrokModuleV1.Get(obj)
 	default:
 		return nil, false, fmt.Errorf("unsupported object type: %T", obj)
 	}
@@ -148,8 +165,14 @@ func (c CacheStores) Add(obj runtime.Object) error {
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Add(obj)
+	case *gatewayv1.GatewayClass:
+		return c.GatewayClass.Add(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Add(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Add(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Add(obj)
 
 	// ----------------------------------------------------------------------------
 	// Ngrok API Support
@@ -162,6 +185,8 @@ func (c CacheStores) Add(obj runtime.Object) error {
 		return c.HTTPSEdgeV1.Add(obj)
 	case *ingressv1alpha1.NgrokModuleSet:
 		return c.NgrokModuleV1.Add(obj)
+	case *ingressv1alpha1.ClusterNgrokModuleSet:
+		return c.ClusterNgrokModuleV1.Add(obj)
 
 	default:
 		return fmt.Errorf("unsupported object type: %T", obj)
@@ -190,8 +215,14 @@ func (c CacheStores) Delete(obj runtime.Object) error {
 	// ----------------------------------------------------------------------------
 	case *gatewayv1.HTTPRoute:
 		return c.HTTPRoute.Delete(obj)
+	case *gatewayv1.GatewayClass:
+		return c.GatewayClass.Delete(obj)
 	case *gatewayv1.Gateway:
 		return c.Gateway.Delete(obj)
+	case *gatewayv1alpha2.TLSRoute:
+		return c.TLSRoute.Delete(obj)
+	case *gatewayv1alpha2.TCPRoute:
+		return c.TCPRoute.Delete(obj)
 
 	// ----------------------------------------------------------------------------
 	// Ngrok API Support
@@ -204,6 +235,8 @@ func (c CacheStores) Delete(obj runtime.Object) error {
 		return c.HTTPSEdgeV1.Delete(obj)
 	case *ingressv1alpha1.NgrokModuleSet:
 		return c.NgrokModuleV1.Delete(obj)
+	case *ingressv1alpha1.ClusterNgrokModuleSet:
+		return c.ClusterNgrokModuleV1.Delete(obj)
 	default:
 		return fmt.Errorf("unsupported object type: %T", obj)
 	}
@@ -5,19 +5,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations"
@@ -27,6 +35,9 @@ import (
 
 const clusterDomain = "svc.cluster.local" // TODO: We can technically figure this out by looking at things like our resolv.conf or we can just take this as a helm option
 
+// syncRetryInterval is how long the sync loop waits before retrying a failed pass
+const syncRetryInterval = 10 * time.Second
+
 const (
 	labelControllerNamespace = "k8s.ngrok.com/controller-namespace"
 	labelControllerName      = "k8s.ngrok.com/controller-name"
@@ -35,8 +46,25 @@ const (
 	labelServiceUID          = "k8s.ngrok.com/service-uid"
 	labelService             = "k8s.ngrok.com/service"
 	labelPort                = "k8s.ngrok.com/port"
+	labelTCPRoute            = "k8s.ngrok.com/tcproute"
 )
 
+// Service annotations that configure connections to HTTPS backends
+const (
+	annotationBackendTLSVerify           = "k8s.ngrok.com/backend-tls-verify"
+	annotationBackendTLSServerName       = "k8s.ngrok.com/backend-tls-server-name"
+	annotationBackendTLSCASecret         = "k8s.ngrok.com/backend-tls-ca-secret"
+	annotationBackendTLSCAConfigMap      = "k8s.ngrok.com/backend-tls-ca-configmap"
+	annotationBackendTLSClientCertSecret = "k8s.ngrok.com/backend-tls-client-cert-secret"
+
+	// backendTLSCAKey is the key of the CA bundle in the Secret or ConfigMap
+	backendTLSCAKey = "ca.crt"
+)
+
+// annotationBackendProxyProtocol is the Service annotation that enables sending a PROXY protocol header of the
+// given version to the backend
+const annotationBackendProxyProtocol = "k8s.ngrok.com/backend-proxy-protocol"
+
 // Driver maintains the store of information, can derive new information from the store, and can
 // synchronize the desired state of the store to the actual state of the cluster.
 type Driver struct {
@@ -48,13 +76,23 @@ type Driver struct {
 	customMetadata string
 	managerName    types.NamespacedName
 
-	syncMu              sync.Mutex
-	syncRunning         bool
-	syncFullCh          chan error
-	syncPartialCh       chan error
-	syncAllowConcurrent bool
+	// syncMu serializes Sync passes, dirty coalesces change notifications from the
+	// reconcilers into a single pending pass for the background loop in Start
+	syncMu         sync.Mutex
+	dirty          chan struct{}
+	client         client.Client
+	resyncInterval time.Duration
 
 	gatewayEnabled bool
+
+	annotationsExtractor annotations.Extractor
+
+	// recorder records events for problems found while syncing, if set
+	recorder record.EventRecorder
+
+	// seeded is closed once Seed has loaded the state of the cluster into the store
+	seeded     chan struct{}
+	seededOnce sync.Once
 }
 
 // NewDriver creates a new driver with a basic logger and cache store setup
@@ -67,10 +105,30 @@ func NewDriver(logger logr.Logger, scheme *runtime.Scheme, controllerName string
 		log:            logger,
 		scheme:         scheme,
 		managerName:    managerName,
+		dirty:          make(chan struct{}, 1),
+		seeded:         make(chan struct{}),
 		gatewayEnabled: gatewayEnabled,
+
+		annotationsExtractor: annotations.NewAnnotationsExtractor(),
 	}
 }
 
+// WithSyncLoop configures the client used by the background sync loop and how often it
+// runs a full sync even when nothing has been marked dirty. An interval of 0 disables the
+// periodic resync, so passes only happen when the store changes.
+func (d *Driver) WithSyncLoop(c client.Client, resyncInterval time.Duration) *Driver {
+	d.client = c
+	d.resyncInterval = resyncInterval
+	return d
+}
+
+// WithEventRecorder configures the recorder used to report problems with the resources being synced, like
+// conflicting annotations, as events on them
+func (d *Driver) WithEventRecorder(recorder record.EventRecorder) *Driver {
+	d.recorder = recorder
+	return d
+}
+
 // WithMetaData allows you to pass in custom metadata to be added to all resources created by the controller
 func (d *Driver) WithMetaData(customMetadata map[string]string) *Driver {
 	if _, ok := customMetadata["owned-by"]; !ok {
@@ -90,12 +148,18 @@ func (d *Driver) WithMetaData(customMetadata map[string]string) *Driver {
 // each calculation will be based on an incomplete state of the world. It currently relies on:
 // - Ingresses
 // - IngressClasses
+// - GatewayClasses
 // - Gateways
 // - HTTPRoutes
+// - TLSRoutes
+// - TCPRoutes
 // - Services
 // - Secrets
 // - Domains
 // - Edges
+// - Tunnels
+// - NgrokModuleSets
+// - ClusterNgrokModuleSets
 // When the sync method becomes a background process, this likely won't be needed anymore
 func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 	ingresses := &netv1.IngressList{}
@@ -119,6 +183,16 @@ func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 	}
 
 	if d.gatewayEnabled {
+		gatewayClasses := &gatewayv1.GatewayClassList{}
+		if err := c.List(ctx, gatewayClasses); err != nil {
+			return err
+		}
+		for _, gtwClass := range gatewayClasses.Items {
+			if err := d.store.Update(&gtwClass); err != nil {
+				return err
+			}
+		}
+
 		gateways := &gatewayv1.GatewayList{}
 		if err := c.List(ctx, gateways); err != nil {
 			return err
@@ -138,6 +212,28 @@ func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 				return err
 			}
 		}
+
+		// TLSRoutes and TCPRoutes are only part of the experimental channel of the Gateway API,
+		// so their CRDs may not be installed
+		tlsroutes := &gatewayv1alpha2.TLSRouteList{}
+		if err := c.List(ctx, tlsroutes); client.IgnoreNotFound(err) != nil && !meta.IsNoMatchError(err) {
+			return err
+		}
+		for _, tlsroute := range tlsroutes.Items {
+			if err := d.store.Update(&tlsroute); err != nil {
+				return err
+			}
+		}
+
+		tcproutes := &gatewayv1alpha2.TCPRouteList{}
+		if err := c.List(ctx, tcproutes); client.IgnoreNotFound(err) != nil && !meta.IsNoMatchError(err) {
+			return err
+		}
+		for _, tcproute := range tcproutes.Items {
+			if err := d.store.Update(&tcproute); err != nil {
+				return err
+			}
+		}
 	}
 
 	services := &corev1.ServiceList{}
@@ -180,9 +276,65 @@ func (d *Driver) Seed(ctx context.Context, c client.Reader) error {
 		}
 	}
 
+	modSets := &ingressv1alpha1.NgrokModuleSetList{}
+	if err := c.List(ctx, modSets); err != nil {
+		return err
+	}
+	for _, modSet := range modSets.Items {
+		if err := d.store.Update(&modSet); err != nil {
+			return err
+		}
+	}
+
+	clusterModSets := &ingressv1alpha1.ClusterNgrokModuleSetList{}
+	if err := c.List(ctx, clusterModSets); err != nil {
+		return err
+	}
+	for _, clusterModSet := range clusterModSets.Items {
+		if err := d.store.Update(&clusterModSet); err != nil {
+			return err
+		}
+	}
+
+	d.seededOnce.Do(func() { close(d.seeded) })
 	return nil
 }
 
+// Seeded is a readiness check that fails until the driver has been seeded, since calculations made before then
+// are based on an incomplete state of the cluster
+func (d *Driver) Seeded(_ *http.Request) error {
+	select {
+	case <-d.seeded:
+		return nil
+	default:
+		return fmt.Errorf("driver has not been seeded")
+	}
+}
+
+// Seeder returns a manager.Runnable that seeds the driver from c once the manager starts, so the Seeded readiness
+// check fails until then. Unlike the sync loop it runs on every replica, since they all serve the store.
+func (d *Driver) Seeder(c client.Reader) manager.Runnable {
+	return &driverSeeder{driver: d, reader: c}
+}
+
+type driverSeeder struct {
+	driver *Driver
+	reader client.Reader
+}
+
+func (s *driverSeeder) Start(ctx context.Context) error {
+	if err := s.driver.Seed(ctx, s.reader); err != nil {
+		return fmt.Errorf("unable to seed cache store: %w", err)
+	}
+	s.driver.PrintState(s.driver.log)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *driverSeeder) NeedLeaderElection() bool {
+	return false
+}
+
 func (d *Driver) PrintState(setupLog logr.Logger) {
 	ings := d.store.ListNgrokIngressesV1()
 	for _, ing := range ings {
@@ -225,6 +377,20 @@ func (d *Driver) UpdateHTTPRoute(httproute *gatewayv1.HTTPRoute) (*gatewayv1.HTT
 	return d.store.GetHTTPRoute(httproute.Name, httproute.Namespace)
 }
 
+func (d *Driver) UpdateTLSRoute(tlsroute *gatewayv1alpha2.TLSRoute) (*gatewayv1alpha2.TLSRoute, error) {
+	if err := d.store.Update(tlsroute); err != nil {
+		return nil, err
+	}
+	return d.store.GetTLSRoute(tlsroute.Name, tlsroute.Namespace)
+}
+
+func (d *Driver) UpdateTCPRoute(tcproute *gatewayv1alpha2.TCPRoute) (*gatewayv1alpha2.TCPRoute, error) {
+	if err := d.store.Update(tcproute); err != nil {
+		return nil, err
+	}
+	return d.store.GetTCPRoute(tcproute.Name, tcproute.Namespace)
+}
+
 func (d *Driver) DeleteIngress(ingress *netv1.Ingress) error {
 	return d.store.Delete(ingress)
 }
@@ -237,6 +403,14 @@ func (d *Driver) DeleteHTTPRoute(httproute *gatewayv1.HTTPRoute) error {
 	return d.store.Delete(httproute)
 }
 
+func (d *Driver) DeleteTLSRoute(tlsroute *gatewayv1alpha2.TLSRoute) error {
+	return d.store.Delete(tlsroute)
+}
+
+func (d *Driver) DeleteTCPRoute(tcproute *gatewayv1alpha2.TCPRoute) error {
+	return d.store.Delete(tcproute)
+}
+
 // Delete an ingress object given the NamespacedName
 // Takes a namespacedName string as a parameter and
 // deletes the ingress object from the cacheStores map
@@ -264,251 +438,338 @@ func (d *Driver) DeleteNamedHTTPRoute(n types.NamespacedName) error {
 	return d.cacheStores.Delete(httproute)
 }
 
-// syncStart will:
-//   - let the first caller proceed, indicated by returning true
-//   - while the first one is running any subsequent calls will be batched to the last call
-//   - the callers between first and last will be assumed "success" and wait will return nil
-//   - the last one will return an error, which will retrigger reconciliation
-func (d *Driver) syncStart(partial bool) (bool, func(ctx context.Context) error) {
-	d.log.Info("sync start")
-	d.syncMu.Lock()
-	defer d.syncMu.Unlock()
+func (d *Driver) DeleteNamedTLSRoute(n types.NamespacedName) error {
+	tlsroute := &gatewayv1alpha2.TLSRoute{}
+	tlsroute.SetNamespace(n.Namespace)
+	tlsroute.SetName(n.Name)
+	return d.cacheStores.Delete(tlsroute)
+}
 
-	if !d.syncRunning {
-		// not running, we can take action
-		d.syncRunning = true
-		return true, nil
+func (d *Driver) DeleteNamedTCPRoute(n types.NamespacedName) error {
+	tcproute := &gatewayv1alpha2.TCPRoute{}
+	tcproute.SetNamespace(n.Namespace)
+	tcproute.SetName(n.Name)
+	return d.cacheStores.Delete(tcproute)
+}
+
+// MarkDirty signals the background sync loop that the store has changed and the desired state
+// needs to be recalculated. It never blocks, and any number of calls made while a pass is pending
+// are coalesced into that single pass.
+func (d *Driver) MarkDirty() {
+	select {
+	case d.dirty <- struct{}{}:
+	default:
 	}
+}
 
-	// already running, overtake any other waiters
-	if d.syncFullCh != nil {
-		if partial {
-			// a full sync is already waiting, ignore non-full ones
-			return false, func(ctx context.Context) error {
-				return nil
-			}
-		}
-		close(d.syncFullCh)
-		d.syncFullCh = nil
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The sync loop creates, updates and
+// deletes resources, so only the leader should be running it.
+func (d *Driver) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable. It runs a Sync pass whenever the store is marked dirty, and
+// additionally every resync interval to correct any drift. Failed passes are retried after
+// syncRetryInterval. It blocks until the context is cancelled.
+func (d *Driver) Start(ctx context.Context) error {
+	if d.client == nil {
+		return fmt.Errorf("driver sync loop has no client, use WithSyncLoop before starting it")
 	}
-	if d.syncPartialCh != nil {
-		close(d.syncPartialCh)
-		d.syncPartialCh = nil
+
+	var resync <-chan time.Time
+	if d.resyncInterval > 0 {
+		ticker := time.NewTicker(d.resyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
 	}
 
-	// put yourself in waiting position
-	ch := make(chan error, 1)
-	if partial {
-		d.syncPartialCh = ch
-	} else {
-		d.syncFullCh = ch
+	// Calculations need the full state of the cluster, wait for the store to be seeded
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-d.seeded:
 	}
 
-	return false, func(ctx context.Context) error {
+	// Always run an initial pass once seeded
+	d.MarkDirty()
+
+	for {
 		select {
-		case err := <-ch:
-			d.log.Info("sync done", "err", err)
-			return err
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil
+		case <-d.dirty:
+		case <-resync:
+			d.log.V(1).Info("periodic resync")
 		}
-	}
-}
-
-var errSyncDone = errors.New("sync done")
-
-func (d *Driver) syncDone() {
-	d.log.Info("sync done")
-	d.syncMu.Lock()
-	defer d.syncMu.Unlock()
 
-	if d.syncFullCh != nil {
-		d.syncFullCh <- errSyncDone
-		close(d.syncFullCh)
-		d.syncFullCh = nil
-	}
-	if d.syncPartialCh != nil {
-		d.syncPartialCh <- errSyncDone
-		close(d.syncPartialCh)
-		d.syncPartialCh = nil
+		if err := d.Sync(ctx, d.client); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.log.Error(err, "error syncing driver state, will retry", "retryAfter", syncRetryInterval)
+			time.AfterFunc(syncRetryInterval, d.MarkDirty)
+		}
 	}
-	d.syncRunning = false
 }
 
 // Sync calculates what the desired state for each of our CRDs should be based on the ingresses and other
 // objects in the store. It then compares that to the actual state of the cluster and updates the cluster
-func (d *Driver) Sync(ctx context.Context, c client.Client) error {
-	// This function gets called a lot in the current architecture. At the end it also syncs
-	// resources which in turn triggers more reconcile events. Its all eventually consistent, but
-	// its noisy and can make us hit ngrok api limits. We should probably just change this to be
-	// a periodic sync instead of a sync on every reconcile event, but for now this debouncer
-	// keeps it in check and syncs in batches
-	if !d.syncAllowConcurrent {
-		if proceed, wait := d.syncStart(false); proceed {
-			defer d.syncDone()
-		} else {
-			return wait(ctx)
+//
+// Reconcilers should not call this directly, they update the store and call MarkDirty so that the
+// background loop started by Start can batch changes into a single pass.
+func (d *Driver) Sync(ctx context.Context, c client.Client) (err error) {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
 		}
-	}
+		syncDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
 
 	d.log.Info("syncing driver state!!")
-	desiredDomains, desiredIngressDomains, desiredGatewayDomainMap := d.calculateDomains()
-	desiredEdges := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
-	desiredTunnels := d.calculateTunnels()
+	changes, err := d.calculateChanges(ctx, c)
+	if err != nil {
+		return err
+	}
+	recordResourceCounts(changes.counts)
 
-	currDomains := &ingressv1alpha1.DomainList{}
-	currEdges := &ingressv1alpha1.HTTPSEdgeList{}
-	currTunnels := &ingressv1alpha1.TunnelList{}
+	if err := d.applyDomains(ctx, c, changes.domains); err != nil {
+		return err
+	}
 
-	if err := c.List(ctx, currDomains); err != nil {
-		d.log.Error(err, "error listing domains")
+	if err := d.applyHTTPSEdges(ctx, c, changes.edges); err != nil {
 		return err
 	}
-	if err := c.List(ctx, currEdges, client.MatchingLabels{
-		labelControllerNamespace: d.managerName.Namespace,
-		labelControllerName:      d.managerName.Name,
-	}); err != nil {
-		d.log.Error(err, "error listing edges")
+	d.recordPolicyConflicts(changes.policyConflicts)
+
+	if err := d.applyTLSEdges(ctx, c, changes.tlsEdges); err != nil {
 		return err
 	}
-	if err := c.List(ctx, currTunnels, client.MatchingLabels{
-		labelControllerNamespace: d.managerName.Namespace,
-		labelControllerName:      d.managerName.Name,
-	}); err != nil {
-		d.log.Error(err, "error listing tunnels")
+
+	if err := d.applyTCPEdges(ctx, c, changes.tcpEdges); err != nil {
 		return err
 	}
 
-	if err := d.applyDomains(ctx, c, desiredDomains, currDomains.Items); err != nil {
+	if err := d.applyTunnels(ctx, c, changes.tunnels); err != nil {
 		return err
 	}
 
-	if err := d.applyHTTPSEdges(ctx, c, desiredEdges, currEdges.Items); err != nil {
+	if err := d.updateIngressStatuses(ctx, c); err != nil {
 		return err
 	}
 
-	if err := d.applyTunnels(ctx, c, desiredTunnels, currTunnels.Items); err != nil {
+	if err := d.updateGatewayStatuses(ctx, c, changes.ingressDomains); err != nil {
 		return err
 	}
 
-	if err := d.updateIngressStatuses(ctx, c); err != nil {
+	if err := d.updateHTTPRouteStatuses(ctx, c, changes.ignoredHTTPRouteRules, changes.ingressDomains); err != nil {
 		return err
 	}
 
-	// UpdateGatewayStatuses
-	//if err := d.updateGatewayStatuses(ctx, c); err != nil {
-	//	return err
-	//}
+	if err := d.updateTLSRouteStatuses(ctx, c, changes.ingressDomains); err != nil {
+		return err
+	}
 
-	// UpdateHTTPRouteStatuses
-	//if err := d.updateHTTPRouteStatuses(ctx, c); err != nil {
-	//	return err
-	//}
+	if err := d.updateTCPRouteStatuses(ctx, c, changes.ingressDomains); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (d *Driver) SyncEdges(ctx context.Context, c client.Client) error {
-	if !d.syncAllowConcurrent {
-		if proceed, wait := d.syncStart(true); proceed {
-			defer d.syncDone()
-		} else {
-			return wait(ctx)
-		}
-	}
+// syncChanges is everything a single Sync pass needs to create, update, or delete
+type syncChanges struct {
+	domains  changeSet[ingressv1alpha1.Domain]
+	edges    changeSet[ingressv1alpha1.HTTPSEdge]
+	tlsEdges changeSet[ingressv1alpha1.TLSEdge]
+	tcpEdges changeSet[ingressv1alpha1.TCPEdge]
+	tunnels  changeSet[ingressv1alpha1.Tunnel]
+
+	// counts are the number of desired and current resources by kind
+	counts map[string]resourceCounts
+
+	// ignoredHTTPRouteRules are the HTTPRoute rules left out of the edges, reported in the HTTPRoutes' statuses
+	ignoredHTTPRouteRules map[types.NamespacedName][]string
 
-	d.log.Info("syncing edges state!!")
-	_, desiredIngressDomains, desiredGatewayDomainMap := d.calculateDomains()
+	// policyConflicts are the paths of each Ingress left out of the edges because of a policy conflict
+	policyConflicts map[types.NamespacedName][]string
+
+	// ingressDomains are the domains of the Ingresses by hostname, used again to resolve the route statuses
+	ingressDomains map[string]ingressv1alpha1.Domain
+}
+
+// calculateChanges calculates the desired state from the store and diffs it against the
+// resources that currently exist in the cluster
+func (d *Driver) calculateChanges(ctx context.Context, c client.Reader) (*syncChanges, error) {
+	// Listeners can't use the hostnames of Ingresses, every route and listener is checked against them
+	ingressDomainMap := d.calculateDomainsFromIngress()
+	desiredDomains, desiredIngressDomains, desiredGatewayDomainMap := d.calculateDomains(ingressDomainMap)
+	desiredEdges, policyConflicts, ignoredHTTPRouteRules := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap, ingressDomainMap)
+	desiredTLSEdges := d.calculateTLSEdges(desiredGatewayDomainMap, ingressDomainMap)
+	desiredTCPEdges := d.calculateTCPEdges(ingressDomainMap)
+	desiredTunnels := d.calculateTunnels(ingressDomainMap)
 
-	desiredEdges := d.calculateHTTPSEdges(&desiredIngressDomains, desiredGatewayDomainMap)
+	currDomains := &ingressv1alpha1.DomainList{}
 	currEdges := &ingressv1alpha1.HTTPSEdgeList{}
+	currTLSEdges := &ingressv1alpha1.TLSEdgeList{}
+	currTCPEdges := &ingressv1alpha1.TCPEdgeList{}
+	currTunnels := &ingressv1alpha1.TunnelList{}
+
+	if err := c.List(ctx, currDomains); err != nil {
+		d.log.Error(err, "error listing domains")
+		return nil, err
+	}
 	if err := c.List(ctx, currEdges, client.MatchingLabels{
 		labelControllerNamespace: d.managerName.Namespace,
 		labelControllerName:      d.managerName.Name,
 	}); err != nil {
 		d.log.Error(err, "error listing edges")
-		return err
+		return nil, err
 	}
-
-	if err := d.applyHTTPSEdges(ctx, c, desiredEdges, currEdges.Items); err != nil {
-		return err
+	if err := c.List(ctx, currTLSEdges, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing tls edges")
+		return nil, err
+	}
+	if err := c.List(ctx, currTCPEdges, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing tcp edges")
+		return nil, err
+	}
+	if err := c.List(ctx, currTunnels, client.MatchingLabels{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+	}); err != nil {
+		d.log.Error(err, "error listing tunnels")
+		return nil, err
 	}
 
-	return nil
+	// Count before diffing, which consumes the desired maps
+	counts := map[string]resourceCounts{
+		"Domain":    {desired: len(desiredDomains), current: len(currDomains.Items)},
+		"HTTPSEdge": {desired: len(desiredEdges), current: len(currEdges.Items)},
+		"TLSEdge":   {desired: len(desiredTLSEdges), current: len(currTLSEdges.Items)},
+		"TCPEdge":   {desired: len(desiredTCPEdges), current: len(currTCPEdges.Items)},
+		"Tunnel":    {desired: len(desiredTunnels), current: len(currTunnels.Items)},
+	}
+
+	return &syncChanges{
+		counts:                counts,
+		domains:               d.diffDomains(desiredDomains, currDomains.Items),
+		edges:                 d.diffHTTPSEdges(desiredEdges, currEdges.Items),
+		tlsEdges:              d.diffTLSEdges(desiredTLSEdges, currTLSEdges.Items),
+		tcpEdges:              d.diffTCPEdges(desiredTCPEdges, currTCPEdges.Items),
+		tunnels:               d.diffTunnels(desiredTunnels, currTunnels.Items),
+		ignoredHTTPRouteRules: ignoredHTTPRouteRules,
+		policyConflicts:       policyConflicts,
+		ingressDomains:        ingressDomainMap,
+	}, nil
 }
 
-func (d *Driver) applyDomains(ctx context.Context, c client.Client, desiredDomains, currentDomains []ingressv1alpha1.Domain) error {
+func (d *Driver) diffDomains(desiredDomains, currentDomains []ingressv1alpha1.Domain) changeSet[ingressv1alpha1.Domain] {
+	var changes changeSet[ingressv1alpha1.Domain]
 	for _, desiredDomain := range desiredDomains {
 		found := false
 		for _, currDomain := range currentDomains {
 			if desiredDomain.Name == currDomain.Name && desiredDomain.Namespace == currDomain.Namespace {
 				// It matches so lets update it if anything is different
 				if !reflect.DeepEqual(desiredDomain.Spec, currDomain.Spec) {
-					currDomain.Spec = desiredDomain.Spec
-					if err := c.Update(ctx, &currDomain); err != nil {
-						d.log.Error(err, "error updating domain", "domain", desiredDomain)
-						return err
-					}
+					updated := currDomain
+					updated.Spec = desiredDomain.Spec
+					changes.addUpdate(currDomain, updated)
 				}
 				found = true
 				break
 			}
 		}
 		if !found {
-			if err := c.Create(ctx, &desiredDomain); err != nil {
-				d.log.Error(err, "error creating domain", "domain", desiredDomain)
-				return err
-			}
+			changes.create = append(changes.create, desiredDomain)
 		}
 	}
 
 	// Don't delete domains to prevent accidentally de-registering them and making people re-do DNS
 
+	return changes
+}
+
+func (d *Driver) applyDomains(ctx context.Context, c client.Client, changes changeSet[ingressv1alpha1.Domain]) error {
+	for _, domain := range changes.update {
+		if err := c.Update(ctx, &domain); err != nil {
+			d.log.Error(err, "error updating domain", "domain", domain)
+			return err
+		}
+	}
+	for _, domain := range changes.create {
+		if err := c.Create(ctx, &domain); err != nil {
+			d.log.Error(err, "error creating domain", "domain", domain)
+			return err
+		}
+	}
 	return nil
 }
 
-func (d *Driver) applyHTTPSEdges(ctx context.Context, c client.Client, desiredEdges map[string]ingressv1alpha1.HTTPSEdge, currentEdges []ingressv1alpha1.HTTPSEdge) error {
+func (d *Driver) diffHTTPSEdges(desiredEdges map[string]ingressv1alpha1.HTTPSEdge, currentEdges []ingressv1alpha1.HTTPSEdge) changeSet[ingressv1alpha1.HTTPSEdge] {
+	var changes changeSet[ingressv1alpha1.HTTPSEdge]
+
 	// update or delete edge we don't need anymore
 	for _, currEdge := range currentEdges {
 		domain := currEdge.Labels[labelDomain]
 
 		if desiredEdge, ok := desiredEdges[domain]; ok {
-			needsUpdate := false
-
 			if !reflect.DeepEqual(desiredEdge.Spec, currEdge.Spec) {
-				currEdge.Spec = desiredEdge.Spec
-				needsUpdate = true
-			}
-
-			if needsUpdate {
-				if err := c.Update(ctx, &currEdge); err != nil {
-					d.log.Error(err, "error updating edge", "desiredEdge", desiredEdge, "currEdge", currEdge)
-					return err
-				}
+				updated := currEdge
+				updated.Spec = desiredEdge.Spec
+				changes.addUpdate(currEdge, updated)
 			}
 
 			// matched and updated the edge, no longer desired
 			delete(desiredEdges, domain)
 		} else {
-			if err := c.Delete(ctx, &currEdge); client.IgnoreNotFound(err) != nil {
-				d.log.Error(err, "error deleting edge", "edge", currEdge)
-				return err
-			}
+			changes.delete = append(changes.delete, currEdge)
 		}
 	}
 
 	// the set of desired edges now only contains new edges, create them
 	for _, edge := range desiredEdges {
+		changes.create = append(changes.create, edge)
+	}
+
+	return changes
+}
+
+func (d *Driver) applyHTTPSEdges(ctx context.Context, c client.Client, changes changeSet[ingressv1alpha1.HTTPSEdge]) error {
+	for _, edge := range changes.update {
+		if err := c.Update(ctx, &edge); err != nil {
+			d.log.Error(err, "error updating edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.delete {
+		if err := c.Delete(ctx, &edge); client.IgnoreNotFound(err) != nil {
+			d.log.Error(err, "error deleting edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.create {
 		if err := c.Create(ctx, &edge); err != nil {
 			d.log.Error(err, "error creating edge", "edge", edge)
 			return err
 		}
 	}
-
 	return nil
 }
 
-func (d *Driver) applyTunnels(ctx context.Context, c client.Client, desiredTunnels map[tunnelKey]ingressv1alpha1.Tunnel, currentTunnels []ingressv1alpha1.Tunnel) error {
+func (d *Driver) diffTunnels(desiredTunnels map[tunnelKey]ingressv1alpha1.Tunnel, currentTunnels []ingressv1alpha1.Tunnel) changeSet[ingressv1alpha1.Tunnel] {
+	var changes changeSet[ingressv1alpha1.Tunnel]
+
 	// update or delete tunnels we don't need anymore
 	for _, currTunnel := range currentTunnels {
 		// extract tunnel key
@@ -517,45 +778,59 @@ func (d *Driver) applyTunnels(ctx context.Context, c client.Client, desiredTunne
 		// check if new state still needs this tunnel
 		if desiredTunnel, ok := desiredTunnels[tkey]; ok {
 			needsUpdate := false
+			updated := currTunnel
 
 			// compare/update owner references
 			if !slices.Equal(desiredTunnel.OwnerReferences, currTunnel.OwnerReferences) {
 				needsUpdate = true
-				currTunnel.OwnerReferences = desiredTunnel.OwnerReferences
+				updated.OwnerReferences = desiredTunnel.OwnerReferences
 			}
 
 			// compare/update desired tunnel spec
 			if !reflect.DeepEqual(desiredTunnel.Spec, currTunnel.Spec) {
 				needsUpdate = true
-				currTunnel.Spec = desiredTunnel.Spec
+				updated.Spec = desiredTunnel.Spec
 			}
 
 			if needsUpdate {
-				if err := c.Update(ctx, &currTunnel); err != nil {
-					d.log.Error(err, "error updating tunnel", "tunnel", desiredTunnel)
-					return err
-				}
+				changes.addUpdate(currTunnel, updated)
 			}
 
 			// matched and updated the tunnel, no longer desired
 			delete(desiredTunnels, tkey)
 		} else {
 			// no longer needed, delete it
-			if err := c.Delete(ctx, &currTunnel); client.IgnoreNotFound(err) != nil {
-				d.log.Error(err, "error deleting tunnel", "tunnel", currTunnel)
-				return err
-			}
+			changes.delete = append(changes.delete, currTunnel)
 		}
 	}
 
 	// the set of desired tunnels now only contains new tunnels, create them
 	for _, tunnel := range desiredTunnels {
+		changes.create = append(changes.create, tunnel)
+	}
+
+	return changes
+}
+
+func (d *Driver) applyTunnels(ctx context.Context, c client.Client, changes changeSet[ingressv1alpha1.Tunnel]) error {
+	for _, tunnel := range changes.update {
+		if err := c.Update(ctx, &tunnel); err != nil {
+			d.log.Error(err, "error updating tunnel", "tunnel", tunnel)
+			return err
+		}
+	}
+	for _, tunnel := range changes.delete {
+		if err := c.Delete(ctx, &tunnel); client.IgnoreNotFound(err) != nil {
+			d.log.Error(err, "error deleting tunnel", "tunnel", tunnel)
+			return err
+		}
+	}
+	for _, tunnel := range changes.create {
 		if err := c.Create(ctx, &tunnel); err != nil {
 			d.log.Error(err, "error creating tunnel", "tunnel", tunnel)
 			return err
 		}
 	}
-
 	return nil
 }
 
@@ -574,9 +849,8 @@ func (d *Driver) updateIngressStatuses(ctx context.Context, c client.Client) err
 	return nil
 }
 
-func (d *Driver) calculateDomains() ([]ingressv1alpha1.Domain, []ingressv1alpha1.Domain, map[string]ingressv1alpha1.Domain) {
+func (d *Driver) calculateDomains(ingressDomainMap map[string]ingressv1alpha1.Domain) ([]ingressv1alpha1.Domain, []ingressv1alpha1.Domain, map[string]ingressv1alpha1.Domain) {
 	var domains, ingressDomains []ingressv1alpha1.Domain
-	ingressDomainMap := d.calculateDomainsFromIngress()
 
 	ingressDomains = make([]ingressv1alpha1.Domain, 0, len(ingressDomainMap))
 	for _, domain := range ingressDomainMap {
@@ -621,19 +895,24 @@ func (d *Driver) calculateDomainsFromIngress() map[string]ingressv1alpha1.Domain
 	return domainMap
 }
 
+// calculateDomainsFromGateway calculates the domains to reserve for the hostnames of the Gateway listeners that
+// are served by HTTPS or TLS edges. TCP listeners are served on reserved TCP addresses, and listeners that
+// aren't accepted aren't served at all, so neither gets a domain.
 func (d *Driver) calculateDomainsFromGateway(ingressDomains map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.Domain {
 	domainMap := make(map[string]ingressv1alpha1.Domain)
 
-	gateways := d.store.ListGateways()
+	gateways := d.store.ListNgrokGateways()
 	for _, gw := range gateways {
 		for _, listener := range gw.Spec.Listeners {
-			if listener.Hostname == nil {
+			if listener.Hostname == nil || listener.Protocol == gatewayv1.TCPProtocolType {
+				continue
+			}
+			if reason, _ := listenerAcceptance(listener); reason != gatewayv1.ListenerReasonAccepted {
 				continue
 			}
 			domainName := string(*listener.Hostname)
 			if _, hasVal := ingressDomains[domainName]; hasVal {
-				// TODO update gateway status
-				// also add error to error page
+				// the ingress keeps the domain, the listener is reported as conflicted in the gateway status
 				continue
 			}
 			domain := ingressv1alpha1.Domain{
@@ -653,131 +932,177 @@ func (d *Driver) calculateDomainsFromGateway(ingressDomains map[string]ingressv1
 	return domainMap
 }
 
-// Given an ingress, it will resolve any ngrok modulesets defined on the ingress to the
-// CRDs and then will merge them in to a single moduleset
+// getNgrokModuleSetForIngress resolves the modules of an ingress by merging, from the lowest to the highest
+// precedence:
+//  1. the ClusterNgrokModuleSets that aren't enforced, by name
+//  2. the NgrokModuleSets marked as defaults for the ingress's namespace, by name
+//  3. the NgrokModuleSets in the ingress's modules annotation, in order
+//
+// calculateHTTPSEdgesFromIngress then merges the module annotations, the module sets of each path and finally
+// the enforced ClusterNgrokModuleSets over the result, in that order.
 func (d *Driver) getNgrokModuleSetForIngress(ing *netv1.Ingress) (*ingressv1alpha1.NgrokModuleSet, error) {
 	computedModSet := &ingressv1alpha1.NgrokModuleSet{}
 
 	modules, err := annotations.ExtractNgrokModuleSetsFromAnnotations(ing)
-	if err != nil {
-		if errors.IsMissingAnnotations(err) {
-			return computedModSet, nil
-		}
+	if err != nil && !errors.IsMissingAnnotations(err) {
 		return computedModSet, err
 	}
 
+	for _, clusterModSet := range d.store.ListClusterNgrokModuleSetsV1() {
+		if !clusterModSet.Enforced {
+			overrideModuleSet(computedModSet, clusterModSet.NgrokModuleSet())
+		}
+	}
+
+	for _, modSet := range d.store.ListNgrokModuleSetsV1() {
+		if modSet.Namespace == ing.Namespace && annotations.IsDefaultNgrokModuleSet(modSet) {
+			overrideModuleSet(computedModSet, modSet)
+		}
+	}
+
 	for _, module := range modules {
 		resolvedMod, err := d.store.GetNgrokModuleSetV1(module, ing.Namespace)
 		if err != nil {
 			return computedModSet, err
 		}
-		computedModSet.Merge(resolvedMod)
+		overrideModuleSet(computedModSet, resolvedMod)
 	}
 
 	return computedModSet, nil
 }
 
-func (d *Driver) calculateHTTPSEdges(ingressDomains *[]ingressv1alpha1.Domain, gatewayDomainMap map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.HTTPSEdge {
-	edgeMap := make(map[string]ingressv1alpha1.HTTPSEdge, len(*ingressDomains))
-	for _, domain := range *ingressDomains {
-		edge := ingressv1alpha1.HTTPSEdge{
-			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: domain.Name + "-",
-				Namespace:    domain.Namespace,
-				Labels:       d.edgeLabels(domain.Spec.Domain),
-			},
-			Spec: ingressv1alpha1.HTTPSEdgeSpec{
-				Hostports: []string{domain.Spec.Domain + ":443"},
-			},
+// getNgrokModuleSetForPath merges the module sets for a single path of an ingress over the modules of the whole
+// ingress, so the path's module sets take precedence
+func (d *Driver) getNgrokModuleSetForPath(ing *netv1.Ingress, ingModSet *ingressv1alpha1.NgrokModuleSet, modules []string) (*ingressv1alpha1.NgrokModuleSet, error) {
+	computedModSet := ingModSet.DeepCopy()
+
+	for _, module := range modules {
+		resolvedMod, err := d.store.GetNgrokModuleSetV1(module, ing.Namespace)
+		if err != nil {
+			return computedModSet, err
 		}
-		edge.Spec.Metadata = d.customMetadata
-		edgeMap[domain.Spec.Domain] = edge
+		overrideModuleSet(computedModSet, resolvedMod)
 	}
-	d.calculateHTTPSEdgesFromIngress(edgeMap)
 
-	if d.gatewayEnabled {
-		gatewayEdgeMap := make(map[string]ingressv1alpha1.HTTPSEdge)
-		httproutes := d.store.ListHTTPRoutes()
-		gateways := d.store.ListGateways()
-		for _, gtw := range gateways {
-			gatewayDomains := make(map[string]string)
-			for _, listener := range gtw.Spec.Listeners {
-				if listener.Hostname == nil {
-					continue
-				}
-				if listener.Protocol != gatewayv1.HTTPSProtocolType || int(listener.Port) != 443 {
-					continue
-				}
-				if _, hasDomain := gatewayDomainMap[string(*listener.Hostname)]; !hasDomain {
-					continue
-				}
-				gatewayDomains[string(*listener.Hostname)] = string(*listener.Hostname)
-			}
-			if len(gatewayDomains) == 0 {
-				continue
-			}
-			for _, httproute := range httproutes {
-				var routeDomains []string
-				for _, parent := range httproute.Spec.ParentRefs {
-					if string(parent.Name) != gtw.Name {
-						continue
-					}
-					var domainOverlap []string
-					for _, hostname := range httproute.Spec.Hostnames {
-						domain := string(hostname)
-						if _, hasDomain := gatewayDomains[domain]; hasDomain {
-							domainOverlap = append(domainOverlap, domain)
-						}
-					}
-					if len(domainOverlap) == 0 {
-						// no hostnames overlap with gateway
-						continue
-					}
-					routeDomains = append(routeDomains, domainOverlap...)
-				}
-				if len(routeDomains) == 0 {
-					// no usable domains in route
-					continue
-				}
-				var hostPorts []string
+	return computedModSet, nil
+}
 
-				for _, domain := range routeDomains {
-					hostPorts = append(hostPorts, domain+":443")
-				}
-				edge := ingressv1alpha1.HTTPSEdge{
-					ObjectMeta: metav1.ObjectMeta{
-						GenerateName: httproute.Name + "-",
-						Namespace:    httproute.Namespace,
-						Labels:       d.edgeLabels(routeDomains[0]),
-					},
-					Spec: ingressv1alpha1.HTTPSEdgeSpec{
-						Hostports: hostPorts,
-					},
-				}
-				edge.Spec.Metadata = d.customMetadata
-				gatewayEdgeMap[routeDomains[0]] = edge
+// getEnforcedNgrokModuleSets returns the enforced ClusterNgrokModuleSets, sorted by name. They are applied over
+// every other module of an ingress with applyEnforcedModuleSets, so ingresses can't override them.
+func (d *Driver) getEnforcedNgrokModuleSets() []*ingressv1alpha1.NgrokModuleSet {
+	var enforced []*ingressv1alpha1.NgrokModuleSet
+	for _, clusterModSet := range d.store.ListClusterNgrokModuleSetsV1() {
+		if clusterModSet.Enforced {
+			enforced = append(enforced, clusterModSet.NgrokModuleSet())
+		}
+	}
+	return enforced
+}
+
+// applyEnforcedModuleSets merges the enforced module sets over modSet one at a time with
+// NgrokModuleSet.MergeEnforced, so each is merged with its own merge strategy but can't be weakened by modSet
+func applyEnforcedModuleSets(modSet *ingressv1alpha1.NgrokModuleSet, enforced []*ingressv1alpha1.NgrokModuleSet) {
+	for _, o := range enforced {
+		clearAuthModules(modSet, o)
+		modSet.MergeEnforced(o)
+	}
+}
+
+// overrideModuleSet merges o over modSet with NgrokModuleSet.Merge, clearing modSet's auth modules first with
+// clearAuthModules
+func overrideModuleSet(modSet *ingressv1alpha1.NgrokModuleSet, o *ingressv1alpha1.NgrokModuleSet) {
+	clearAuthModules(modSet, o)
+	modSet.Merge(o)
+}
+
+// clearAuthModules removes the auth modules of modSet if o has one. Like the auth annotations, an auth module in
+// o replaces every auth module of modSet, since only one of OAuth, OIDC and SAML can be used on a route.
+func clearAuthModules(modSet *ingressv1alpha1.NgrokModuleSet, o *ingressv1alpha1.NgrokModuleSet) {
+	if o.Modules.OAuth != nil || o.Modules.OIDC != nil || o.Modules.SAML != nil {
+		modSet.Modules.OAuth = nil
+		modSet.Modules.OIDC = nil
+		modSet.Modules.SAML = nil
+	}
+}
+
+// applyAnnotationModules applies the modules configured with annotations on an ingress over the ones from its
+// module sets. The OAuth, OIDC and SAML annotations replace every auth module from the module sets, since only
+// one can be used on a route, and a policy ConfigMap reference replaces the module sets' policy.
+func applyAnnotationModules(modSet *ingressv1alpha1.NgrokModuleSet, routeModules *annotations.RouteModules) {
+	if routeModules.OAuth != nil || routeModules.OIDC != nil || routeModules.SAML != nil {
+		modSet.Modules.OAuth = routeModules.OAuth
+		modSet.Modules.OIDC = routeModules.OIDC
+		modSet.Modules.SAML = routeModules.SAML
+	}
+	if routeModules.CircuitBreaker != nil {
+		modSet.Modules.CircuitBreaker = routeModules.CircuitBreaker
+	}
+	if routeModules.Policy != nil || routeModules.PolicyConfigMapRef != nil {
+		modSet.Modules.Policy = routeModules.Policy
+	}
+	if routeModules.MutualTLS != nil {
+		modSet.Modules.MutualTLS = routeModules.MutualTLS
+	}
+	if routeModules.WebsocketTCPConverter != nil {
+		modSet.Modules.WebsocketTCPConverter = routeModules.WebsocketTCPConverter
+	}
+}
 
-			}
+// calculateHTTPSEdges calculates the edges of the domains from the Ingresses and HTTPRoutes. It also returns the
+// paths of each Ingress left out because of a policy conflict and the ignored rules of each HTTPRoute.
+func (d *Driver) calculateHTTPSEdges(ingressDomains *[]ingressv1alpha1.Domain, gatewayDomainMap, ingressDomainMap map[string]ingressv1alpha1.Domain) (map[string]ingressv1alpha1.HTTPSEdge, map[types.NamespacedName][]string, map[types.NamespacedName][]string) {
+	edgeMap := make(map[string]ingressv1alpha1.HTTPSEdge, len(*ingressDomains))
+	for _, domain := range *ingressDomains {
+		edge := ingressv1alpha1.HTTPSEdge{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: domain.Name + "-",
+				Namespace:    domain.Namespace,
+				Labels:       d.edgeLabels(domain.Spec.Domain),
+			},
+			Spec: ingressv1alpha1.HTTPSEdgeSpec{
+				Hostports: []string{domain.Spec.Domain + ":443"},
+			},
 		}
-		d.calculateHTTPSEdgesFromGateway(gatewayEdgeMap)
+		edge.Spec.Metadata = d.customMetadata
+		edgeMap[domain.Spec.Domain] = edge
+	}
+	policyConflicts := d.calculateHTTPSEdgesFromIngress(edgeMap)
 
-		// merge edge maps
-		for k, v := range gatewayEdgeMap {
-			edgeMap[k] = v
-		}
+	var ignoredHTTPRouteRules map[types.NamespacedName][]string
+	if d.gatewayEnabled {
+		ignoredHTTPRouteRules = d.calculateHTTPSEdgesFromGateway(edgeMap, gatewayDomainMap, ingressDomainMap)
 	}
 
-	return edgeMap
+	return edgeMap, policyConflicts, ignoredHTTPRouteRules
 }
 
-func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alpha1.HTTPSEdge) {
+// calculateHTTPSEdgesFromIngress adds the routes of the Ingresses' rules to the edges of their hosts. A route whose
+// policy-configmap-name annotation conflicts with the policy of a path or enforced module set is left out rather
+// than silently dropping one of the policies, and returned by Ingress.
+func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alpha1.HTTPSEdge) map[types.NamespacedName][]string {
+	policyConflicts := map[types.NamespacedName][]string{}
 	ingresses := d.store.ListNgrokIngressesV1()
+	enforcedModSets := d.getEnforcedNgrokModuleSets()
 	for _, ingress := range ingresses {
 		modSet, err := d.getNgrokModuleSetForIngress(ingress)
 		if err != nil {
 			d.log.Error(err, "error getting ngrok moduleset for ingress", "ingress", ingress)
 			continue
 		}
+		routeModules, err := d.annotationsExtractor.Extract(ingress)
+		if err != nil {
+			// don't publish the ingress' routes without the security modules it asked for
+			d.log.Error(err, "invalid security annotations on ingress, skipping its rules", "ingress", ingress)
+			continue
+		}
+		applyAnnotationModules(modSet, routeModules)
+		pathModSets, err := annotations.ExtractPathNgrokModuleSetsFromAnnotations(ingress)
+		if err != nil && !errors.IsMissingAnnotations(err) {
+			d.log.Error(err, "error getting path ngrok modulesets for ingress", "ingress", ingress)
+			continue
+		}
+		edgeModSet := modSet.DeepCopy()
+		applyEnforcedModuleSets(edgeModSet, enforcedModSets)
 
 		for _, rule := range ingress.Spec.Rules {
 			// TODO: Handle routes without hosts that then apply to all edges
@@ -787,8 +1112,11 @@ func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alph
 				continue
 			}
 
-			if modSet.Modules.TLSTermination != nil {
-				edge.Spec.TLSTermination = modSet.Modules.TLSTermination
+			if edgeModSet.Modules.TLSTermination != nil {
+				edge.Spec.TLSTermination = edgeModSet.Modules.TLSTermination
+			}
+			if edgeModSet.Modules.MutualTLS != nil {
+				edge.Spec.MutualTLS = edgeModSet.Modules.MutualTLS
 			}
 
 			// If any rule for an ingress matches, then it applies to this ingress
@@ -820,21 +1148,37 @@ func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alph
 					continue
 				}
 
+				// TLS termination and mutual TLS apply to the whole edge, so they only come from edgeModSet
+				routeModSet, err := d.getNgrokModuleSetForPath(ingress, modSet, pathModSets.ForPath(rule.Host, httpIngressPath.Path))
+				if err != nil {
+					d.log.Error(err, "error getting ngrok modulesets for path", "ingress", ingress, "host", rule.Host, "path", httpIngressPath.Path)
+					continue
+				}
+				applyEnforcedModuleSets(routeModSet, enforcedModSets)
+				if routeModules.PolicyConfigMapRef != nil && routeModSet.Modules.Policy != nil {
+					d.log.Info("policy ConfigMap of ingress conflicts with the policy of a module set, skipping path", "ingress", ingress, "host", rule.Host, "path", httpIngressPath.Path)
+					key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+					policyConflicts[key] = append(policyConflicts[key], rule.Host+httpIngressPath.Path)
+					continue
+				}
+
 				route := ingressv1alpha1.HTTPSEdgeRouteSpec{
 					Match:     httpIngressPath.Path,
 					MatchType: matchType,
 					Backend: ingressv1alpha1.TunnelGroupBackend{
 						Labels: d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
 					},
-					CircuitBreaker:      modSet.Modules.CircuitBreaker,
-					Compression:         modSet.Modules.Compression,
-					IPRestriction:       modSet.Modules.IPRestriction,
-					Headers:             modSet.Modules.Headers,
-					OAuth:               modSet.Modules.OAuth,
-					Policy:              modSet.Modules.Policy,
-					OIDC:                modSet.Modules.OIDC,
-					SAML:                modSet.Modules.SAML,
-					WebhookVerification: modSet.Modules.WebhookVerification,
+					CircuitBreaker:        routeModSet.Modules.CircuitBreaker,
+					Compression:           routeModSet.Modules.Compression,
+					IPRestriction:         routeModSet.Modules.IPRestriction,
+					Headers:               routeModSet.Modules.Headers,
+					OAuth:                 routeModSet.Modules.OAuth,
+					Policy:                routeModSet.Modules.Policy,
+					PolicyConfigMapRef:    routeModules.PolicyConfigMapRef,
+					OIDC:                  routeModSet.Modules.OIDC,
+					SAML:                  routeModSet.Modules.SAML,
+					WebhookVerification:   routeModSet.Modules.WebhookVerification,
+					WebsocketTCPConverter: routeModSet.Modules.WebsocketTCPConverter,
 				}
 				route.Metadata = d.customMetadata
 
@@ -844,147 +1188,374 @@ func (d *Driver) calculateHTTPSEdgesFromIngress(edgeMap map[string]ingressv1alph
 			edgeMap[rule.Host] = edge
 		}
 	}
+	return policyConflicts
+}
+
+// recordPolicyConflicts records a warning event on each Ingress with paths left out because of a policy conflict
+func (d *Driver) recordPolicyConflicts(policyConflicts map[types.NamespacedName][]string) {
+	if d.recorder == nil {
+		return
+	}
+	for key, paths := range policyConflicts {
+		ingress, err := d.store.GetIngressV1(key.Name, key.Namespace)
+		if err != nil {
+			continue
+		}
+		d.recorder.Eventf(ingress, corev1.EventTypeWarning, "PolicyConflict",
+			"The policy-configmap-name annotation conflicts with the policy of a module set, these paths are not served: %s",
+			strings.Join(paths, ", "))
+	}
 }
 
-func (d *Driver) calculateHTTPSEdgesFromGateway(edgeMap map[string]ingressv1alpha1.HTTPSEdge) {
-	gateways := d.store.ListGateways()
+// calculateHTTPSEdgesFromGateway adds an HTTPSEdge for each hostname of an HTTP or HTTPS listener, with a route for
+// each path matched by the rules of the HTTPRoutes the listener accepted. ngrok edge routes only match on the path
+// and forward to a single set of backends, so when rules share a path only one of them is served. A rule that also
+// matches on the method, headers, or query params denies the requests it doesn't match, so it's only served when
+// no rule matches the path alone; otherwise the one with the highest precedence is. The rules that aren't served
+// are returned by HTTPRoute, to be reported in their statuses.
+func (d *Driver) calculateHTTPSEdgesFromGateway(edgeMap map[string]ingressv1alpha1.HTTPSEdge, gatewayDomainMap, ingressDomains map[string]ingressv1alpha1.Domain) map[types.NamespacedName][]string {
+	edgeRoutes := map[string][]gatewayEdgeRoute{}
+	ignoredRules := map[types.NamespacedName][]string{}
+	enforcedModSets := d.getEnforcedNgrokModuleSets()
+	edgeModSet := &ingressv1alpha1.NgrokModuleSet{}
+	applyEnforcedModuleSets(edgeModSet, enforcedModSets)
 
-	for _, gtw := range gateways {
-		for _, listener := range gtw.Spec.Listeners {
-			if listener.Hostname == nil {
-				continue
-			}
-			allowedRoutes := listener.AllowedRoutes.Kinds
-			if len(allowedRoutes) > 0 {
-				createHttpsedge := false
-				for _, routeKind := range allowedRoutes {
-					if routeKind.Kind == "HTTPRoute" {
-						createHttpsedge = true
-					}
-				}
-				if !createHttpsedge {
+	httproutes := d.store.ListHTTPRoutes()
+	for _, httproute := range httproutes {
+		// a route can reach the same listener through more than one parentRef, only add its rules once
+		routeDomains := map[string]bool{}
+		for _, parent := range d.resolveRouteParents(kindHTTPRoute, httproute.Namespace, httproute.Spec.ParentRefs, httproute.Spec.Hostnames, ingressDomains) {
+			for _, listener := range parent.listeners {
+				domainName := string(*listener.Hostname)
+				if _, ok := gatewayDomainMap[domainName]; !ok || routeDomains[domainName] {
 					continue
 				}
-			}
-			domainName := string(*listener.Hostname)
-			edge, ok := edgeMap[domainName]
-			if !ok {
-				continue
-			}
-			// TODO: Calculate routes from httpRoutes
-			// TODO: skip if no backend services
-			httproutes := d.store.ListHTTPRoutes()
-			for _, httproute := range httproutes {
-				for _, parent := range httproute.Spec.ParentRefs {
-					if string(parent.Name) != gtw.Name {
-						// not our gateway so skip
-						continue
+				routeDomains[domainName] = true
+
+				if _, ok := edgeMap[domainName]; !ok {
+					edge := ingressv1alpha1.HTTPSEdge{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: httproute.Name + "-",
+							Namespace:    httproute.Namespace,
+							Labels:       d.edgeLabels(domainName),
+						},
+						Spec: ingressv1alpha1.HTTPSEdgeSpec{
+							Hostports: []string{domainName + ":443"},
+						},
+					}
+					edge.Spec.Metadata = d.customMetadata
+					edgeMap[domainName] = edge
+				}
+				if edgeModSet.Modules.TLSTermination != nil || edgeModSet.Modules.MutualTLS != nil {
+					edge := edgeMap[domainName]
+					if edgeModSet.Modules.TLSTermination != nil {
+						edge.Spec.TLSTermination = edgeModSet.Modules.TLSTermination
 					}
+					if edgeModSet.Modules.MutualTLS != nil {
+						edge.Spec.MutualTLS = edgeModSet.Modules.MutualTLS
+					}
+					edgeMap[domainName] = edge
+				}
 
-					if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces.From != nil {
-						switch *listener.AllowedRoutes.Namespaces.From {
-						case gatewayv1.NamespacesFromAll:
-						case gatewayv1.NamespacesFromSame:
-							if httproute.Namespace != gtw.Namespace {
-								continue
-							}
-						case gatewayv1.NamespacesFromSelector:
-							if httproute.Namespace != listener.AllowedRoutes.Namespaces.Selector.String() {
-								continue
-							}
+				for ruleIndex, rule := range httproute.Spec.Rules {
+					for _, group := range groupHTTPRouteMatchesByPath(rule.Matches) {
+						route := ingressv1alpha1.HTTPSEdgeRouteSpec{
+							Match:     group.path,
+							MatchType: group.matchType,
 						}
-					}
 
-					// matches our gateway
-					for _, hostname := range httproute.Spec.Hostnames {
-						if string(hostname) != string(*listener.Hostname) {
-							// doesn't match this listener
+						policy, err := d.createEndpointPolicyForGateway(&rule, group.matches)
+						if err != nil {
+							d.log.Error(err, "error creating policy from HTTPRouteRule", "rule", rule)
 							continue
 						}
-						// matches gateway and listener
-						for _, rule := range httproute.Spec.Rules {
-							// TODO: resolve rule.Matches
-							// TODO: resolve rule.Filters
-							// for v0 we will only resolve the first backendRef
-							pathMatch := "/"
-							pathMatchType := "path_prefix"
-							// first match with a path will be accepted as the route's path
-							for _, match := range rule.Matches {
-								if match.Path != nil {
-									pathMatch = *match.Path.Value
-									if *match.Path.Type == gatewayv1.PathMatchExact {
-										pathMatchType = "exact_path"
-									}
-									break
-								}
-							}
-							route := ingressv1alpha1.HTTPSEdgeRouteSpec{
-								Match:     pathMatch,     // change based on the rule.match
-								MatchType: pathMatchType, // change based on rule.Matches
-							}
-
-							// TODO: set with values from rules.Filters + rules.Matches
-							policy, err := d.createEndpointPolicyForGateway(&rule)
-							if err != nil {
-								d.log.Error(err, "error creating policy from HTTPRouteRule", "rule", rule)
-								continue
-							}
-							route.Policy = policy
-
-							for idx, backendref := range rule.BackendRefs {
-								// currently the ingress controller doesn't support weighted backends
-								// so we'll only support one backendref per rule
-								// TODO: remove when tested with multiple backends
-								if idx > 0 {
-									break
-								}
-								// handle backendref
-								refKind := string(*backendref.Kind)
-								if refKind != "Service" {
-									// only support services currently
-									continue
-								}
-
-								refName := string(backendref.Name)
-								//refNamespace := string(*backendref.Namespace)
-								serviceUID, servicePort, err := d.getEdgeBackendRef(backendref.BackendRef, gtw.Namespace)
-								if err != nil {
-									d.log.Error(err, "could not find port for service", "namespace", gtw.Namespace, "service", refName)
-								}
-
-								route.Backend = ingressv1alpha1.TunnelGroupBackend{
-									Labels: d.ngrokLabels(gtw.Namespace, serviceUID, refName, servicePort),
-								}
-
-							}
-							// set different customMetadata for gateways next
-							route.Metadata = d.customMetadata
-
-							edge.Spec.Routes = append(edge.Spec.Routes, route)
+						applyEnforcedModulesToGatewayRoute(&route, policy, enforcedModSets)
+
+						backends := d.calculateHTTPRouteRuleBackends(httproute.Namespace, rule.BackendRefs)
+						switch {
+						case len(backends) == 0 && len(rule.BackendRefs) > 0:
+							// the unresolved backendRefs are reported in the route's ResolvedRefs condition
+							d.log.Info("no backendRefs of HTTPRoute rule resolve to a Service, skipping", "namespace", httproute.Namespace, "name", httproute.Name)
+							continue
+						case len(backends) == 1:
+							// a single backend doesn't need to be weighted
+							route.Backend = ingressv1alpha1.TunnelGroupBackend{Labels: backends[0].Labels}
+						case len(backends) > 1:
+							route.WeightedBackends = backends
 						}
+
+						// set different customMetadata for gateways next
+						route.Metadata = d.customMetadata
+
+						edgeRoutes[domainName] = append(edgeRoutes[domainName], gatewayEdgeRoute{
+							httproute: httproute,
+							ruleIndex: ruleIndex,
+							matches:   group.matches,
+							spec:      route,
+						})
 					}
 				}
 			}
+		}
+	}
+
+	for domainName, routes := range edgeRoutes {
+		sort.SliceStable(routes, func(i, j int) bool {
+			return routes[i].precedes(routes[j])
+		})
+
+		// group the routes by path, in order of precedence
+		var paths []string
+		pathRoutes := map[string][]gatewayEdgeRoute{}
+		for _, route := range routes {
+			path := route.spec.MatchType + " " + route.spec.Match
+			if _, ok := pathRoutes[path]; !ok {
+				paths = append(paths, path)
+			}
+			pathRoutes[path] = append(pathRoutes[path], route)
+		}
+
+		edge := edgeMap[domainName]
+		for _, path := range paths {
+			// an edge route can only forward to one set of backends. A rule with conditions would deny the
+			// requests of a less specific rule matching the path alone, such as a header canary in front of its
+			// stable rule, so the first rule without conditions is served if there is one.
+			candidates := pathRoutes[path]
+			served := slices.IndexFunc(candidates, func(route gatewayEdgeRoute) bool {
+				return !route.conditional()
+			})
+			if served == -1 {
+				served = 0
+			}
+			edge.Spec.Routes = append(edge.Spec.Routes, candidates[served].spec)
+
+			// the others are reported in the route's PartiallyInvalid condition
+			for i, route := range candidates {
+				if i == served {
+					continue
+				}
+				d.log.Info("HTTPRoute rule matches the same path as the rule served for it, skipping",
+					"namespace", route.httproute.Namespace, "name", route.httproute.Name, "rule", route.ruleIndex, "path", route.spec.Match)
+				key := types.NamespacedName{Namespace: route.httproute.Namespace, Name: route.httproute.Name}
+				ignored := fmt.Sprintf("rule %d (%s)", route.ruleIndex, route.spec.Match)
+				if !slices.Contains(ignoredRules[key], ignored) {
+					ignoredRules[key] = append(ignoredRules[key], ignored)
+				}
+			}
+		}
+		edgeMap[domainName] = edge
+	}
+
+	// edges are calculated in map order, keep the route statuses stable
+	for _, rules := range ignoredRules {
+		slices.Sort(rules)
+	}
+	return ignoredRules
+}
+
+// applyEnforcedModulesToGatewayRoute sets the modules of the enforced module sets on an edge route of an HTTPRoute
+// rule. The rule's policy implements its matches and filters, so the enforced policies are always prepended to it
+// rather than replacing it, whatever their merge strategy, and a rule with a terminal action can't skip them.
+func applyEnforcedModulesToGatewayRoute(route *ingressv1alpha1.HTTPSEdgeRouteSpec, policy *ingressv1alpha1.EndpointPolicy, enforced []*ingressv1alpha1.NgrokModuleSet) {
+	modSet := &ingressv1alpha1.NgrokModuleSet{}
+	applyEnforcedModuleSets(modSet, enforced)
+
+	route.CircuitBreaker = modSet.Modules.CircuitBreaker
+	route.Compression = modSet.Modules.Compression
+	route.IPRestriction = modSet.Modules.IPRestriction
+	route.Headers = modSet.Modules.Headers
+	route.OAuth = modSet.Modules.OAuth
+	route.OIDC = modSet.Modules.OIDC
+	route.SAML = modSet.Modules.SAML
+	route.WebhookVerification = modSet.Modules.WebhookVerification
+	route.WebsocketTCPConverter = modSet.Modules.WebsocketTCPConverter
+
+	route.Policy = policy
+	if modSet.Modules.Policy != nil {
+		withPolicy := &ingressv1alpha1.NgrokModuleSet{Modules: ingressv1alpha1.NgrokModuleSetModules{Policy: policy}}
+		withPolicy.MergeEnforced(&ingressv1alpha1.NgrokModuleSet{
+			Modules:       ingressv1alpha1.NgrokModuleSetModules{Policy: modSet.Modules.Policy},
+			MergeStrategy: ingressv1alpha1.MergeStrategyMerge,
+		})
+		route.Policy = withPolicy.Modules.Policy
+	}
+}
+
+// httpRouteMatchGroup is the matches of an HTTPRoute rule that share a path, and can be served by one edge route
+type httpRouteMatchGroup struct {
+	matchType string
+	path      string
+	matches   []gatewayv1.HTTPRouteMatch
+}
+
+// groupHTTPRouteMatchesByPath groups the matches of a rule by path, in the order the paths first appear. A rule
+// without matches matches every path.
+func groupHTTPRouteMatchesByPath(matches []gatewayv1.HTTPRouteMatch) []httpRouteMatchGroup {
+	if len(matches) == 0 {
+		return []httpRouteMatchGroup{{matchType: "path_prefix", path: "/"}}
+	}
+
+	var groups []httpRouteMatchGroup
+	for _, match := range matches {
+		matchType, path := "path_prefix", "/"
+		if match.Path != nil {
+			if match.Path.Value != nil {
+				path = *match.Path.Value
+			}
+			if match.Path.Type != nil && *match.Path.Type == gatewayv1.PathMatchExact {
+				matchType = "exact_path"
+			}
+		}
+
+		idx := slices.IndexFunc(groups, func(g httpRouteMatchGroup) bool {
+			return g.matchType == matchType && g.path == path
+		})
+		if idx == -1 {
+			groups = append(groups, httpRouteMatchGroup{matchType: matchType, path: path})
+			idx = len(groups) - 1
+		}
+		groups[idx].matches = append(groups[idx].matches, match)
+	}
+	return groups
+}
+
+// gatewayEdgeRoute is an edge route calculated from the matches of an HTTPRoute rule that share a path
+type gatewayEdgeRoute struct {
+	httproute *gatewayv1.HTTPRoute
+	ruleIndex int
+	matches   []gatewayv1.HTTPRouteMatch
+	spec      ingressv1alpha1.HTTPSEdgeRouteSpec
+}
+
+// precedes orders edge routes by the precedence the Gateway API gives to HTTPRoute rules: exact paths, then the
+// longest prefixes, then the most specific match by method, number of headers, and number of query params. Ties
+// are broken by the oldest HTTPRoute, then its namespace and name, then the rule order.
+func (r gatewayEdgeRoute) precedes(other gatewayEdgeRoute) bool {
+	if (r.spec.MatchType == "exact_path") != (other.spec.MatchType == "exact_path") {
+		return r.spec.MatchType == "exact_path"
+	}
+	if len(r.spec.Match) != len(other.spec.Match) {
+		return len(r.spec.Match) > len(other.spec.Match)
+	}
+
+	method, headers, queryParams := r.specificity()
+	otherMethod, otherHeaders, otherQueryParams := other.specificity()
+	if method != otherMethod {
+		return method
+	}
+	if headers != otherHeaders {
+		return headers > otherHeaders
+	}
+	if queryParams != otherQueryParams {
+		return queryParams > otherQueryParams
+	}
+
+	if !r.httproute.CreationTimestamp.Equal(&other.httproute.CreationTimestamp) {
+		return r.httproute.CreationTimestamp.Before(&other.httproute.CreationTimestamp)
+	}
+	if r.httproute.Namespace != other.httproute.Namespace {
+		return r.httproute.Namespace < other.httproute.Namespace
+	}
+	if r.httproute.Name != other.httproute.Name {
+		return r.httproute.Name < other.httproute.Name
+	}
+	return r.ruleIndex < other.ruleIndex
+}
+
+// conditional returns true if every match of the route also matches on the method, headers, or query params, so
+// its policy denies the requests on its path that don't satisfy them
+func (r gatewayEdgeRoute) conditional() bool {
+	if len(r.matches) == 0 {
+		return false
+	}
+	for _, match := range r.matches {
+		if match.Method == nil && len(match.Headers) == 0 && len(match.QueryParams) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity returns the most specific of the route's matches, in the order the Gateway API ranks them
+func (r gatewayEdgeRoute) specificity() (method bool, headers int, queryParams int) {
+	for _, match := range r.matches {
+		m, h, q := match.Method != nil, len(match.Headers), len(match.QueryParams)
+		switch {
+		case m != method:
+			if m {
+				method, headers, queryParams = m, h, q
+			}
+		case h != headers:
+			if h > headers {
+				headers, queryParams = h, q
+			}
+		case q > queryParams:
+			queryParams = q
+		}
+	}
+	return method, headers, queryParams
+}
+
+// maxBackendWeight is the largest weight of a WeightedTunnelGroupBackend. The Gateway API allows backendRef weights
+// up to 1,000,000, so larger weights are scaled down to fit.
+const maxBackendWeight = 10000
+
+// calculateHTTPRouteRuleBackends resolves the backendRefs of an HTTPRoute rule to weighted tunnel group backends.
+// Refs that don't resolve, or have a weight of 0, don't receive any traffic and are left out.
+func (d *Driver) calculateHTTPRouteRuleBackends(namespace string, refs []gatewayv1.HTTPBackendRef) []ingressv1alpha1.WeightedTunnelGroupBackend {
+	var backends []ingressv1alpha1.WeightedTunnelGroupBackend
+	maxWeight := int64(0)
+	for _, ref := range refs {
+		weight := int64(1)
+		if ref.Weight != nil {
+			weight = int64(*ref.Weight)
+		}
+		if weight == 0 {
+			continue
+		}
+
+		service, port, _, err := d.resolveRouteServiceBackend(namespace, ref.BackendRef)
+		if err != nil {
+			d.log.Error(err, "could not resolve backendRef", "namespace", namespace, "service", ref.Name)
+			continue
+		}
+
+		backends = append(backends, ingressv1alpha1.WeightedTunnelGroupBackend{
+			Labels: d.ngrokLabels(service.Namespace, string(service.UID), service.Name, port.Port),
+			Weight: weight,
+		})
+		maxWeight = max(maxWeight, weight)
+	}
 
-			edgeMap[domainName] = edge
+	if maxWeight > maxBackendWeight {
+		// keep the weights proportional, without dropping a backend whose share rounds down to 0
+		for i := range backends {
+			backends[i].Weight = max(1, backends[i].Weight*maxBackendWeight/maxWeight)
 		}
 	}
+	return backends
 }
 
 type Actions struct {
 	endpointActions []ingressv1alpha1.EndpointAction
 }
 
-func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule) (*ingressv1alpha1.EndpointPolicy, error) {
+// createEndpointPolicyForGateway creates the policy for the edge route serving the given matches of an HTTPRoute
+// rule. The rule's filters become actions, and the method, header, and query param matches become an expression
+// the actions are conditional on. Requests that don't satisfy any of the matches are denied.
+func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule, matches []gatewayv1.HTTPRouteMatch) (*ingressv1alpha1.EndpointPolicy, error) {
 	inboundActions := Actions{}
 	outboundActions := Actions{}
 	expressions := []string{}
 	pathPrefixMatches := []string{}
 
+	// a rule without matches matches every request
+	unconditional := len(matches) == 0
+
 	// NOTE: matches are only defined on requests, and fitlers are only triggered by matches,
 	// but some fitlers define transformations on responses, so we need to define matches on both
 	// Policy.Inbound and Policy.Outbound when possible to work with ngrok's system
-	for _, match := range rule.Matches {
+	for _, match := range matches {
 		if match.Path != nil {
 			if match.Path.Type != nil {
 				switch *match.Path.Type {
@@ -1001,17 +1572,19 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule) (
 			}
 		}
 
-		if match.Method != nil {
-			d.log.Error(fmt.Errorf("Unsupported match type"), "Unsupported match type", "HTTPMethod", *match.Method)
-		}
-
-		if len(match.Headers) > 0 {
-			d.log.Error(fmt.Errorf("Unsupported match type"), "Unsupported match type", "HTTPHeaderMatch", match.Headers)
+		expression, err := httpRouteMatchExpression(match)
+		if err != nil {
+			return nil, err
 		}
-
-		if len(match.QueryParams) > 0 {
-			d.log.Error(fmt.Errorf("Unsupported match type"), "Unsupported match type", "HTTPQueryParamMatch", match.QueryParams)
+		if expression == "" {
+			// only the path has to match, which the edge route already does
+			unconditional = true
+			continue
 		}
+		expressions = append(expressions, expression)
+	}
+	if unconditional {
+		expressions = []string{}
 	}
 
 	responseHeaders := make(map[string]string)
@@ -1034,9 +1607,13 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule) (
 				return nil, err
 			}
 		case gatewayv1.HTTPRouteFilterURLRewrite:
-			return nil, errors.NewErrorNotFound(fmt.Sprintf("Unsupported filter HTTPRouteFilterType %v found", filter.Type))
+			err := d.handleURLRewriteFilter(filter.URLRewrite, pathPrefixMatches, &inboundActions)
+			if err != nil {
+				return nil, err
+			}
 		case gatewayv1.HTTPRouteFilterRequestMirror:
-			return nil, errors.NewErrorNotFound(fmt.Sprintf("Unsupported filter HTTPRouteFilterType %v found", filter.Type))
+			// mirroring is not supported by ngrok, the filter is ignored and reported in the HTTPRoute status
+			d.log.V(1).Info("ignoring unsupported filter", "HTTPRouteFilterType", filter.Type)
 		case gatewayv1.HTTPRouteFilterExtensionRef:
 			return nil, errors.NewErrorNotFound(fmt.Sprintf("Unsupported filter HTTPRouteFilterType %v found", filter.Type))
 		default:
@@ -1048,20 +1625,40 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule) (
 	enabled := true
 
 	if len(expressions) > 1 {
-		expressions = []string{strings.Join(expressions[:], " || ")}
+		expressions = []string{"(" + strings.Join(expressions[:], ") || (") + ")"}
 	}
 
-	if len(inboundActions.endpointActions) > 0 {
-		policy = &ingressv1alpha1.EndpointPolicy{
-			Enabled: &enabled,
-			// NOTE: Mapping each HTTPRouteRule to one Inbound endpoint rule
-			Inbound: []ingressv1alpha1.EndpointRule{
+	var inboundRules []ingressv1alpha1.EndpointRule
+	if len(expressions) > 0 {
+		// The edge route only matches on the path, so requests that don't satisfy the rest of the matches are
+		// denied the same way as requests no HTTPRoute matches
+		deny, err := json.Marshal(DenyConfig{StatusCode: http.StatusNotFound})
+		if err != nil {
+			return nil, err
+		}
+		inboundRules = append(inboundRules, ingressv1alpha1.EndpointRule{
+			Expressions: []string{"!(" + expressions[0] + ")"},
+			Actions: []ingressv1alpha1.EndpointAction{
 				{
-					Expressions: expressions,
-					Actions:     inboundActions.endpointActions,
-					Name:        "Inbound HTTPRouteRule",
+					Type:   "deny",
+					Config: deny,
 				},
 			},
+			Name: "Deny unmatched HTTPRouteRule",
+		})
+	}
+	if len(inboundActions.endpointActions) > 0 {
+		// NOTE: Mapping each HTTPRouteRule to one Inbound endpoint rule
+		inboundRules = append(inboundRules, ingressv1alpha1.EndpointRule{
+			Expressions: expressions,
+			Actions:     inboundActions.endpointActions,
+			Name:        "Inbound HTTPRouteRule",
+		})
+	}
+	if len(inboundRules) > 0 {
+		policy = &ingressv1alpha1.EndpointPolicy{
+			Enabled: &enabled,
+			Inbound: inboundRules,
 		}
 	}
 	if len(outboundActions.endpointActions) > 0 {
@@ -1083,6 +1680,63 @@ func (d *Driver) createEndpointPolicyForGateway(rule *gatewayv1.HTTPRouteRule) (
 	return policy, nil
 }
 
+type DenyConfig struct {
+	StatusCode int `json:"status_code"`
+}
+
+// httpRouteMatchExpression converts the method, header, and query param conditions of an HTTPRoute match into a
+// CEL expression. All of the conditions have to be satisfied. It returns an empty string if the match only has
+// a path. Header names are case insensitive, so they are lowercased like the keys of req.headers.
+func httpRouteMatchExpression(match gatewayv1.HTTPRouteMatch) (string, error) {
+	var conditions []string
+
+	if match.Method != nil {
+		conditions = append(conditions, fmt.Sprintf("req.method == %s", strconv.Quote(string(*match.Method))))
+	}
+
+	for _, header := range match.Headers {
+		condition, err := valuesMatchExpression("req.headers", strings.ToLower(string(header.Name)), header.Type, header.Value)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	for _, param := range match.QueryParams {
+		var matchType *gatewayv1.HeaderMatchType
+		if param.Type != nil {
+			matchType = (*gatewayv1.HeaderMatchType)(param.Type)
+		}
+		condition, err := valuesMatchExpression("req.url.query_params", string(param.Name), matchType, param.Value)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return strings.Join(conditions, " && "), nil
+}
+
+// valuesMatchExpression returns a CEL expression checking that one of the values of key in the map of lists
+// variable matches value, either exactly or as a regular expression
+func valuesMatchExpression(variable string, key string, matchType *gatewayv1.HeaderMatchType, value string) (string, error) {
+	quotedKey := strconv.Quote(key)
+	quotedValue := strconv.Quote(value)
+
+	comparison := fmt.Sprintf("v == %s", quotedValue)
+	if matchType != nil {
+		switch *matchType {
+		case gatewayv1.HeaderMatchExact:
+		case gatewayv1.HeaderMatchRegularExpression:
+			comparison = fmt.Sprintf("v.matches(%s)", quotedValue)
+		default:
+			return "", errors.NewErrorNotFound(fmt.Sprintf("Unknown match type %v found", *matchType))
+		}
+	}
+
+	return fmt.Sprintf("(%s in %s && %s[%s].exists(v, %s))", quotedKey, variable, variable, quotedKey, comparison), nil
+}
+
 type RemoveHeadersConfig struct {
 	Headers []string `json:"headers"`
 }
@@ -1264,6 +1918,71 @@ func (d *Driver) handleRequestRedirectFilter(filter *gatewayv1.HTTPRequestRedire
 	return nil
 }
 
+type URLRewriteConfig struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (d *Driver) createUrlRewriteConfig(from string, to string, actions *Actions) error {
+	urlRewriteAction := URLRewriteConfig{
+		From: from,
+		To:   to,
+	}
+	config, err := json.Marshal(urlRewriteAction)
+	if err != nil {
+		d.log.Error(err, "cannot convert url rewrite filter to json", "HTTPURLRewriteFilter", urlRewriteAction)
+		return err
+	}
+	actions.endpointActions = append(
+		actions.endpointActions,
+		ingressv1alpha1.EndpointAction{
+			Type:   "url-rewrite",
+			Config: config,
+		},
+	)
+
+	return nil
+}
+
+func (d *Driver) handleURLRewriteFilter(filter *gatewayv1.HTTPURLRewriteFilter, pathPrefixMatches []string, actions *Actions) error {
+	if filter == nil {
+		return nil
+	}
+
+	hostname := "$host"
+	if filter.Hostname != nil {
+		hostname = string(*filter.Hostname)
+	}
+
+	if filter.Path == nil {
+		from := "^https?://[^/:]+(:[0-9]*)?(.*)$"
+		to := fmt.Sprintf("$scheme://%s$1$2", hostname)
+		return d.createUrlRewriteConfig(from, to, actions)
+	}
+
+	switch filter.Path.Type {
+	case gatewayv1.PrefixMatchHTTPPathModifier:
+		// Trailing slashes are trimmed so that the rest of the path keeps its leading slash, e.g. replacing
+		// the prefix /foo/ with / rewrites /foo/bar to /bar
+		replacement := strings.TrimSuffix(*filter.Path.ReplacePrefixMatch, "/")
+		for _, pathPrefix := range pathPrefixMatches {
+			from := fmt.Sprintf("^https?://[^/:]+(:[0-9]*)?(%s)([^\\?]*)(\\?.*)?$", regexp.QuoteMeta(strings.TrimSuffix(pathPrefix, "/")))
+			to := fmt.Sprintf("$scheme://%s$1%s$3$4", hostname, replacement)
+			if err := d.createUrlRewriteConfig(from, to, actions); err != nil {
+				return err
+			}
+		}
+	case gatewayv1.FullPathHTTPPathModifier:
+		from := "^https?://[^/:]+(:[0-9]*)?([^\\?]*)(\\?.*)?$"
+		to := fmt.Sprintf("$scheme://%s$1%s$3", hostname, *filter.Path.ReplaceFullPath)
+		return d.createUrlRewriteConfig(from, to, actions)
+	default:
+		d.log.Error(fmt.Errorf("Unsupported path modifier type"), "unsupported path modifier type", "HTTPPathModifier", filter.Path.Type)
+		return nil
+	}
+	return nil
+}
+
 type tunnelKey struct {
 	namespace string
 	service   string
@@ -1278,10 +1997,11 @@ func (d *Driver) tunnelKeyFromTunnel(tunnel ingressv1alpha1.Tunnel) tunnelKey {
 	}
 }
 
-func (d *Driver) calculateTunnels() map[tunnelKey]ingressv1alpha1.Tunnel {
+func (d *Driver) calculateTunnels(ingressDomains map[string]ingressv1alpha1.Domain) map[tunnelKey]ingressv1alpha1.Tunnel {
 	tunnels := map[tunnelKey]ingressv1alpha1.Tunnel{}
 	d.calculateTunnelsFromIngress(tunnels)
 	d.calculateTunnelsFromGateway(tunnels)
+	d.calculateTunnelsFromGatewayRoutes(tunnels, ingressDomains)
 	return tunnels
 }
 
@@ -1296,7 +2016,7 @@ func (d *Driver) calculateTunnelsFromIngress(tunnels map[tunnelKey]ingressv1alph
 				}
 
 				serviceName := path.Backend.Service.Name
-				serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackend(*path.Backend.Service, ingress.Namespace)
+				serviceUID, servicePort, backendConfig, appProtocol, err := d.getTunnelBackend(*path.Backend.Service, ingress.Namespace)
 				if err != nil {
 					d.log.Error(err, "could not find port for service", "namespace", ingress.Namespace, "service", serviceName)
 				}
@@ -1304,7 +2024,7 @@ func (d *Driver) calculateTunnelsFromIngress(tunnels map[tunnelKey]ingressv1alph
 				key := tunnelKey{ingress.Namespace, serviceName, strconv.Itoa(int(servicePort))}
 				tunnel, found := tunnels[key]
 				if !found {
-					targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, key.namespace, clusterDomain, servicePort)
+					targetAddr := ServiceAddress(serviceName, key.namespace, servicePort)
 					tunnel = ingressv1alpha1.Tunnel{
 						ObjectMeta: metav1.ObjectMeta{
 							GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
@@ -1313,12 +2033,10 @@ func (d *Driver) calculateTunnelsFromIngress(tunnels map[tunnelKey]ingressv1alph
 							Labels:          d.tunnelLabels(serviceName, servicePort),
 						},
 						Spec: ingressv1alpha1.TunnelSpec{
-							ForwardsTo: targetAddr,
-							Labels:     d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
-							BackendConfig: &ingressv1alpha1.BackendConfig{
-								Protocol: protocol,
-							},
-							AppProtocol: appProtocol,
+							ForwardsTo:    targetAddr,
+							Labels:        d.ngrokLabels(ingress.Namespace, serviceUID, serviceName, servicePort),
+							BackendConfig: backendConfig,
+							AppProtocol:   appProtocol,
 						},
 					}
 				}
@@ -1362,15 +2080,16 @@ func (d *Driver) calculateTunnelsFromGateway(tunnels map[tunnelKey]ingressv1alph
 				//}
 
 				serviceName := string(backendRef.Name)
-				serviceUID, servicePort, protocol, appProtocol, err := d.getTunnelBackendFromGateway(backendRef.BackendRef, httproute.Namespace)
+				serviceUID, servicePort, backendConfig, appProtocol, err := d.getTunnelBackendFromGateway(backendRef.BackendRef, httproute.Namespace)
 				if err != nil {
 					d.log.Error(err, "could not find port for service", "namespace", httproute.Namespace, "service", serviceName)
+					continue
 				}
 
 				key := tunnelKey{httproute.Namespace, serviceName, strconv.Itoa(int(servicePort))}
 				tunnel, found := tunnels[key]
 				if !found {
-					targetAddr := fmt.Sprintf("%s.%s.%s:%d", serviceName, key.namespace, clusterDomain, servicePort)
+					targetAddr := ServiceAddress(serviceName, key.namespace, servicePort)
 					tunnel = ingressv1alpha1.Tunnel{
 						ObjectMeta: metav1.ObjectMeta{
 							GenerateName:    fmt.Sprintf("%s-%d-", serviceName, servicePort),
@@ -1379,12 +2098,10 @@ func (d *Driver) calculateTunnelsFromGateway(tunnels map[tunnelKey]ingressv1alph
 							Labels:          d.tunnelLabels(serviceName, servicePort),
 						},
 						Spec: ingressv1alpha1.TunnelSpec{
-							ForwardsTo: targetAddr,
-							Labels:     d.ngrokLabels(httproute.Namespace, serviceUID, serviceName, servicePort),
-							BackendConfig: &ingressv1alpha1.BackendConfig{
-								Protocol: protocol,
-							},
-							AppProtocol: appProtocol,
+							ForwardsTo:    targetAddr,
+							Labels:        d.ngrokLabels(httproute.Namespace, serviceUID, serviceName, servicePort),
+							BackendConfig: backendConfig,
+							AppProtocol:   appProtocol,
 						},
 					}
 				}
@@ -1479,42 +2196,42 @@ func (d *Driver) findBackendRefServicesPort(service *corev1.Service, backendRef
 	return nil, fmt.Errorf("could not find matching port for service %s, backend port %v, name %s", service.Name, int32(*backendRef.Port), string(backendRef.Name))
 }
 
-func (d *Driver) getTunnelBackend(backendSvc netv1.IngressServiceBackend, namespace string) (string, int32, string, string, error) {
+func (d *Driver) getTunnelBackend(backendSvc netv1.IngressServiceBackend, namespace string) (string, int32, *ingressv1alpha1.BackendConfig, string, error) {
 	service, servicePort, err := d.findBackendServicePort(backendSvc, namespace)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
-	protocol, err := d.getPortAnnotatedProtocol(service, servicePort.Name)
+	backendConfig, err := d.getBackendConfig(service, servicePort)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
 	appProtocol, err := d.getPortAppProtocol(service, servicePort)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
-	return string(service.UID), servicePort.Port, protocol, appProtocol, nil
+	return string(service.UID), servicePort.Port, backendConfig, appProtocol, nil
 }
 
-func (d *Driver) getTunnelBackendFromGateway(backendRef gatewayv1.BackendRef, namespace string) (string, int32, string, string, error) {
+func (d *Driver) getTunnelBackendFromGateway(backendRef gatewayv1.BackendRef, namespace string) (string, int32, *ingressv1alpha1.BackendConfig, string, error) {
 	service, servicePort, err := d.findBackendRefServicePort(backendRef, namespace)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
-	protocol, err := d.getPortAnnotatedProtocol(service, servicePort.Name)
+	backendConfig, err := d.getBackendConfig(service, servicePort)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
 	appProtocol, err := d.getPortAppProtocol(service, servicePort)
 	if err != nil {
-		return "", 0, "", "", err
+		return "", 0, nil, "", err
 	}
 
-	return string(service.UID), servicePort.Port, protocol, appProtocol, nil
+	return string(service.UID), servicePort.Port, backendConfig, appProtocol, nil
 }
 
 func (d *Driver) findBackendServicePort(backendSvc netv1.IngressServiceBackend, namespace string) (*corev1.Service, *corev1.ServicePort, error) {
@@ -1541,6 +2258,82 @@ func (d *Driver) findServicesPort(service *corev1.Service, backendSvcPort netv1.
 	return nil, fmt.Errorf("could not find matching port for service %s, backend port %v, name %s", service.Name, backendSvcPort.Number, backendSvcPort.Name)
 }
 
+// getBackendConfig returns the configuration for connections to a service port. HTTPS ports can be configured
+// with the backend-tls-* annotations on the service.
+func (d *Driver) getBackendConfig(service *corev1.Service, port *corev1.ServicePort) (*ingressv1alpha1.BackendConfig, error) {
+	protocol, err := d.getPortAnnotatedProtocol(service, port.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyProtocolVersion, err := getProxyProtocolVersion(service)
+	if err != nil {
+		return nil, err
+	}
+
+	backendConfig := &ingressv1alpha1.BackendConfig{
+		Protocol:             protocol,
+		ProxyProtocolVersion: proxyProtocolVersion,
+	}
+	if protocol == "HTTPS" {
+		backendConfig.TLS, err = getBackendTLSConfig(service)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return backendConfig, nil
+}
+
+// getProxyProtocolVersion reads the PROXY protocol version to send to the service's backends from its
+// annotations. It returns 0 if it isn't enabled.
+func getProxyProtocolVersion(service *corev1.Service) (int, error) {
+	switch version := service.Annotations[annotationBackendProxyProtocol]; version {
+	case "":
+		return 0, nil
+	case "1", "2":
+		return strconv.Atoi(version)
+	default:
+		return 0, fmt.Errorf("invalid %s annotation '%s' on service %s/%s: must be '1' or '2'", annotationBackendProxyProtocol, version, service.Namespace, service.Name)
+	}
+}
+
+// getBackendTLSConfig reads the backend TLS settings from the service's annotations. It returns nil if none
+// are set, in which case the backend's certificate isn't verified.
+func getBackendTLSConfig(service *corev1.Service) (*ingressv1alpha1.BackendTLSConfig, error) {
+	annotations := service.Annotations
+	tlsConfig := &ingressv1alpha1.BackendTLSConfig{
+		ServerName:              annotations[annotationBackendTLSServerName],
+		ClientCertificateSecret: annotations[annotationBackendTLSClientCertSecret],
+	}
+
+	if verify, ok := annotations[annotationBackendTLSVerify]; ok {
+		v, err := strconv.ParseBool(verify)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation '%s' on service %s/%s: must be 'true' or 'false'", annotationBackendTLSVerify, verify, service.Namespace, service.Name)
+		}
+		tlsConfig.Verify = &v
+	}
+
+	caSecret, caConfigMap := annotations[annotationBackendTLSCASecret], annotations[annotationBackendTLSCAConfigMap]
+	switch {
+	case caSecret != "" && caConfigMap != "":
+		return nil, fmt.Errorf("only one of %s and %s may be set on service %s/%s", annotationBackendTLSCASecret, annotationBackendTLSCAConfigMap, service.Namespace, service.Name)
+	case caSecret != "":
+		tlsConfig.CACertificates = &ingressv1alpha1.BackendCABundle{
+			Secret: &ingressv1alpha1.SecretKeyRef{Name: caSecret, Key: backendTLSCAKey},
+		}
+	case caConfigMap != "":
+		tlsConfig.CACertificates = &ingressv1alpha1.BackendCABundle{
+			ConfigMap: &ingressv1alpha1.ConfigMapKeyRef{Name: caConfigMap, Key: backendTLSCAKey},
+		}
+	}
+
+	if *tlsConfig == (ingressv1alpha1.BackendTLSConfig{}) {
+		return nil, nil
+	}
+	return tlsConfig, nil
+}
+
 func (d *Driver) getPortAnnotatedProtocol(service *corev1.Service, portName string) (string, error) {
 	if service.Annotations != nil {
 		annotation := service.Annotations["k8s.ngrok.com/app-protocols"]
@@ -1601,6 +2394,12 @@ func (d *Driver) tunnelLabels(serviceName string, port int32) map[string]string
 
 // Generates a labels map for matching ngrok Routes to Agent Tunnels
 func (d *Driver) ngrokLabels(namespace, serviceUID, serviceName string, port int32) map[string]string {
+	return NgrokLabels(namespace, serviceUID, serviceName, port)
+}
+
+// NgrokLabels returns the labels matching ngrok routes to the agent tunnels of a service port. The service
+// controller uses them for LoadBalancer Services too, so every tunnel for a service port is labeled the same way.
+func NgrokLabels(namespace, serviceUID, serviceName string, port int32) map[string]string {
 	return map[string]string{
 		labelNamespace:  namespace,
 		labelServiceUID: serviceUID,
@@ -1608,3 +2407,8 @@ func (d *Driver) ngrokLabels(namespace, serviceUID, serviceName string, port int
 		labelPort:       strconv.Itoa(int(port)),
 	}
 }
+
+// ServiceAddress returns the in-cluster address tunnels forward to for a service port
+func ServiceAddress(serviceName, namespace string, port int32) string {
+	return fmt.Sprintf("%s.%s.%s:%d", serviceName, namespace, clusterDomain, port)
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+// updateGatewayStatuses reports the Accepted and Programmed conditions, listener statuses, and addresses of
+// each Gateway in the store whose GatewayClass we handle
+func (d *Driver) updateGatewayStatuses(ctx context.Context, c client.Client, ingressDomains map[string]ingressv1alpha1.Domain) error {
+	if !d.gatewayEnabled {
+		return nil
+	}
+
+	domains := &ingressv1alpha1.DomainList{}
+	if err := c.List(ctx, domains); err != nil {
+		d.log.Error(err, "failed to list domains")
+		return err
+	}
+	// Domains are only usable once ngrok has reserved them
+	reservedDomains := map[string]ingressv1alpha1.Domain{}
+	for _, domain := range domains.Items {
+		if domain.Status.ID != "" {
+			reservedDomains[domain.Spec.Domain] = domain
+		}
+	}
+
+	attachedRoutes := d.calculateAttachedRoutes(ingressDomains)
+
+	for _, gtw := range d.store.ListNgrokGateways() {
+		key := types.NamespacedName{Namespace: gtw.Namespace, Name: gtw.Name}
+		status := calculateGatewayStatus(gtw, reservedDomains, ingressDomains, attachedRoutes[key])
+		if reflect.DeepEqual(gtw.Status, status) {
+			continue
+		}
+
+		updated := gtw.DeepCopy()
+		updated.Status = status
+		if err := c.Status().Update(ctx, updated); err != nil {
+			d.log.Error(err, "error updating gateway status", "namespace", gtw.Namespace, "name", gtw.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// calculateAttachedRoutes counts the routes accepted by each listener, keyed by Gateway and listener name
+func (d *Driver) calculateAttachedRoutes(ingressDomains map[string]ingressv1alpha1.Domain) map[types.NamespacedName]map[gatewayv1.SectionName]int32 {
+	attached := map[types.NamespacedName]map[gatewayv1.SectionName]int32{}
+	count := func(parents []routeParent) {
+		for _, parent := range parents {
+			if parent.gateway == nil {
+				continue
+			}
+			key := types.NamespacedName{Namespace: parent.gateway.Namespace, Name: parent.gateway.Name}
+			if attached[key] == nil {
+				attached[key] = map[gatewayv1.SectionName]int32{}
+			}
+			for _, listener := range parent.listeners {
+				attached[key][listener.Name]++
+			}
+		}
+	}
+
+	for _, route := range d.store.ListHTTPRoutes() {
+		count(d.resolveRouteParents(kindHTTPRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains))
+	}
+	for _, route := range d.store.ListTLSRoutes() {
+		count(d.resolveRouteParents(kindTLSRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains))
+	}
+	for _, route := range d.store.ListTCPRoutes() {
+		count(d.resolveRouteParents(kindTCPRoute, route.Namespace, route.Spec.ParentRefs, nil, ingressDomains))
+	}
+	return attached
+}
+
+// calculateGatewayStatus builds the status of a Gateway. A listener is programmed once the domain for its hostname
+// has been reserved, and the Gateway once all of its valid listeners are. The addresses are the CNAME targets of
+// the reserved domains, or the domains themselves for ngrok managed domains that don't need a CNAME.
+func calculateGatewayStatus(gtw *gatewayv1.Gateway, reservedDomains, ingressDomains map[string]ingressv1alpha1.Domain, attachedRoutes map[gatewayv1.SectionName]int32) gatewayv1.GatewayStatus {
+	status := gatewayv1.GatewayStatus{
+		Conditions: slices.Clone(gtw.Status.Conditions),
+	}
+
+	validListeners, programmedListeners := 0, 0
+	addresses := map[string]bool{}
+	for _, listener := range gtw.Spec.Listeners {
+		listenerStatus := calculateListenerStatus(gtw, listener, reservedDomains, ingressDomains, attachedRoutes[listener.Name])
+		status.Listeners = append(status.Listeners, listenerStatus)
+
+		if !meta.IsStatusConditionTrue(listenerStatus.Conditions, string(gatewayv1.ListenerConditionAccepted)) ||
+			meta.IsStatusConditionTrue(listenerStatus.Conditions, string(gatewayv1.ListenerConditionConflicted)) {
+			continue
+		}
+		validListeners++
+
+		if !meta.IsStatusConditionTrue(listenerStatus.Conditions, string(gatewayv1.ListenerConditionProgrammed)) {
+			continue
+		}
+		programmedListeners++
+
+		if listener.Hostname != nil {
+			domain := reservedDomains[string(*listener.Hostname)]
+			if domain.Status.CNAMETarget != nil {
+				addresses[*domain.Status.CNAMETarget] = true
+			} else {
+				addresses[domain.Spec.Domain] = true
+			}
+		}
+	}
+
+	for address := range addresses {
+		status.Addresses = append(status.Addresses, gatewayv1.GatewayStatusAddress{
+			Type:  ptr.To(gatewayv1.HostnameAddressType),
+			Value: address,
+		})
+	}
+	sort.Slice(status.Addresses, func(i, j int) bool {
+		return status.Addresses[i].Value < status.Addresses[j].Value
+	})
+
+	accepted := metav1.Condition{
+		Type:               string(gatewayv1.GatewayConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.GatewayReasonAccepted),
+		Message:            "Gateway accepted",
+		ObservedGeneration: gtw.Generation,
+	}
+	switch {
+	case validListeners == 0:
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(gatewayv1.GatewayReasonListenersNotValid)
+		accepted.Message = "None of the Gateway's listeners are valid"
+	case validListeners < len(gtw.Spec.Listeners):
+		accepted.Reason = string(gatewayv1.GatewayReasonListenersNotValid)
+		accepted.Message = "Some of the Gateway's listeners are not valid"
+	}
+	meta.SetStatusCondition(&status.Conditions, accepted)
+
+	programmed := metav1.Condition{
+		Type:               string(gatewayv1.GatewayConditionProgrammed),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.GatewayReasonProgrammed),
+		Message:            "Gateway programmed",
+		ObservedGeneration: gtw.Generation,
+	}
+	switch {
+	case validListeners == 0:
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.GatewayReasonInvalid)
+		programmed.Message = "None of the Gateway's listeners are valid"
+	case programmedListeners < validListeners:
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.GatewayReasonPending)
+		programmed.Message = "Waiting for the listener domains to be reserved"
+	}
+	meta.SetStatusCondition(&status.Conditions, programmed)
+
+	return status
+}
+
+func calculateListenerStatus(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, reservedDomains, ingressDomains map[string]ingressv1alpha1.Domain, attachedRoutes int32) gatewayv1.ListenerStatus {
+	var conditions []metav1.Condition
+	for _, status := range gtw.Status.Listeners {
+		if status.Name == listener.Name {
+			conditions = slices.Clone(status.Conditions)
+			break
+		}
+	}
+
+	supportedKinds, kindsValid := listenerSupportedKinds(listener)
+	resolvedRefs := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionResolvedRefs),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.ListenerReasonResolvedRefs),
+		Message: "All references resolved",
+	}
+	if !kindsValid {
+		resolvedRefs.Status = metav1.ConditionFalse
+		resolvedRefs.Reason = string(gatewayv1.ListenerReasonInvalidRouteKinds)
+		resolvedRefs.Message = "Some of the allowed route kinds are not supported by the listener protocol"
+	}
+
+	acceptedReason, acceptedMessage := listenerAcceptance(listener)
+	accepted := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionAccepted),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(acceptedReason),
+		Message: acceptedMessage,
+	}
+	if acceptedReason != gatewayv1.ListenerReasonAccepted {
+		accepted.Status = metav1.ConditionFalse
+	}
+
+	conflicted := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionConflicted),
+		Status:  metav1.ConditionFalse,
+		Reason:  string(gatewayv1.ListenerReasonNoConflicts),
+		Message: "No conflicts",
+	}
+	if listenerConflicts(listener, ingressDomains) {
+		conflicted.Status = metav1.ConditionTrue
+		conflicted.Reason = string(gatewayv1.ListenerReasonHostnameConflict)
+		conflicted.Message = "The hostname is already used by an Ingress"
+	}
+
+	programmed := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionProgrammed),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.ListenerReasonProgrammed),
+		Message: "Listener programmed",
+	}
+	switch {
+	case accepted.Status == metav1.ConditionFalse || conflicted.Status == metav1.ConditionTrue:
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.ListenerReasonInvalid)
+		programmed.Message = "Listener is not valid"
+	case listener.Hostname != nil:
+		if _, ok := reservedDomains[string(*listener.Hostname)]; !ok {
+			programmed.Status = metav1.ConditionFalse
+			programmed.Reason = string(gatewayv1.ListenerReasonPending)
+			programmed.Message = "Waiting for the domain to be reserved"
+		}
+	}
+
+	for _, condition := range []metav1.Condition{accepted, resolvedRefs, conflicted, programmed} {
+		condition.ObservedGeneration = gtw.Generation
+		meta.SetStatusCondition(&conditions, condition)
+	}
+
+	return gatewayv1.ListenerStatus{
+		Name:           listener.Name,
+		SupportedKinds: supportedKinds,
+		AttachedRoutes: attachedRoutes,
+		Conditions:     conditions,
+	}
+}
+
+// listenerSupportedKinds returns the route kinds that can attach to the listener. It returns false if any of
+// the listener's allowed kinds can't be supported.
+func listenerSupportedKinds(listener gatewayv1.Listener) ([]gatewayv1.RouteGroupKind, bool) {
+	var protocolKinds []gatewayv1.Kind
+	for kind, protocols := range routeKindProtocols {
+		if slices.Contains(protocols, listener.Protocol) {
+			protocolKinds = append(protocolKinds, kind)
+		}
+	}
+	slices.Sort(protocolKinds)
+
+	supported := []gatewayv1.RouteGroupKind{}
+	if listener.AllowedRoutes == nil || len(listener.AllowedRoutes.Kinds) == 0 {
+		for _, kind := range protocolKinds {
+			supported = append(supported, gatewayv1.RouteGroupKind{Group: ptr.To(gatewayv1.Group(gatewayv1.GroupName)), Kind: kind})
+		}
+		return supported, true
+	}
+
+	valid := true
+	for _, allowed := range listener.AllowedRoutes.Kinds {
+		if (allowed.Group == nil || *allowed.Group == gatewayv1.GroupName) && slices.Contains(protocolKinds, allowed.Kind) {
+			supported = append(supported, gatewayv1.RouteGroupKind{Group: ptr.To(gatewayv1.Group(gatewayv1.GroupName)), Kind: allowed.Kind})
+		} else {
+			valid = false
+		}
+	}
+	return supported, valid
+}
@@ -0,0 +1,738 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+// GatewayControllerName is the controllerName of the GatewayClasses we handle. It is also reported as
+// the controller in the parent statuses of routes.
+const GatewayControllerName = "ngrok.com/gateway-controller"
+
+const (
+	kindHTTPRoute gatewayv1.Kind = "HTTPRoute"
+	kindTLSRoute  gatewayv1.Kind = "TLSRoute"
+	kindTCPRoute  gatewayv1.Kind = "TCPRoute"
+)
+
+// routeKindProtocols are the listener protocols each route kind can attach to. HTTPRoutes attach to both HTTP
+// and HTTPS listeners, ngrok serves every HTTPS edge on port 443 whatever the listener says.
+var routeKindProtocols = map[gatewayv1.Kind][]gatewayv1.ProtocolType{
+	kindHTTPRoute: {gatewayv1.HTTPProtocolType, gatewayv1.HTTPSProtocolType},
+	kindTLSRoute:  {gatewayv1.TLSProtocolType},
+	kindTCPRoute:  {gatewayv1.TCPProtocolType},
+}
+
+// routeParent is the result of resolving one of a route's parentRefs against the Gateways in the store
+type routeParent struct {
+	ref gatewayv1.ParentReference
+
+	// gateway the parentRef points to, nil if it was not found
+	gateway *gatewayv1.Gateway
+
+	// ignored is set when the parent Gateway belongs to a GatewayClass of another controller. The route is
+	// neither attached to it nor given a parent status for it.
+	ignored bool
+
+	// listeners of the parent Gateway that accepted the route, empty if it was not accepted
+	listeners []gatewayv1.Listener
+
+	// reason and message explain why the route was not accepted
+	reason  gatewayv1.RouteConditionReason
+	message string
+}
+
+func (p routeParent) accepted() bool {
+	return len(p.listeners) > 0
+}
+
+// resolveRouteParents resolves a route's parentRefs. ingressDomains are the domains of the Ingresses, calculated
+// once per sync, whose hostnames listeners can't use.
+func (d *Driver) resolveRouteParents(kind gatewayv1.Kind, namespace string, parentRefs []gatewayv1.ParentReference, hostnames []gatewayv1.Hostname, ingressDomains map[string]ingressv1alpha1.Domain) []routeParent {
+	parents := make([]routeParent, 0, len(parentRefs))
+	for _, ref := range parentRefs {
+		parents = append(parents, d.resolveRouteParent(kind, namespace, ref, hostnames, ingressDomains))
+	}
+	return parents
+}
+
+func (d *Driver) resolveRouteParent(kind gatewayv1.Kind, namespace string, ref gatewayv1.ParentReference, hostnames []gatewayv1.Hostname, ingressDomains map[string]ingressv1alpha1.Domain) routeParent {
+	parent := routeParent{ref: ref}
+
+	if (ref.Group != nil && *ref.Group != gatewayv1.GroupName) || (ref.Kind != nil && *ref.Kind != "Gateway") {
+		parent.reason = gatewayv1.RouteReasonNoMatchingParent
+		parent.message = "only Gateway parents are supported"
+		return parent
+	}
+
+	gwNamespace := namespace
+	if ref.Namespace != nil {
+		gwNamespace = string(*ref.Namespace)
+	}
+	gtw, err := d.store.GetGateway(string(ref.Name), gwNamespace)
+	if err != nil {
+		parent.reason = gatewayv1.RouteReasonNoMatchingParent
+		parent.message = fmt.Sprintf("Gateway %s/%s not found", gwNamespace, ref.Name)
+		return parent
+	}
+	if ngrokGtw, _ := d.store.GetNgrokGateway(string(ref.Name), gwNamespace); ngrokGtw == nil {
+		parent.ignored = true
+		return parent
+	}
+	parent.gateway = gtw
+
+	parent.reason = gatewayv1.RouteReasonNoMatchingParent
+	parent.message = "no listener on the Gateway matches the parentRef"
+	for _, listener := range gtw.Spec.Listeners {
+		if ref.SectionName != nil && *ref.SectionName != listener.Name {
+			continue
+		}
+		if ref.Port != nil && *ref.Port != listener.Port {
+			continue
+		}
+
+		if reason, message := listenerAcceptance(listener); reason != gatewayv1.ListenerReasonAccepted {
+			parent.message = fmt.Sprintf("listener %s is not accepted: %s", listener.Name, message)
+			continue
+		}
+		if listenerConflicts(listener, ingressDomains) {
+			parent.message = fmt.Sprintf("listener %s hostname is already used by an Ingress", listener.Name)
+			continue
+		}
+
+		if !listenerAllowsRouteKind(listener, kind) || !listenerAllowsNamespace(gtw, listener, namespace) {
+			parent.reason = gatewayv1.RouteReasonNotAllowedByListeners
+			parent.message = fmt.Sprintf("listener %s does not allow %s routes from namespace %s", listener.Name, kind, namespace)
+			continue
+		}
+
+		if !listenerMatchesHostnames(listener, hostnames) {
+			parent.reason = gatewayv1.RouteReasonNoMatchingListenerHostname
+			parent.message = fmt.Sprintf("listener %s hostname does not match any of the route's hostnames", listener.Name)
+			continue
+		}
+
+		parent.listeners = append(parent.listeners, listener)
+	}
+
+	if parent.accepted() {
+		parent.reason = gatewayv1.RouteReasonAccepted
+		parent.message = "Route accepted"
+	}
+	return parent
+}
+
+// listenerReasonHostnameRequired is reported when an HTTP, HTTPS or TLS listener has no hostname to reserve a domain for
+const listenerReasonHostnameRequired gatewayv1.ListenerConditionReason = "HostnameRequired"
+
+// listenerAcceptance checks a listener can be served by an ngrok edge. It returns ListenerReasonAccepted if it can,
+// otherwise the reason and a message for the listener's Accepted condition.
+func listenerAcceptance(listener gatewayv1.Listener) (gatewayv1.ListenerConditionReason, string) {
+	switch listener.Protocol {
+	case gatewayv1.HTTPProtocolType:
+		// The edge is served over HTTPS on port 443, so the listener's port isn't checked
+		if listener.Hostname == nil {
+			return listenerReasonHostnameRequired, fmt.Sprintf("%s listeners must have a hostname", listener.Protocol)
+		}
+	case gatewayv1.HTTPSProtocolType, gatewayv1.TLSProtocolType:
+		if listener.Port != 443 {
+			return gatewayv1.ListenerReasonPortUnavailable, fmt.Sprintf("%s listeners must use port 443", listener.Protocol)
+		}
+		if listener.Hostname == nil {
+			return listenerReasonHostnameRequired, fmt.Sprintf("%s listeners must have a hostname", listener.Protocol)
+		}
+		if listener.Protocol == gatewayv1.TLSProtocolType {
+			// TLS is passed through to the backend, the edge can't terminate it
+			if listener.TLS == nil || listener.TLS.Mode == nil || *listener.TLS.Mode != gatewayv1.TLSModePassthrough {
+				return gatewayv1.ListenerReasonUnsupportedProtocol, "TLS listeners must use TLS mode Passthrough"
+			}
+		}
+	case gatewayv1.TCPProtocolType:
+	default:
+		return gatewayv1.ListenerReasonUnsupportedProtocol, fmt.Sprintf("protocol %s is not supported", listener.Protocol)
+	}
+	return gatewayv1.ListenerReasonAccepted, "Listener accepted"
+}
+
+// listenerConflicts checks whether the listener hostname is already used by an Ingress. Ingresses take
+// precedence, so the listener is not served.
+func listenerConflicts(listener gatewayv1.Listener, ingressDomains map[string]ingressv1alpha1.Domain) bool {
+	if listener.Hostname == nil {
+		return false
+	}
+	_, ok := ingressDomains[string(*listener.Hostname)]
+	return ok
+}
+
+// listenerAllowsRouteKind checks the listener protocol fits the route kind, and that the kind is
+// in the listener's allowed kinds, if it has any
+func listenerAllowsRouteKind(listener gatewayv1.Listener, kind gatewayv1.Kind) bool {
+	if !slices.Contains(routeKindProtocols[kind], listener.Protocol) {
+		return false
+	}
+	if listener.AllowedRoutes == nil || len(listener.AllowedRoutes.Kinds) == 0 {
+		return true
+	}
+	for _, allowed := range listener.AllowedRoutes.Kinds {
+		if allowed.Kind == kind && (allowed.Group == nil || *allowed.Group == gatewayv1.GroupName) {
+			return true
+		}
+	}
+	return false
+}
+
+func listenerAllowsNamespace(gtw *gatewayv1.Gateway, listener gatewayv1.Listener, namespace string) bool {
+	from := gatewayv1.NamespacesFromSame
+	if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil && listener.AllowedRoutes.Namespaces.From != nil {
+		from = *listener.AllowedRoutes.Namespaces.From
+	}
+
+	switch from {
+	case gatewayv1.NamespacesFromAll:
+		return true
+	case gatewayv1.NamespacesFromSame:
+		return namespace == gtw.Namespace
+	default:
+		// Namespaces aren't in the store, so we can't match their labels against a selector
+		return false
+	}
+}
+
+// listenerMatchesHostnames requires the listener hostname, if it has one, to be one of the route's hostnames,
+// if the route has any. Wildcards are not supported.
+func listenerMatchesHostnames(listener gatewayv1.Listener, hostnames []gatewayv1.Hostname) bool {
+	if listener.Hostname == nil || len(hostnames) == 0 {
+		return true
+	}
+	return slices.Contains(hostnames, *listener.Hostname)
+}
+
+// resolveRouteServiceBackend finds the Service and port a route's backendRef points to. When it can't, the
+// returned reason can be used for the route's ResolvedRefs condition.
+func (d *Driver) resolveRouteServiceBackend(namespace string, ref gatewayv1.BackendRef) (*corev1.Service, *corev1.ServicePort, gatewayv1.RouteConditionReason, error) {
+	if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Service") {
+		return nil, nil, gatewayv1.RouteReasonInvalidKind, fmt.Errorf("backendRef %s is not a Service", ref.Name)
+	}
+	if ref.Namespace != nil && string(*ref.Namespace) != namespace {
+		return nil, nil, gatewayv1.RouteReasonRefNotPermitted, fmt.Errorf("backendRef %s/%s is in a different namespace than the route", *ref.Namespace, ref.Name)
+	}
+	if ref.Port == nil {
+		return nil, nil, gatewayv1.RouteReasonBackendNotFound, fmt.Errorf("backendRef %s has no port", ref.Name)
+	}
+
+	service, err := d.store.GetServiceV1(string(ref.Name), namespace)
+	if err != nil {
+		return nil, nil, gatewayv1.RouteReasonBackendNotFound, fmt.Errorf("Service %s/%s not found", namespace, ref.Name)
+	}
+	for _, port := range service.Spec.Ports {
+		if port.Port == int32(*ref.Port) {
+			return service, &port, gatewayv1.RouteReasonResolvedRefs, nil
+		}
+	}
+	return nil, nil, gatewayv1.RouteReasonBackendNotFound, fmt.Errorf("Service %s/%s has no port %d", namespace, ref.Name, *ref.Port)
+}
+
+// routeServiceBackend returns the first backendRef that resolves to a Service. TCP and TLS edges
+// forward to a single tunnel group, so additional backendRefs are not used.
+func (d *Driver) routeServiceBackend(namespace string, refs []gatewayv1.BackendRef) (*corev1.Service, *corev1.ServicePort, error) {
+	for _, ref := range refs {
+		service, port, _, err := d.resolveRouteServiceBackend(namespace, ref)
+		if err == nil {
+			return service, port, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no backendRefs resolve to a Service")
+}
+
+func httpRouteBackendRefs(route *gatewayv1.HTTPRoute) []gatewayv1.BackendRef {
+	var refs []gatewayv1.BackendRef
+	for _, rule := range route.Spec.Rules {
+		for _, ref := range rule.BackendRefs {
+			refs = append(refs, ref.BackendRef)
+		}
+	}
+	return refs
+}
+
+func tlsRouteBackendRefs(route *gatewayv1alpha2.TLSRoute) []gatewayv1.BackendRef {
+	var refs []gatewayv1.BackendRef
+	for _, rule := range route.Spec.Rules {
+		refs = append(refs, rule.BackendRefs...)
+	}
+	return refs
+}
+
+func tcpRouteBackendRefs(route *gatewayv1alpha2.TCPRoute) []gatewayv1.BackendRef {
+	var refs []gatewayv1.BackendRef
+	for _, rule := range route.Spec.Rules {
+		refs = append(refs, rule.BackendRefs...)
+	}
+	return refs
+}
+
+// calculateTLSEdges creates a TLSEdge for each hostname of a passthrough listener that accepted a
+// TLSRoute. The edge does not terminate TLS, it is forwarded to the backend as is.
+func (d *Driver) calculateTLSEdges(gatewayDomainMap, ingressDomains map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.TLSEdge {
+	edges := map[string]ingressv1alpha1.TLSEdge{}
+	if !d.gatewayEnabled {
+		return edges
+	}
+
+	for _, route := range d.store.ListTLSRoutes() {
+		service, port, err := d.routeServiceBackend(route.Namespace, tlsRouteBackendRefs(route))
+		if err != nil {
+			d.log.Error(err, "could not resolve backend for TLSRoute", "namespace", route.Namespace, "name", route.Name)
+			continue
+		}
+
+		for _, parent := range d.resolveRouteParents(kindTLSRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains) {
+			for _, listener := range parent.listeners {
+				domain := string(*listener.Hostname)
+				if _, ok := gatewayDomainMap[domain]; !ok {
+					// the domain is used by an ingress
+					continue
+				}
+				if _, ok := edges[domain]; ok {
+					d.log.Info("domain already used by another TLSRoute, skipping", "domain", domain, "namespace", route.Namespace, "name", route.Name)
+					continue
+				}
+
+				edge := ingressv1alpha1.TLSEdge{
+					ObjectMeta: metav1.ObjectMeta{
+						GenerateName: route.Name + "-",
+						Namespace:    route.Namespace,
+						Labels:       d.edgeLabels(domain),
+					},
+					Spec: ingressv1alpha1.TLSEdgeSpec{
+						Hostports: []string{domain + ":443"},
+						Backend: ingressv1alpha1.TunnelGroupBackend{
+							Labels: d.ngrokLabels(service.Namespace, string(service.UID), service.Name, port.Port),
+						},
+					},
+				}
+				edge.Spec.Metadata = d.customMetadata
+				edges[domain] = edge
+			}
+		}
+	}
+
+	return edges
+}
+
+// calculateTCPEdges creates a TCPEdge for each TCPRoute accepted by a TCP listener. The edge gets a
+// newly reserved TCP address, the listener port can't be honored.
+func (d *Driver) calculateTCPEdges(ingressDomains map[string]ingressv1alpha1.Domain) map[string]ingressv1alpha1.TCPEdge {
+	edges := map[string]ingressv1alpha1.TCPEdge{}
+	if !d.gatewayEnabled {
+		return edges
+	}
+
+	for _, route := range d.store.ListTCPRoutes() {
+		accepted := slices.ContainsFunc(d.resolveRouteParents(kindTCPRoute, route.Namespace, route.Spec.ParentRefs, nil, ingressDomains), routeParent.accepted)
+		if !accepted {
+			continue
+		}
+
+		service, port, err := d.routeServiceBackend(route.Namespace, tcpRouteBackendRefs(route))
+		if err != nil {
+			d.log.Error(err, "could not resolve backend for TCPRoute", "namespace", route.Namespace, "name", route.Name)
+			continue
+		}
+
+		edge := ingressv1alpha1.TCPEdge{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: route.Name + "-",
+				Namespace:    route.Namespace,
+				Labels:       d.tcpRouteEdgeLabels(route.Name),
+			},
+			Spec: ingressv1alpha1.TCPEdgeSpec{
+				Backend: ingressv1alpha1.TunnelGroupBackend{
+					Labels: d.ngrokLabels(service.Namespace, string(service.UID), service.Name, port.Port),
+				},
+			},
+		}
+		edge.Spec.Metadata = d.customMetadata
+		edges[route.Namespace+"/"+route.Name] = edge
+	}
+
+	return edges
+}
+
+// calculateTunnelsFromGatewayRoutes adds the tunnels for the backends of accepted TLSRoutes and TCPRoutes.
+// Their traffic is forwarded as is, so no backend protocol is set.
+func (d *Driver) calculateTunnelsFromGatewayRoutes(tunnels map[tunnelKey]ingressv1alpha1.Tunnel, ingressDomains map[string]ingressv1alpha1.Domain) {
+	if !d.gatewayEnabled {
+		return
+	}
+
+	for _, route := range d.store.ListTLSRoutes() {
+		if !slices.ContainsFunc(d.resolveRouteParents(kindTLSRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains), routeParent.accepted) {
+			continue
+		}
+		service, port, err := d.routeServiceBackend(route.Namespace, tlsRouteBackendRefs(route))
+		if err != nil {
+			continue
+		}
+		d.addRouteTunnel(tunnels, service, port, metav1.OwnerReference{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       string(kindTLSRoute),
+			Name:       route.Name,
+			UID:        route.UID,
+		})
+	}
+
+	for _, route := range d.store.ListTCPRoutes() {
+		if !slices.ContainsFunc(d.resolveRouteParents(kindTCPRoute, route.Namespace, route.Spec.ParentRefs, nil, ingressDomains), routeParent.accepted) {
+			continue
+		}
+		service, port, err := d.routeServiceBackend(route.Namespace, tcpRouteBackendRefs(route))
+		if err != nil {
+			continue
+		}
+		d.addRouteTunnel(tunnels, service, port, metav1.OwnerReference{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       string(kindTCPRoute),
+			Name:       route.Name,
+			UID:        route.UID,
+		})
+	}
+}
+
+func (d *Driver) addRouteTunnel(tunnels map[tunnelKey]ingressv1alpha1.Tunnel, service *corev1.Service, port *corev1.ServicePort, owner metav1.OwnerReference) {
+	key := tunnelKey{service.Namespace, service.Name, strconv.Itoa(int(port.Port))}
+	tunnel, found := tunnels[key]
+	if !found {
+		tunnel = ingressv1alpha1.Tunnel{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: fmt.Sprintf("%s-%d-", service.Name, port.Port),
+				Namespace:    service.Namespace,
+				Labels:       d.tunnelLabels(service.Name, port.Port),
+			},
+			Spec: ingressv1alpha1.TunnelSpec{
+				ForwardsTo: ServiceAddress(service.Name, service.Namespace, port.Port),
+				Labels:     d.ngrokLabels(service.Namespace, string(service.UID), service.Name, port.Port),
+			},
+		}
+	}
+
+	// Traffic is forwarded as is, the PROXY protocol is the only backend setting that applies
+	if version, err := getProxyProtocolVersion(service); err != nil {
+		d.log.Error(err, "ignoring PROXY protocol annotation", "namespace", service.Namespace, "service", service.Name)
+	} else if version != 0 {
+		tunnel.Spec.BackendConfig = &ingressv1alpha1.BackendConfig{ProxyProtocolVersion: version}
+	}
+
+	if !slices.ContainsFunc(tunnel.OwnerReferences, func(ref metav1.OwnerReference) bool { return ref.UID == owner.UID }) {
+		tunnel.OwnerReferences = append(tunnel.OwnerReferences, owner)
+		slices.SortStableFunc(tunnel.OwnerReferences, func(i, j metav1.OwnerReference) int {
+			return cmp.Compare(string(i.UID), string(j.UID))
+		})
+	}
+
+	tunnels[key] = tunnel
+}
+
+func (d *Driver) tcpRouteEdgeLabels(routeName string) map[string]string {
+	return map[string]string{
+		labelControllerNamespace: d.managerName.Namespace,
+		labelControllerName:      d.managerName.Name,
+		labelTCPRoute:            routeName,
+	}
+}
+
+func (d *Driver) diffTLSEdges(desiredEdges map[string]ingressv1alpha1.TLSEdge, currentEdges []ingressv1alpha1.TLSEdge) changeSet[ingressv1alpha1.TLSEdge] {
+	var changes changeSet[ingressv1alpha1.TLSEdge]
+
+	for _, currEdge := range currentEdges {
+		domain := currEdge.Labels[labelDomain]
+
+		if desiredEdge, ok := desiredEdges[domain]; ok {
+			if !reflect.DeepEqual(desiredEdge.Spec, currEdge.Spec) {
+				updated := currEdge
+				updated.Spec = desiredEdge.Spec
+				changes.addUpdate(currEdge, updated)
+			}
+			delete(desiredEdges, domain)
+		} else {
+			changes.delete = append(changes.delete, currEdge)
+		}
+	}
+
+	for _, edge := range desiredEdges {
+		changes.create = append(changes.create, edge)
+	}
+
+	return changes
+}
+
+func (d *Driver) applyTLSEdges(ctx context.Context, c client.Client, changes changeSet[ingressv1alpha1.TLSEdge]) error {
+	for _, edge := range changes.update {
+		if err := c.Update(ctx, &edge); err != nil {
+			d.log.Error(err, "error updating tls edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.delete {
+		if err := c.Delete(ctx, &edge); client.IgnoreNotFound(err) != nil {
+			d.log.Error(err, "error deleting tls edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.create {
+		if err := c.Create(ctx, &edge); err != nil {
+			d.log.Error(err, "error creating tls edge", "edge", edge)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) diffTCPEdges(desiredEdges map[string]ingressv1alpha1.TCPEdge, currentEdges []ingressv1alpha1.TCPEdge) changeSet[ingressv1alpha1.TCPEdge] {
+	var changes changeSet[ingressv1alpha1.TCPEdge]
+
+	for _, currEdge := range currentEdges {
+		key := currEdge.Namespace + "/" + currEdge.Labels[labelTCPRoute]
+
+		if desiredEdge, ok := desiredEdges[key]; ok {
+			if !reflect.DeepEqual(desiredEdge.Spec, currEdge.Spec) {
+				updated := currEdge
+				updated.Spec = desiredEdge.Spec
+				changes.addUpdate(currEdge, updated)
+			}
+			delete(desiredEdges, key)
+		} else {
+			changes.delete = append(changes.delete, currEdge)
+		}
+	}
+
+	for _, edge := range desiredEdges {
+		changes.create = append(changes.create, edge)
+	}
+
+	return changes
+}
+
+func (d *Driver) applyTCPEdges(ctx context.Context, c client.Client, changes changeSet[ingressv1alpha1.TCPEdge]) error {
+	for _, edge := range changes.update {
+		if err := c.Update(ctx, &edge); err != nil {
+			d.log.Error(err, "error updating tcp edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.delete {
+		if err := c.Delete(ctx, &edge); client.IgnoreNotFound(err) != nil {
+			d.log.Error(err, "error deleting tcp edge", "edge", edge)
+			return err
+		}
+	}
+	for _, edge := range changes.create {
+		if err := c.Create(ctx, &edge); err != nil {
+			d.log.Error(err, "error creating tcp edge", "edge", edge)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateHTTPRouteStatuses reports the parent statuses of each HTTPRoute. ignoredRules are the rules of each route
+// left out of the edges because another rule matching the same path is served.
+func (d *Driver) updateHTTPRouteStatuses(ctx context.Context, c client.Client, ignoredRules map[types.NamespacedName][]string, ingressDomains map[string]ingressv1alpha1.Domain) error {
+	if !d.gatewayEnabled {
+		return nil
+	}
+
+	for _, route := range d.store.ListHTTPRoutes() {
+		parents := d.resolveRouteParents(kindHTTPRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains)
+		resolvedRefs := d.routeResolvedRefsCondition(route.Namespace, httpRouteBackendRefs(route))
+		partiallyInvalid := httpRoutePartiallyInvalidCondition(route, ignoredRules[types.NamespacedName{Namespace: route.Namespace, Name: route.Name}])
+		statuses := routeParentStatuses(route.Status.Parents, route.Generation, parents, resolvedRefs, partiallyInvalid)
+		if reflect.DeepEqual(route.Status.Parents, statuses) {
+			continue
+		}
+
+		updated := route.DeepCopy()
+		updated.Status.Parents = statuses
+		if err := c.Status().Update(ctx, updated); err != nil {
+			d.log.Error(err, "error updating httproute status", "namespace", route.Namespace, "name", route.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateTLSRouteStatuses(ctx context.Context, c client.Client, ingressDomains map[string]ingressv1alpha1.Domain) error {
+	if !d.gatewayEnabled {
+		return nil
+	}
+
+	for _, route := range d.store.ListTLSRoutes() {
+		parents := d.resolveRouteParents(kindTLSRoute, route.Namespace, route.Spec.ParentRefs, route.Spec.Hostnames, ingressDomains)
+		resolvedRefs := d.routeResolvedRefsCondition(route.Namespace, tlsRouteBackendRefs(route))
+		statuses := routeParentStatuses(route.Status.Parents, route.Generation, parents, resolvedRefs, nil)
+		if reflect.DeepEqual(route.Status.Parents, statuses) {
+			continue
+		}
+
+		updated := route.DeepCopy()
+		updated.Status.Parents = statuses
+		if err := c.Status().Update(ctx, updated); err != nil {
+			d.log.Error(err, "error updating tlsroute status", "namespace", route.Namespace, "name", route.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) updateTCPRouteStatuses(ctx context.Context, c client.Client, ingressDomains map[string]ingressv1alpha1.Domain) error {
+	if !d.gatewayEnabled {
+		return nil
+	}
+
+	for _, route := range d.store.ListTCPRoutes() {
+		parents := d.resolveRouteParents(kindTCPRoute, route.Namespace, route.Spec.ParentRefs, nil, ingressDomains)
+		resolvedRefs := d.routeResolvedRefsCondition(route.Namespace, tcpRouteBackendRefs(route))
+		statuses := routeParentStatuses(route.Status.Parents, route.Generation, parents, resolvedRefs, nil)
+		if reflect.DeepEqual(route.Status.Parents, statuses) {
+			continue
+		}
+
+		updated := route.DeepCopy()
+		updated.Status.Parents = statuses
+		if err := c.Status().Update(ctx, updated); err != nil {
+			d.log.Error(err, "error updating tcproute status", "namespace", route.Namespace, "name", route.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// routeResolvedRefsCondition reports whether all of a route's backendRefs resolve to a Service port. When some
+// don't, the reason is that of the first unresolved ref and the message lists all of them.
+func (d *Driver) routeResolvedRefsCondition(namespace string, refs []gatewayv1.BackendRef) metav1.Condition {
+	var reason gatewayv1.RouteConditionReason
+	var messages []string
+	for _, ref := range refs {
+		if _, _, refReason, err := d.resolveRouteServiceBackend(namespace, ref); err != nil {
+			if reason == "" {
+				reason = refReason
+			}
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) > 0 {
+		return metav1.Condition{
+			Type:    string(gatewayv1.RouteConditionResolvedRefs),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(reason),
+			Message: strings.Join(messages, "; "),
+		}
+	}
+	return metav1.Condition{
+		Type:    string(gatewayv1.RouteConditionResolvedRefs),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.RouteReasonResolvedRefs),
+		Message: "All references resolved",
+	}
+}
+
+// httpRoutePartiallyInvalidCondition returns a PartiallyInvalid condition if any of the route's rules use filters
+// that are ignored because ngrok can't support them, or are ignored because they share a path with the rule served
+// for it, otherwise nil
+func httpRoutePartiallyInvalidCondition(route *gatewayv1.HTTPRoute, ignoredRules []string) *metav1.Condition {
+	var messages []string
+	for _, rule := range route.Spec.Rules {
+		if slices.ContainsFunc(rule.Filters, func(filter gatewayv1.HTTPRouteFilter) bool {
+			return filter.Type == gatewayv1.HTTPRouteFilterRequestMirror
+		}) {
+			messages = append(messages, "RequestMirror filters are not supported and are ignored")
+			break
+		}
+	}
+	if len(ignoredRules) > 0 {
+		messages = append(messages, fmt.Sprintf(
+			"only one rule can be served per path, rules matching the same path as the served rule are ignored: %s",
+			strings.Join(ignoredRules, ", "),
+		))
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return &metav1.Condition{
+		Type:    string(gatewayv1.RouteConditionPartiallyInvalid),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.RouteReasonUnsupportedValue),
+		Message: strings.Join(messages, "; "),
+	}
+}
+
+// routeParentStatuses builds the parent statuses of a route from its resolved parents. Statuses written by
+// other controllers are kept, and conditions we reported before keep their transition times. Ignored parents get
+// no status. The PartiallyInvalid condition is removed when partiallyInvalid is nil.
+func routeParentStatuses(existing []gatewayv1.RouteParentStatus, generation int64, parents []routeParent, resolvedRefs metav1.Condition, partiallyInvalid *metav1.Condition) []gatewayv1.RouteParentStatus {
+	var statuses []gatewayv1.RouteParentStatus
+	for _, status := range existing {
+		if status.ControllerName != GatewayControllerName {
+			statuses = append(statuses, status)
+		}
+	}
+
+	for _, parent := range parents {
+		if parent.ignored {
+			continue
+		}
+
+		var conditions []metav1.Condition
+		for _, status := range existing {
+			if status.ControllerName == GatewayControllerName && reflect.DeepEqual(status.ParentRef, parent.ref) {
+				conditions = slices.Clone(status.Conditions)
+				break
+			}
+		}
+
+		accepted := metav1.Condition{
+			Type:               string(gatewayv1.RouteConditionAccepted),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(parent.reason),
+			Message:            parent.message,
+			ObservedGeneration: generation,
+		}
+		if !parent.accepted() {
+			accepted.Status = metav1.ConditionFalse
+		}
+		meta.SetStatusCondition(&conditions, accepted)
+
+		resolvedRefs.ObservedGeneration = generation
+		meta.SetStatusCondition(&conditions, resolvedRefs)
+
+		if partiallyInvalid != nil {
+			partiallyInvalid.ObservedGeneration = generation
+			meta.SetStatusCondition(&conditions, *partiallyInvalid)
+		} else {
+			meta.RemoveStatusCondition(&conditions, string(gatewayv1.RouteConditionPartiallyInvalid))
+		}
+
+		statuses = append(statuses, gatewayv1.RouteParentStatus{
+			ParentRef:      parent.ref,
+			ControllerName: GatewayControllerName,
+			Conditions:     conditions,
+		})
+	}
+
+	return statuses
+}
@@ -2,19 +2,30 @@ package store
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 )
@@ -29,6 +40,7 @@ var _ = Describe("Driver", func() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1alpha2.AddToScheme(scheme))
 	BeforeEach(func() {
 		// create a fake logger to pass into the cachestore
 		logger := logr.New(logr.Discard().GetSink())
@@ -39,7 +51,6 @@ var _ = Describe("Driver", func() {
 			types.NamespacedName{Name: defaultManagerName},
 			false,
 		)
-		driver.syncAllowConcurrent = true
 	})
 
 	Describe("Seed", func() {
@@ -47,6 +58,47 @@ var _ = Describe("Driver", func() {
 			err := driver.Seed(context.Background(), fake.NewClientBuilder().WithScheme(scheme).Build())
 			Expect(err).ToNot(HaveOccurred())
 		})
+		It("Should only be ready once seeded", func() {
+			Expect(driver.Seeded(nil)).ToNot(Succeed())
+			Expect(driver.Seed(context.Background(), fake.NewClientBuilder().WithScheme(scheme).Build())).To(Succeed())
+			Expect(driver.Seeded(nil)).To(Succeed())
+		})
+		It("Should not be ready when seeding fails", func() {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithInterceptorFuncs(interceptor.Funcs{
+					List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error {
+						return errors.New("apiserver unavailable")
+					},
+				}).
+				Build()
+			Expect(driver.Seeder(c).Start(context.Background())).ToNot(Succeed())
+			Expect(driver.Seeded(nil)).ToNot(Succeed())
+
+			Expect(driver.Seeder(fake.NewClientBuilder().WithScheme(scheme).Build()).Start(context.Background())).To(Succeed())
+			Expect(driver.Seeded(nil)).To(Succeed())
+		})
+		It("Should not sync before being seeded", func() {
+			// the store has an ingress needing a domain, but isn't seeded
+			ing := NewTestIngressV1("test-ingress", "test-namespace")
+			ic := NewTestIngressClass("test-ingress-class", true, true)
+			Expect(driver.store.Add(&ic)).To(Succeed())
+			Expect(driver.store.Add(&ing)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- driver.WithSyncLoop(c, 0).Start(ctx) }()
+
+			Consistently(func() ([]ingressv1alpha1.Domain, error) {
+				domains := &ingressv1alpha1.DomainList{}
+				err := c.List(context.Background(), domains)
+				return domains.Items, err
+			}, "200ms").Should(BeEmpty())
+			Expect(driver.Seeded(nil)).ToNot(Succeed())
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
 		It("Should add all the found items to the store", func() {
 			i1 := NewTestIngressV1("test-ingress", "test-namespace")
 			i2 := NewTestIngressV1("test-ingress-2", "test-namespace")
@@ -162,6 +214,10 @@ var _ = Describe("Driver", func() {
 				Expect(foundTunnel.Namespace).To(Equal("test-namespace"))
 				Expect(foundTunnel.Name).To(HavePrefix("example-80-"))
 				Expect(foundTunnel.Labels["k8s.ngrok.com/controller-name"]).To(Equal(defaultManagerName))
+
+				Expect(testutil.ToFloat64(desiredResources.WithLabelValues("Tunnel"))).To(Equal(1.0))
+				Expect(testutil.ToFloat64(currentResources.WithLabelValues("Tunnel"))).To(Equal(0.0))
+				Expect(testutil.CollectAndCount(syncDuration)).To(BeNumerically(">", 0))
 			})
 		})
 	})
@@ -424,70 +480,1235 @@ var _ = Describe("Driver", func() {
 				},
 			))
 		})
+
+		It("merges the ingress's module sets over the namespace defaults and the cluster module sets", func() {
+			baseline := &ingressv1alpha1.ClusterNgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "baseline"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					Compression:   &ingressv1alpha1.EndpointCompression{Enabled: false},
+					IPRestriction: &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{"cluster"}},
+					OIDC:          &ingressv1alpha1.EndpointOIDC{Issuer: "https://accounts.example.com"},
+				},
+			}
+			nsDefault := &ingressv1alpha1.NgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "defaults",
+					Namespace:   "test",
+					Annotations: map[string]string{"k8s.ngrok.com/is-default-module-set": "true"},
+				},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					IPRestriction: &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{"namespace"}},
+					OAuth:         &ingressv1alpha1.EndpointOAuth{Google: &ingressv1alpha1.EndpointOAuthGoogle{}},
+				},
+			}
+			otherDefault := nsDefault.DeepCopy()
+			otherDefault.Namespace = "other"
+			otherDefault.Modules.Headers = &ingressv1alpha1.EndpointHeaders{}
+			Expect(driver.store.Add(baseline)).To(Succeed())
+			Expect(driver.store.Add(nsDefault)).To(Succeed())
+			Expect(driver.store.Add(otherDefault)).To(Succeed())
+
+			ing := NewTestIngressV1("test-ingress", "test")
+			ing.SetAnnotations(map[string]string{"k8s.ngrok.com/modules": "ms1"})
+
+			ms, err := driver.getNgrokModuleSetForIngress(&ing)
+			Expect(err).To(BeNil())
+			Expect(ms.Modules).To(Equal(
+				ingressv1alpha1.NgrokModuleSetModules{
+					Compression:   ms1.Modules.Compression,
+					IPRestriction: nsDefault.Modules.IPRestriction,
+					OAuth:         nsDefault.Modules.OAuth, // Replaces the cluster's OIDC
+				},
+			))
+		})
+	})
+
+	Describe("enforced ClusterNgrokModuleSets", func() {
+		It("overrides the modules of every route", func() {
+			sso := &ingressv1alpha1.ClusterNgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sso"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					OIDC:           &ingressv1alpha1.EndpointOIDC{Issuer: "https://accounts.example.com"},
+					TLSTermination: &ingressv1alpha1.EndpointTLSTerminationAtEdge{MinVersion: "1.3"},
+				},
+				Enforced: true,
+			}
+
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/oauth-provider":                  "google",
+				"k8s.ngrok.com/circuit-breaker-error-threshold": "0.5",
+			})
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s, sso}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.TLSTermination).To(Equal(sso.Modules.TLSTermination))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			route := edges.Items[0].Spec.Routes[0]
+			Expect(route.OIDC).To(Equal(sso.Modules.OIDC))
+			Expect(route.OAuth).To(BeNil())
+			Expect(route.CircuitBreaker).NotTo(BeNil())
+		})
+
+		It("merges an enforced module set with its own merge strategy without weakening it", func() {
+			audit := &ingressv1alpha1.ClusterNgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					IPRestriction: &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{"corp"}},
+					Policy: &ingressv1alpha1.EndpointPolicy{
+						Inbound: []ingressv1alpha1.EndpointRule{{Name: "audit", Actions: []ingressv1alpha1.EndpointAction{{Type: "log"}}}},
+					},
+				},
+				MergeStrategy: ingressv1alpha1.MergeStrategyMerge,
+				Enforced:      true,
+			}
+			Expect(driver.store.Add(audit)).To(Succeed())
+
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/policy":      `{"inbound": [{"name": "from-annotation", "actions": [{"type": "deny"}]}]}`,
+				"k8s.ngrok.com/ip-policies": "public",
+			})
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			policy := edges.Items[0].Spec.Routes[0].Policy
+			Expect(policy).NotTo(BeNil())
+			Expect(policy.Inbound).To(HaveLen(2))
+			// the enforced rules run first, so the ingress's terminal rules can't skip them
+			Expect(policy.Inbound[0].Name).To(Equal("audit"))
+			Expect(policy.Inbound[1].Name).To(Equal("from-annotation"))
+			// and the ingress can't allow more addresses than the enforced IP policies
+			Expect(edges.Items[0].Spec.Routes[0].IPRestriction).To(Equal(audit.Modules.IPRestriction))
+		})
+	})
+
+	Describe("path module sets", func() {
+		It("merges the module sets of each path over the ingress's modules", func() {
+			compression := &ingressv1alpha1.NgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "compression", Namespace: "test-namespace"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					Compression: &ingressv1alpha1.EndpointCompression{Enabled: true},
+					OIDC:        &ingressv1alpha1.EndpointOIDC{Issuer: "https://accounts.example.com"},
+				},
+			}
+			api := &ingressv1alpha1.NgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test-namespace"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					IPRestriction: &ingressv1alpha1.EndpointIPPolicy{IPPolicies: []string{"ipp_123"}},
+				},
+			}
+			admin := &ingressv1alpha1.NgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "test-namespace"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					SAML: &ingressv1alpha1.EndpointSAML{IdPMetadata: "<xml/>"},
+				},
+			}
+
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/modules": "compression",
+				"k8s.ngrok.com/path-modules": `
+/api: api
+example.com/admin: api,admin
+other.example.com/: admin
+`,
+			})
+			paths := &i1.Spec.Rules[0].HTTP.Paths
+			for _, path := range []string{"/api", "/admin"} {
+				p := (*paths)[0].DeepCopy()
+				p.Path = path
+				*paths = append(*paths, *p)
+			}
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			for _, ms := range []*ingressv1alpha1.NgrokModuleSet{compression, api, admin} {
+				Expect(driver.store.Add(ms)).To(Succeed())
+			}
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			routes := map[string]ingressv1alpha1.HTTPSEdgeRouteSpec{}
+			for _, route := range edges.Items[0].Spec.Routes {
+				routes[route.Match] = route
+			}
+			Expect(routes).To(HaveLen(3))
+
+			Expect(routes["/"].Compression).To(Equal(compression.Modules.Compression))
+			Expect(routes["/"].OIDC).To(Equal(compression.Modules.OIDC))
+			Expect(routes["/"].IPRestriction).To(BeNil())
+			Expect(routes["/"].SAML).To(BeNil())
+
+			Expect(routes["/api"].Compression).To(Equal(compression.Modules.Compression))
+			Expect(routes["/api"].OIDC).To(Equal(compression.Modules.OIDC))
+			Expect(routes["/api"].IPRestriction).To(Equal(api.Modules.IPRestriction))
+
+			Expect(routes["/admin"].Compression).To(Equal(compression.Modules.Compression))
+			Expect(routes["/admin"].IPRestriction).To(Equal(api.Modules.IPRestriction))
+			Expect(routes["/admin"].SAML).To(Equal(admin.Modules.SAML))
+			Expect(routes["/admin"].OIDC).To(BeNil())
+		})
+	})
+
+	Describe("applyAnnotationModules", func() {
+		var modSet *ingressv1alpha1.NgrokModuleSet
+
+		BeforeEach(func() {
+			modSet = &ingressv1alpha1.NgrokModuleSet{
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					Compression: &ingressv1alpha1.EndpointCompression{Enabled: true},
+					OIDC:        &ingressv1alpha1.EndpointOIDC{Issuer: "https://accounts.example.com"},
+					Policy:      &ingressv1alpha1.EndpointPolicy{Inbound: []ingressv1alpha1.EndpointRule{{Name: "from-module-set"}}},
+				},
+			}
+		})
+
+		It("keeps the module set's modules when no module annotations are set", func() {
+			ing := NewTestIngressV1("test-ingress", "test")
+			expected := modSet.DeepCopy()
+
+			routeModules, err := driver.annotationsExtractor.Extract(&ing)
+			Expect(err).ToNot(HaveOccurred())
+			applyAnnotationModules(modSet, routeModules)
+			Expect(modSet).To(Equal(expected))
+		})
+
+		It("replaces every auth module from the module sets with the annotated one", func() {
+			ing := NewTestIngressV1("test-ingress", "test")
+			ing.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/oauth-provider":      "google",
+				"k8s.ngrok.com/oauth-email-domains": "example.com",
+			})
+
+			routeModules, err := driver.annotationsExtractor.Extract(&ing)
+			Expect(err).ToNot(HaveOccurred())
+			applyAnnotationModules(modSet, routeModules)
+			Expect(modSet.Modules.Compression).NotTo(BeNil())
+			Expect(modSet.Modules.OIDC).To(BeNil())
+			Expect(modSet.Modules.OAuth).NotTo(BeNil())
+			Expect(modSet.Modules.OAuth.Google).NotTo(BeNil())
+			Expect(modSet.Modules.OAuth.Google.EmailDomains).To(Equal([]string{"example.com"}))
+		})
+
+		It("applies the circuit breaker and policy annotations", func() {
+			ing := NewTestIngressV1("test-ingress", "test")
+			ing.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/circuit-breaker-error-threshold": "0.5",
+				"k8s.ngrok.com/circuit-breaker-num-buckets":     "10",
+				"k8s.ngrok.com/policy":                          `{"inbound": [{"name": "from-annotation", "actions": [{"type": "deny"}]}]}`,
+			})
+
+			routeModules, err := driver.annotationsExtractor.Extract(&ing)
+			Expect(err).ToNot(HaveOccurred())
+			applyAnnotationModules(modSet, routeModules)
+			Expect(modSet.Modules.CircuitBreaker).NotTo(BeNil())
+			Expect(modSet.Modules.CircuitBreaker.NumBuckets).To(Equal(uint32(10)))
+			Expect(modSet.Modules.Policy.Inbound).To(HaveLen(1))
+			Expect(modSet.Modules.Policy.Inbound[0].Name).To(Equal("from-annotation"))
+		})
+
+		It("drops the module set's policy when the policy is in a ConfigMap", func() {
+			ing := NewTestIngressV1("test-ingress", "test")
+			ing.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/policy-configmap-name": "traffic-policy",
+				"k8s.ngrok.com/policy-configmap-key":  "policy.yaml",
+			})
+
+			routeModules, err := driver.annotationsExtractor.Extract(&ing)
+			Expect(err).ToNot(HaveOccurred())
+			applyAnnotationModules(modSet, routeModules)
+			Expect(modSet.Modules.Policy).To(BeNil())
+			Expect(routeModules.PolicyConfigMapRef).To(Equal(&ingressv1alpha1.ConfigMapKeyRef{Name: "traffic-policy", Key: "policy.yaml"}))
+		})
+
+		It("doesn't publish the routes of an ingress with an invalid auth annotation", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/oauth-provider":      "not-a-provider",
+				"k8s.ngrok.com/oauth-email-domains": "example.com",
+			})
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			for _, edge := range edges.Items {
+				Expect(edge.Spec.Routes).To(BeEmpty())
+			}
+		})
+
+		It("doesn't serve a path whose policy ConfigMap conflicts with an enforced policy", func() {
+			audit := &ingressv1alpha1.ClusterNgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					Policy: &ingressv1alpha1.EndpointPolicy{
+						Inbound: []ingressv1alpha1.EndpointRule{{Name: "audit", Actions: []ingressv1alpha1.EndpointAction{{Type: "log"}}}},
+					},
+				},
+				Enforced: true,
+			}
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/policy-configmap-name": "traffic-policy",
+				"k8s.ngrok.com/policy-configmap-key":  "policy.yaml",
+			})
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s, audit}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			recorder := record.NewFakeRecorder(10)
+			driver.WithEventRecorder(recorder)
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(BeEmpty())
+			Expect(recorder.Events).To(Receive(ContainSubstring("PolicyConflict")))
+		})
+
+		It("sets mutual TLS on the edge and the websocket TCP converter on its routes", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			i1.SetAnnotations(map[string]string{
+				"k8s.ngrok.com/mutual-tls-ca-secrets":   "client-ca",
+				"k8s.ngrok.com/websocket-tcp-converter": "true",
+			})
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.MutualTLS).To(Equal(&ingressv1alpha1.EndpointMutualTLS{
+				CertificateAuthoritySecrets: []ingressv1alpha1.SecretKeyRef{{Name: "client-ca", Key: "ca.crt"}},
+			}))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes[0].WebsocketTCPConverter).To(Equal(&ingressv1alpha1.EndpointWebsocketTCPConverter{Enabled: true}))
+		})
 	})
 
-	Describe("When not running concurrently", func() {
-		It("starts one", func() {
-			proceed, wait := driver.syncStart(false)
-			Expect(proceed).To(BeTrue())
-			Expect(wait).To(BeNil())
-			driver.syncDone()
+	Describe("Plan", func() {
+		It("Should report the changes without applying them", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+
+			plan, err := driver.Plan(context.Background(), c)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Empty()).To(BeFalse())
+
+			Expect(plan.Domains).To(HaveLen(1))
+			Expect(plan.Domains[0].Action).To(Equal(PlanActionCreate))
+			Expect(plan.Domains[0].Name).To(Equal("example-com"))
+			Expect(plan.HTTPSEdges).To(HaveLen(1))
+			Expect(plan.HTTPSEdges[0].Action).To(Equal(PlanActionCreate))
+			Expect(plan.Tunnels).To(HaveLen(1))
+			Expect(plan.Tunnels[0].Action).To(Equal(PlanActionCreate))
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(BeEmpty())
+
+			// once synced, there is nothing left to do
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+			plan, err = driver.Plan(context.Background(), c)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Empty()).To(BeTrue())
 		})
 
-		It("second waits, then returns error", func() {
-			firstProceed, _ := driver.syncStart(false)
-			Expect(firstProceed).To(BeTrue())
+		It("Should report the fields an update changes", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges)).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			edge := edges.Items[0]
+			match := edge.Spec.Routes[0].Match
+			edge.Spec.Routes[0].Match = "/changed"
+			edge.Spec.Routes[0].Backend.Labels["k8s.ngrok.com/port"] = "9090"
+			Expect(c.Update(context.Background(), &edge)).To(Succeed())
+
+			plan, err := driver.Plan(context.Background(), c)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.HTTPSEdges).To(HaveLen(1))
+			Expect(plan.HTTPSEdges[0].Action).To(Equal(PlanActionUpdate))
+			Expect(plan.HTTPSEdges[0].Fields).To(Equal([]PlanFieldChange{
+				{Path: `spec.routes[0].backend.labels["k8s.ngrok.com/port"]`, Old: "9090", New: "80"},
+				{Path: "spec.routes[0].match", Old: "/changed", New: match},
+			}))
+
+			var out strings.Builder
+			Expect(plan.WriteText(&out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring(`    spec.routes[0].match: "/changed" -> "` + match + `"`))
+		})
+	})
 
-			secondProceed, secondWait := driver.syncStart(false)
-			Expect(secondProceed).To(BeFalse())
-			Expect(secondWait).To(Not(BeNil()))
+	Describe("TLSRoute and TCPRoute", func() {
+		var gtwClass gatewayv1.GatewayClass
+		var gtw gatewayv1.Gateway
+		var svc corev1.Service
+		var tlsRoute gatewayv1alpha2.TLSRoute
+		var tcpRoute gatewayv1alpha2.TCPRoute
 
-			driver.syncDone()
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				true,
+			)
+
+			gtwClass = NewTestGatewayClass("ngrok", true)
+			hostname := gatewayv1.Hostname("tls.example.com")
+			passthrough := gatewayv1.TLSModePassthrough
+			gtw = gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "test-namespace"},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "ngrok",
+					Listeners: []gatewayv1.Listener{
+						{
+							Name:     "tls",
+							Hostname: &hostname,
+							Port:     443,
+							Protocol: gatewayv1.TLSProtocolType,
+							TLS:      &gatewayv1.GatewayTLSConfig{Mode: &passthrough},
+							AllowedRoutes: &gatewayv1.AllowedRoutes{
+								Kinds: []gatewayv1.RouteGroupKind{{Kind: "TLSRoute"}},
+							},
+						},
+						{
+							Name:     "tcp",
+							Port:     5432,
+							Protocol: gatewayv1.TCPProtocolType,
+							AllowedRoutes: &gatewayv1.AllowedRoutes{
+								Kinds: []gatewayv1.RouteGroupKind{{Kind: "TCPRoute"}},
+							},
+						},
+					},
+				},
+			}
+			svc = NewTestServiceV1("example", "test-namespace")
 
-			err := secondWait(context.Background())
-			Expect(err).To(Equal(errSyncDone))
+			port := gatewayv1.PortNumber(80)
+			backendRefs := []gatewayv1.BackendRef{{
+				BackendObjectReference: gatewayv1.BackendObjectReference{Name: "example", Port: &port},
+			}}
+			tlsRoute = gatewayv1alpha2.TLSRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "tls-route", Namespace: "test-namespace"},
+				Spec: gatewayv1alpha2.TLSRouteSpec{
+					CommonRouteSpec: gatewayv1.CommonRouteSpec{
+						ParentRefs: []gatewayv1.ParentReference{{Name: "gw"}},
+					},
+					Hostnames: []gatewayv1.Hostname{hostname},
+					Rules:     []gatewayv1alpha2.TLSRouteRule{{BackendRefs: backendRefs}},
+				},
+			}
+			tcpRoute = gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "tcp-route", Namespace: "test-namespace"},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1.CommonRouteSpec{
+						ParentRefs: []gatewayv1.ParentReference{{Name: "gw"}},
+					},
+					Rules: []gatewayv1alpha2.TCPRouteRule{{BackendRefs: backendRefs}},
+				},
+			}
 		})
 
-		It("third releases second, no error", func() {
-			firstProceed, _ := driver.syncStart(false)
-			Expect(firstProceed).To(BeTrue())
+		It("Should create TLS and TCP edges and report route statuses", func() {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &gtw, &svc, &tlsRoute, &tcpRoute).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1alpha2.TLSRoute{}, &gatewayv1alpha2.TCPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
 
-			secondProceed, secondWait := driver.syncStart(false)
-			Expect(secondProceed).To(BeFalse())
-			Expect(secondWait).To(Not(BeNil()))
+			tlsEdges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), tlsEdges)).To(Succeed())
+			Expect(tlsEdges.Items).To(HaveLen(1))
+			Expect(tlsEdges.Items[0].Spec.Hostports).To(ConsistOf("tls.example.com:443"))
 
-			thirdProceed, thirdWait := driver.syncStart(false)
-			Expect(thirdProceed).To(BeFalse())
-			Expect(thirdWait).To(Not(BeNil()))
+			tcpEdges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), tcpEdges)).To(Succeed())
+			Expect(tcpEdges.Items).To(HaveLen(1))
 
-			secondErr := secondWait(context.Background())
-			Expect(secondErr).To(BeNil())
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(1))
+			Expect(tunnels.Items[0].Spec.ForwardsTo).To(Equal("example.test-namespace.svc.cluster.local:80"))
 
-			driver.syncDone()
+			foundTLSRoute := &gatewayv1alpha2.TLSRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&tlsRoute), foundTLSRoute)).To(Succeed())
+			Expect(foundTLSRoute.Status.Parents).To(HaveLen(1))
+			Expect(foundTLSRoute.Status.Parents[0].ControllerName).To(BeEquivalentTo(GatewayControllerName))
+			Expect(meta.IsStatusConditionTrue(foundTLSRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(foundTLSRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))).To(BeTrue())
 
-			err := thirdWait(context.Background())
-			Expect(err).To(Equal(errSyncDone))
+			foundTCPRoute := &gatewayv1alpha2.TCPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&tcpRoute), foundTCPRoute)).To(Succeed())
+			Expect(foundTCPRoute.Status.Parents).To(HaveLen(1))
+			Expect(meta.IsStatusConditionTrue(foundTCPRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
 		})
 
-		It("partial third does not wait, no error", func() {
-			firstProceed, _ := driver.syncStart(true)
-			Expect(firstProceed).To(BeTrue())
+		It("Should ignore TLS and TCP routes on Gateways of other GatewayClasses", func() {
+			other := NewTestGatewayClass("other", false)
+			gtw.Spec.GatewayClassName = "other"
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &other, &gtw, &svc, &tlsRoute, &tcpRoute).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1alpha2.TLSRoute{}, &gatewayv1alpha2.TCPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
 
-			secondProceed, secondWait := driver.syncStart(false)
-			Expect(secondProceed).To(BeFalse())
-			Expect(secondWait).To(Not(BeNil()))
+			tlsEdges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), tlsEdges)).To(Succeed())
+			Expect(tlsEdges.Items).To(BeEmpty())
 
-			thirdProceed, thirdWait := driver.syncStart(true)
-			Expect(thirdProceed).To(BeFalse())
-			Expect(thirdWait).To(Not(BeNil()))
+			tcpEdges := &ingressv1alpha1.TCPEdgeList{}
+			Expect(c.List(context.Background(), tcpEdges)).To(Succeed())
+			Expect(tcpEdges.Items).To(BeEmpty())
 
-			thirdErr := thirdWait(context.Background())
-			Expect(thirdErr).To(BeNil())
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(BeEmpty())
+
+			foundTLSRoute := &gatewayv1alpha2.TLSRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&tlsRoute), foundTLSRoute)).To(Succeed())
+			Expect(foundTLSRoute.Status.Parents).To(BeEmpty())
+
+			foundTCPRoute := &gatewayv1alpha2.TCPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&tcpRoute), foundTCPRoute)).To(Succeed())
+			Expect(foundTCPRoute.Status.Parents).To(BeEmpty())
+		})
+
+		It("Should only reserve domains for the listeners served by HTTPS and TLS edges", func() {
+			tcpHostname := gatewayv1.Hostname("tcp.example.com")
+			gtw.Spec.Listeners[1].Hostname = &tcpHostname
+			unsupported := gatewayv1.Hostname("unsupported.example.com")
+			gtw.Spec.Listeners = append(gtw.Spec.Listeners, gatewayv1.Listener{
+				Name:     "https-8443",
+				Hostname: &unsupported,
+				Port:     8443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+			})
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &gtw, &svc).
+				WithStatusSubresource(&gatewayv1.Gateway{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(HaveLen(1))
+			Expect(domains.Items[0].Spec.Domain).To(Equal("tls.example.com"))
+		})
+
+		It("Should not accept a TLSRoute on a listener that terminates TLS", func() {
+			terminate := gatewayv1.TLSModeTerminate
+			gtw.Spec.Listeners[0].TLS.Mode = &terminate
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &gtw, &svc, &tlsRoute).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1alpha2.TLSRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			tlsEdges := &ingressv1alpha1.TLSEdgeList{}
+			Expect(c.List(context.Background(), tlsEdges)).To(Succeed())
+			Expect(tlsEdges.Items).To(BeEmpty())
+
+			foundTLSRoute := &gatewayv1alpha2.TLSRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&tlsRoute), foundTLSRoute)).To(Succeed())
+			Expect(foundTLSRoute.Status.Parents).To(HaveLen(1))
+			Expect(meta.IsStatusConditionFalse(foundTLSRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+		})
+	})
+
+	Describe("Gateway and HTTPRoute statuses", func() {
+		var gtwClass gatewayv1.GatewayClass
+		var gtw gatewayv1.Gateway
+		var route gatewayv1.HTTPRoute
+
+		BeforeEach(func() {
+			driver = NewDriver(
+				logr.New(logr.Discard().GetSink()),
+				scheme,
+				defaultControllerName,
+				types.NamespacedName{Name: defaultManagerName},
+				true,
+			)
+
+			gtwClass = NewTestGatewayClass("ngrok", true)
+			hostname := gatewayv1.Hostname("gw.example.com")
+			conflicting := gatewayv1.Hostname("example.com")
+			gtw = gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "test-namespace", Generation: 2},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "ngrok",
+					Listeners: []gatewayv1.Listener{
+						{Name: "https", Hostname: &hostname, Port: 443, Protocol: gatewayv1.HTTPSProtocolType},
+						{Name: "conflict", Hostname: &conflicting, Port: 443, Protocol: gatewayv1.HTTPSProtocolType},
+					},
+				},
+			}
+
+			port := gatewayv1.PortNumber(80)
+			kind := gatewayv1.Kind("Service")
+			route = gatewayv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "test-namespace"},
+				Spec: gatewayv1.HTTPRouteSpec{
+					CommonRouteSpec: gatewayv1.CommonRouteSpec{
+						ParentRefs: []gatewayv1.ParentReference{{Name: "gw"}},
+					},
+					Hostnames: []gatewayv1.Hostname{hostname},
+					Rules: []gatewayv1.HTTPRouteRule{{
+						BackendRefs: []gatewayv1.HTTPBackendRef{{
+							BackendRef: gatewayv1.BackendRef{
+								BackendObjectReference: gatewayv1.BackendObjectReference{Name: "example", Kind: &kind, Port: &port},
+							},
+						}},
+					}},
+				},
+			}
+		})
+
+		It("Should report listener conflicts, addresses, and attached routes", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			svc := NewTestServiceV1("example", "test-namespace")
+			cname := "abc123.cname.ngrok.app"
+			domain := NewDomainV1("gw.example.com", "test-namespace")
+			domain.Name = "gw-example-com"
+			domain.Status = ingressv1alpha1.DomainStatus{ID: "rd_123", Domain: "gw.example.com", CNAMETarget: &cname}
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&ic1, &i1, &svc, &gtwClass, &gtw, &route, &domain).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}, &netv1.Ingress{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+
+			foundGateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&gtw), foundGateway)).To(Succeed())
+			accepted := meta.FindStatusCondition(foundGateway.Status.Conditions, string(gatewayv1.GatewayConditionAccepted))
+			Expect(accepted).ToNot(BeNil())
+			Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+			Expect(accepted.Reason).To(Equal(string(gatewayv1.GatewayReasonListenersNotValid)))
+			Expect(accepted.ObservedGeneration).To(Equal(int64(2)))
+			Expect(meta.IsStatusConditionTrue(foundGateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))).To(BeTrue())
+			Expect(foundGateway.Status.Addresses).To(HaveLen(1))
+			Expect(foundGateway.Status.Addresses[0].Value).To(Equal(cname))
+
+			Expect(foundGateway.Status.Listeners).To(HaveLen(2))
+			Expect(foundGateway.Status.Listeners[0].AttachedRoutes).To(Equal(int32(1)))
+			Expect(meta.IsStatusConditionTrue(foundGateway.Status.Listeners[0].Conditions, string(gatewayv1.ListenerConditionProgrammed))).To(BeTrue())
+			Expect(foundGateway.Status.Listeners[1].AttachedRoutes).To(Equal(int32(0)))
+			conflicted := meta.FindStatusCondition(foundGateway.Status.Listeners[1].Conditions, string(gatewayv1.ListenerConditionConflicted))
+			Expect(conflicted).ToNot(BeNil())
+			Expect(conflicted.Status).To(Equal(metav1.ConditionTrue))
+			Expect(conflicted.Reason).To(Equal(string(gatewayv1.ListenerReasonHostnameConflict)))
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			Expect(foundRoute.Status.Parents).To(HaveLen(1))
+			Expect(meta.IsStatusConditionTrue(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))).To(BeTrue())
+		})
+
+		It("Should serve HTTPRoutes attached to HTTP listeners", func() {
+			gtw.Spec.Listeners = gtw.Spec.Listeners[:1]
+			gtw.Spec.Listeners[0].Name = "http"
+			gtw.Spec.Listeners[0].Port = 80
+			gtw.Spec.Listeners[0].Protocol = gatewayv1.HTTPProtocolType
+			svc := NewTestServiceV1("example", "test-namespace")
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&svc, &gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Hostports).To(Equal([]string{"gw.example.com:443"}))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+
+			foundGateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&gtw), foundGateway)).To(Succeed())
+			Expect(foundGateway.Status.Listeners).To(HaveLen(1))
+			Expect(meta.IsStatusConditionTrue(foundGateway.Status.Listeners[0].Conditions, string(gatewayv1.ListenerConditionAccepted))).To(BeTrue())
+			Expect(foundGateway.Status.Listeners[0].AttachedRoutes).To(Equal(int32(1)))
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+		})
+
+		It("Should split traffic between weighted backendRefs", func() {
+			stable := NewTestServiceV1("stable", "test-namespace")
+			stable.UID = "stable-uid"
+			canary := NewTestServiceV1("canary", "test-namespace")
+			canary.UID = "canary-uid"
+			ref := route.Spec.Rules[0].BackendRefs[0]
+			route.Spec.Rules[0].BackendRefs = nil
+			for _, backend := range []struct {
+				name   string
+				weight int32
+			}{{"stable", 90}, {"canary", 10}, {"missing", 5}} {
+				backendRef := *ref.DeepCopy()
+				backendRef.Name = gatewayv1.ObjectName(backend.name)
+				weight := backend.weight
+				backendRef.Weight = &weight
+				route.Spec.Rules[0].BackendRefs = append(route.Spec.Rules[0].BackendRefs, backendRef)
+			}
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&stable, &canary, &gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes[0].WeightedBackends).To(Equal([]ingressv1alpha1.WeightedTunnelGroupBackend{
+				{Labels: driver.ngrokLabels("test-namespace", "stable-uid", "stable", 80), Weight: 90},
+				{Labels: driver.ngrokLabels("test-namespace", "canary-uid", "canary", 80), Weight: 10},
+			}))
+
+			tunnels := &ingressv1alpha1.TunnelList{}
+			Expect(c.List(context.Background(), tunnels)).To(Succeed())
+			Expect(tunnels.Items).To(HaveLen(2))
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			resolvedRefs := meta.FindStatusCondition(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+			Expect(resolvedRefs).ToNot(BeNil())
+			Expect(resolvedRefs.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolvedRefs.Message).To(ContainSubstring("missing"))
+		})
+
+		It("Should scale weights down to the largest weight of a backend", func() {
+			stable := NewTestServiceV1("stable", "test-namespace")
+			stable.UID = "stable-uid"
+			canary := NewTestServiceV1("canary", "test-namespace")
+			canary.UID = "canary-uid"
+			ref := route.Spec.Rules[0].BackendRefs[0]
+			route.Spec.Rules[0].BackendRefs = nil
+			for _, backend := range []struct {
+				name   string
+				weight int32
+			}{{"stable", 1000000}, {"canary", 50}} {
+				backendRef := *ref.DeepCopy()
+				backendRef.Name = gatewayv1.ObjectName(backend.name)
+				weight := backend.weight
+				backendRef.Weight = &weight
+				route.Spec.Rules[0].BackendRefs = append(route.Spec.Rules[0].BackendRefs, backendRef)
+			}
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&stable, &canary, &gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes[0].WeightedBackends).To(Equal([]ingressv1alpha1.WeightedTunnelGroupBackend{
+				{Labels: driver.ngrokLabels("test-namespace", "stable-uid", "stable", 80), Weight: 10000},
+				{Labels: driver.ngrokLabels("test-namespace", "canary-uid", "canary", 80), Weight: 1},
+			}))
+		})
+
+		It("Should apply enforced ClusterNgrokModuleSets to the edge and its routes", func() {
+			enforced := &ingressv1alpha1.ClusterNgrokModuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "enforced"},
+				Modules: ingressv1alpha1.NgrokModuleSetModules{
+					Compression:    &ingressv1alpha1.EndpointCompression{Enabled: true},
+					TLSTermination: &ingressv1alpha1.EndpointTLSTerminationAtEdge{MinVersion: "1.3"},
+					Policy: &ingressv1alpha1.EndpointPolicy{
+						Inbound: []ingressv1alpha1.EndpointRule{{Name: "enforced", Actions: []ingressv1alpha1.EndpointAction{{Type: "log"}}}},
+					},
+				},
+				Enforced: true,
+			}
+			method := gatewayv1.HTTPMethodGet
+			route.Spec.Rules[0].Matches = []gatewayv1.HTTPRouteMatch{{Method: &method}}
+			svc := NewTestServiceV1("example", "test-namespace")
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&svc, &gtwClass, &gtw, &route, enforced).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.TLSTermination).To(Equal(enforced.Modules.TLSTermination))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			edgeRoute := edges.Items[0].Spec.Routes[0]
+			Expect(edgeRoute.Compression).To(Equal(enforced.Modules.Compression))
+			// the enforced policy doesn't replace the one implementing the rule's matches
+			Expect(edgeRoute.Policy).NotTo(BeNil())
+			Expect(edgeRoute.Policy.Inbound).To(HaveLen(2))
+			Expect(edgeRoute.Policy.Inbound[0].Name).To(Equal("enforced"))
+			Expect(edgeRoute.Policy.Inbound[1].Expressions).To(Equal([]string{`!(req.method == "GET")`}))
+		})
+
+		It("Should create an edge route per path ordered by precedence", func() {
+			exact := gatewayv1.PathMatchExact
+			prefix := gatewayv1.PathMatchPathPrefix
+			method := gatewayv1.HTTPMethodGet
+			backendRefs := route.Spec.Rules[0].BackendRefs
+			pathMatch := func(pathType *gatewayv1.PathMatchType, path string) *gatewayv1.HTTPPathMatch {
+				return &gatewayv1.HTTPPathMatch{Type: pathType, Value: &path}
+			}
+			route.Spec.Rules = []gatewayv1.HTTPRouteRule{
+				{
+					// shares its path with the more specific rule below, which would deny everything but GETs, so
+					// it's served instead
+					Matches:     []gatewayv1.HTTPRouteMatch{{Path: pathMatch(&prefix, "/api")}},
+					BackendRefs: backendRefs,
+				},
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{
+						{Path: pathMatch(&prefix, "/api"), Method: &method},
+						{Path: pathMatch(&exact, "/health")},
+					},
+					BackendRefs: backendRefs,
+				},
+				{
+					BackendRefs: backendRefs,
+				},
+			}
+			svc := NewTestServiceV1("example", "test-namespace")
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&svc, &gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			routes := edges.Items[0].Spec.Routes
+			Expect(routes).To(HaveLen(3))
+			Expect(routes[0].MatchType).To(Equal("exact_path"))
+			Expect(routes[0].Match).To(Equal("/health"))
+			Expect(routes[0].Policy).To(BeNil())
+			Expect(routes[1].Match).To(Equal("/api"))
+			Expect(routes[1].Policy).To(BeNil())
+			Expect(routes[2].Match).To(Equal("/"))
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			partiallyInvalid := meta.FindStatusCondition(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionPartiallyInvalid))
+			Expect(partiallyInvalid).ToNot(BeNil())
+			Expect(partiallyInvalid.Message).To(ContainSubstring("rule 1 (/api)"))
+		})
+
+		It("Should leave Gateways of other GatewayClasses alone", func() {
+			gtwClass = NewTestGatewayClass("other", false)
+			gtw.Spec.GatewayClassName = "other"
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &gtw).
+				WithStatusSubresource(&gatewayv1.Gateway{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			foundGateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&gtw), foundGateway)).To(Succeed())
+			Expect(foundGateway.Status).To(Equal(gtw.Status))
+
+			domains := &ingressv1alpha1.DomainList{}
+			Expect(c.List(context.Background(), domains)).To(Succeed())
+			Expect(domains.Items).To(BeEmpty())
+		})
+
+		It("Should serve the stable rule rather than a header canary rule on the same path", func() {
+			canary := NewTestServiceV1("canary", "test-namespace")
+			stable := NewTestServiceV1("example", "test-namespace")
+			canaryRule := *route.Spec.Rules[0].DeepCopy()
+			canaryRule.Matches = []gatewayv1.HTTPRouteMatch{{
+				Path:    &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchPathPrefix), Value: ptr.To("/")},
+				Headers: []gatewayv1.HTTPHeaderMatch{{Name: "X-Canary", Value: "true"}},
+			}}
+			canaryRule.BackendRefs[0].Name = "canary"
+			stableRule := *route.Spec.Rules[0].DeepCopy()
+			stableRule.Matches = []gatewayv1.HTTPRouteMatch{{
+				Path: &gatewayv1.HTTPPathMatch{Type: ptr.To(gatewayv1.PathMatchPathPrefix), Value: ptr.To("/")},
+			}}
+			route.Spec.Rules = []gatewayv1.HTTPRouteRule{canaryRule, stableRule}
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&canary, &stable, &gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			edges := &ingressv1alpha1.HTTPSEdgeList{}
+			Expect(c.List(context.Background(), edges, client.MatchingLabels{labelDomain: "gw.example.com"})).To(Succeed())
+			Expect(edges.Items).To(HaveLen(1))
+			Expect(edges.Items[0].Spec.Routes).To(HaveLen(1))
+			// the canary rule has the higher precedence, but serving it would deny every request without the header
+			Expect(edges.Items[0].Spec.Routes[0].Backend.Labels).To(Equal(driver.ngrokLabels("test-namespace", "", "example", 80)))
+			Expect(edges.Items[0].Spec.Routes[0].Policy).To(BeNil())
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			Expect(foundRoute.Status.Parents).To(HaveLen(1))
+			Expect(meta.IsStatusConditionTrue(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+			partiallyInvalid := meta.FindStatusCondition(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionPartiallyInvalid))
+			Expect(partiallyInvalid).ToNot(BeNil())
+			Expect(partiallyInvalid.Status).To(Equal(metav1.ConditionTrue))
+			Expect(partiallyInvalid.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+			Expect(partiallyInvalid.Message).To(ContainSubstring("rule 0 (/)"))
+		})
+
+		It("Should not be programmed until the domains are reserved", func() {
+			gtw.Spec.Listeners = gtw.Spec.Listeners[:1]
+			route.Spec.Rules[0].BackendRefs[0].Name = "missing"
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(&gtwClass, &gtw, &route).
+				WithStatusSubresource(&gatewayv1.Gateway{}, &gatewayv1.HTTPRoute{}).
+				Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+			Expect(driver.Sync(context.Background(), c)).To(Succeed())
+
+			foundGateway := &gatewayv1.Gateway{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&gtw), foundGateway)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(foundGateway.Status.Conditions, string(gatewayv1.GatewayConditionAccepted))).To(BeTrue())
+			programmed := meta.FindStatusCondition(foundGateway.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))
+			Expect(programmed).ToNot(BeNil())
+			Expect(programmed.Status).To(Equal(metav1.ConditionFalse))
+			Expect(programmed.Reason).To(Equal(string(gatewayv1.GatewayReasonPending)))
+			Expect(foundGateway.Status.Addresses).To(BeEmpty())
+
+			foundRoute := &gatewayv1.HTTPRoute{}
+			Expect(c.Get(context.Background(), client.ObjectKeyFromObject(&route), foundRoute)).To(Succeed())
+			resolvedRefs := meta.FindStatusCondition(foundRoute.Status.Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+			Expect(resolvedRefs).ToNot(BeNil())
+			Expect(resolvedRefs.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolvedRefs.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+		})
+	})
+
+	Describe("createEndpointPolicyForGateway", func() {
+		prefixRule := func(prefix string, filters ...gatewayv1.HTTPRouteFilter) *gatewayv1.HTTPRouteRule {
+			pathType := gatewayv1.PathMatchPathPrefix
+			return &gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{
+					Path: &gatewayv1.HTTPPathMatch{Type: &pathType, Value: &prefix},
+				}},
+				Filters: filters,
+			}
+		}
+
+		It("Should create a url-rewrite action for a ReplacePrefixMatch URLRewrite filter", func() {
+			replacement := "/v2/"
+			rule := prefixRule("/api/", gatewayv1.HTTPRouteFilter{
+				Type: gatewayv1.HTTPRouteFilterURLRewrite,
+				URLRewrite: &gatewayv1.HTTPURLRewriteFilter{
+					Path: &gatewayv1.HTTPPathModifier{
+						Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+						ReplacePrefixMatch: &replacement,
+					},
+				},
+			})
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, rule.Matches)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).ToNot(BeNil())
+			Expect(policy.Inbound).To(HaveLen(1))
+			Expect(policy.Inbound[0].Actions).To(HaveLen(1))
+			Expect(policy.Inbound[0].Actions[0].Type).To(Equal("url-rewrite"))
+			Expect(string(policy.Inbound[0].Actions[0].Config)).To(MatchJSON(`{
+				"from": "^https?://[^/:]+(:[0-9]*)?(/api)([^\\?]*)(\\?.*)?$",
+				"to": "$scheme://$host$1/v2$3$4"
+			}`))
+		})
+
+		It("Should create a url-rewrite action for a ReplaceFullPath URLRewrite filter with a hostname", func() {
+			replacement := "/index.html"
+			hostname := gatewayv1.PreciseHostname("internal.example.com")
+			rule := prefixRule("/", gatewayv1.HTTPRouteFilter{
+				Type: gatewayv1.HTTPRouteFilterURLRewrite,
+				URLRewrite: &gatewayv1.HTTPURLRewriteFilter{
+					Hostname: &hostname,
+					Path: &gatewayv1.HTTPPathModifier{
+						Type:            gatewayv1.FullPathHTTPPathModifier,
+						ReplaceFullPath: &replacement,
+					},
+				},
+			})
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, rule.Matches)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Inbound[0].Actions).To(HaveLen(1))
+			Expect(string(policy.Inbound[0].Actions[0].Config)).To(MatchJSON(`{
+				"from": "^https?://[^/:]+(:[0-9]*)?([^\\?]*)(\\?.*)?$",
+				"to": "$scheme://internal.example.com$1/index.html$3"
+			}`))
+		})
+
+		It("Should deny requests that don't satisfy the method, header, and query param matches", func() {
+			method := gatewayv1.HTTPMethodPost
+			regex := gatewayv1.HeaderMatchRegularExpression
+			rule := prefixRule("/api")
+			rule.Matches[0].Method = &method
+			rule.Matches[0].Headers = []gatewayv1.HTTPHeaderMatch{{Name: "X-Version", Value: "v2"}}
+			rule.Matches[0].QueryParams = []gatewayv1.HTTPQueryParamMatch{{Name: "debug", Type: (*gatewayv1.QueryParamMatchType)(&regex), Value: "^(1|true)$"}}
+			rule.Matches = append(rule.Matches, gatewayv1.HTTPRouteMatch{
+				Path:   rule.Matches[0].Path,
+				Method: &method,
+			})
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, rule.Matches)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).ToNot(BeNil())
+			Expect(policy.Inbound).To(HaveLen(1))
+			Expect(policy.Inbound[0].Expressions).To(Equal([]string{
+				`!((req.method == "POST" && ("x-version" in req.headers && req.headers["x-version"].exists(v, v == "v2")) && ` +
+					`("debug" in req.url.query_params && req.url.query_params["debug"].exists(v, v.matches("^(1|true)$")))) || (req.method == "POST"))`,
+			}))
+			Expect(policy.Inbound[0].Actions).To(HaveLen(1))
+			Expect(policy.Inbound[0].Actions[0].Type).To(Equal("deny"))
+		})
+
+		It("Should not deny anything if one of the matches only has a path", func() {
+			method := gatewayv1.HTTPMethodPost
+			rule := prefixRule("/api")
+			rule.Matches = append(rule.Matches, gatewayv1.HTTPRouteMatch{
+				Path:   rule.Matches[0].Path,
+				Method: &method,
+			})
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, rule.Matches)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).To(BeNil())
+		})
+
+		It("Should ignore RequestMirror filters instead of failing", func() {
+			rule := prefixRule("/", gatewayv1.HTTPRouteFilter{
+				Type: gatewayv1.HTTPRouteFilterRequestMirror,
+				RequestMirror: &gatewayv1.HTTPRequestMirrorFilter{
+					BackendRef: gatewayv1.BackendObjectReference{Name: "mirror"},
+				},
+			})
+
+			policy, err := driver.createEndpointPolicyForGateway(rule, rule.Matches)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).To(BeNil())
+
+			route := &gatewayv1.HTTPRoute{Spec: gatewayv1.HTTPRouteSpec{Rules: []gatewayv1.HTTPRouteRule{*rule}}}
+			condition := httpRoutePartiallyInvalidCondition(route, nil)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Type).To(Equal(string(gatewayv1.RouteConditionPartiallyInvalid)))
+			Expect(condition.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+		})
+	})
+
+	Describe("getBackendConfig", func() {
+		var service corev1.Service
+
+		BeforeEach(func() {
+			service = NewTestServiceV1("example", "test-namespace")
+			service.Annotations = map[string]string{
+				"k8s.ngrok.com/app-protocols":                  `{"http":"HTTPS"}`,
+				"k8s.ngrok.com/backend-tls-verify":             "true",
+				"k8s.ngrok.com/backend-tls-server-name":        "example.internal",
+				"k8s.ngrok.com/backend-tls-ca-configmap":       "example-ca",
+				"k8s.ngrok.com/backend-tls-client-cert-secret": "example-client",
+			}
+		})
+
+		It("reads the backend TLS settings from the service's annotations", func() {
+			backendConfig, err := driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backendConfig.Protocol).To(Equal("HTTPS"))
+			Expect(backendConfig.TLS).To(Equal(&ingressv1alpha1.BackendTLSConfig{
+				Verify:     ptr.To(true),
+				ServerName: "example.internal",
+				CACertificates: &ingressv1alpha1.BackendCABundle{
+					ConfigMap: &ingressv1alpha1.ConfigMapKeyRef{Name: "example-ca", Key: "ca.crt"},
+				},
+				ClientCertificateSecret: "example-client",
+			}))
+		})
+
+		It("ignores the TLS settings for HTTP backends", func() {
+			delete(service.Annotations, "k8s.ngrok.com/app-protocols")
+			backendConfig, err := driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backendConfig.TLS).To(BeNil())
+		})
+
+		It("enables the PROXY protocol for any backend protocol", func() {
+			delete(service.Annotations, "k8s.ngrok.com/app-protocols")
+			service.Annotations["k8s.ngrok.com/backend-proxy-protocol"] = "2"
+			backendConfig, err := driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backendConfig.ProxyProtocolVersion).To(Equal(2))
+
+			service.Annotations["k8s.ngrok.com/backend-proxy-protocol"] = "3"
+			_, err = driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).To(MatchError(ContainSubstring("must be '1' or '2'")))
+		})
+
+		It("rejects invalid annotations", func() {
+			service.Annotations["k8s.ngrok.com/backend-tls-verify"] = "yes please"
+			_, err := driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).To(MatchError(ContainSubstring("k8s.ngrok.com/backend-tls-verify")))
+
+			service.Annotations["k8s.ngrok.com/backend-tls-verify"] = "true"
+			service.Annotations["k8s.ngrok.com/backend-tls-ca-secret"] = "example-ca"
+			_, err = driver.getBackendConfig(&service, &service.Spec.Ports[0])
+			Expect(err).To(MatchError(ContainSubstring("only one of")))
+		})
+	})
+
+	Describe("MarkDirty", func() {
+		It("coalesces multiple calls into a single pending sync", func() {
+			driver.MarkDirty()
+			driver.MarkDirty()
+			driver.MarkDirty()
+			Expect(driver.dirty).To(HaveLen(1))
+
+			<-driver.dirty
+			Expect(driver.dirty).To(BeEmpty())
+		})
+	})
+
+	Describe("Start", func() {
+		It("errors without a client", func() {
+			err := driver.Start(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("syncs the store when started and stops with the context", func() {
+			i1 := NewTestIngressV1("test-ingress", "test-namespace")
+			ic1 := NewTestIngressClass("test-ingress-class", true, true)
+			s := NewTestServiceV1("example", "test-namespace")
+			obs := []runtime.Object{&ic1, &i1, &s}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obs...).Build()
+			Expect(driver.Seed(context.Background(), c)).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- driver.WithSyncLoop(c, 0).Start(ctx)
+			}()
+
+			Eventually(func() error {
+				return c.Get(context.Background(), types.NamespacedName{
+					Namespace: "test-namespace",
+					Name:      "example-com",
+				}, &ingressv1alpha1.Domain{})
+			}).Should(Succeed())
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+
+	Describe("UpdateStoreHandler", func() {
+		var handler *UpdateStoreHandler
+		var tunnel *ingressv1alpha1.Tunnel
+
+		BeforeEach(func() {
+			handler = NewUpdateStoreHandler("Tunnel", driver, fake.NewClientBuilder().WithScheme(scheme).Build())
+			tunnel = &ingressv1alpha1.Tunnel{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test-namespace", Generation: 1},
+			}
+		})
+
+		It("does not mark the driver dirty for tunnel status updates", func() {
+			updated := tunnel.DeepCopy()
+			updated.Status.Agents = []ingressv1alpha1.TunnelAgentStatus{{PodName: "pod", Online: true}}
+			handler.Update(context.Background(), event.UpdateEvent{ObjectOld: tunnel, ObjectNew: updated}, nil)
+
+			Expect(driver.dirty).ToNot(Receive())
+			tunnels := driver.store.ListTunnelsV1()
+			Expect(tunnels).To(HaveLen(1))
+			Expect(tunnels[0].Status.Agents).To(HaveLen(1))
+		})
 
-			driver.syncDone()
+		It("marks the driver dirty for tunnel spec updates", func() {
+			updated := tunnel.DeepCopy()
+			updated.Generation = 2
+			updated.Spec.ForwardsTo = "example.test-namespace.svc.cluster.local:80"
+			handler.Update(context.Background(), event.UpdateEvent{ObjectOld: tunnel, ObjectNew: updated}, nil)
 
-			err := secondWait(context.Background())
-			Expect(err).To(Equal(errSyncDone))
+			Expect(driver.dirty).To(Receive())
 		})
 	})
 })
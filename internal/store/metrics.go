@@ -0,0 +1,48 @@
+package store
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	desiredResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ngrok",
+		Subsystem: "driver",
+		Name:      "desired_resources",
+		Help:      "Number of resources of each kind the driver calculated from the store in its last sync",
+	}, []string{"kind"})
+
+	currentResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ngrok",
+		Subsystem: "driver",
+		Name:      "current_resources",
+		Help:      "Number of resources of each kind that existed in the cluster at the start of the driver's last sync",
+	}, []string{"kind"})
+
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ngrok",
+		Subsystem: "driver",
+		Name:      "sync_duration_seconds",
+		Help:      "How long driver syncs take, by result",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(desiredResources, currentResources, syncDuration)
+}
+
+// resourceCounts is the number of desired and current resources of a kind
+type resourceCounts struct {
+	desired int
+	current int
+}
+
+// recordResourceCounts updates the resource gauges with the counts from a sync
+func recordResourceCounts(counts map[string]resourceCounts) {
+	for kind, c := range counts {
+		desiredResources.WithLabelValues(kind).Set(float64(c.desired))
+		currentResources.WithLabelValues(kind).Set(float64(c.current))
+	}
+}
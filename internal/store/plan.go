@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// changeSet holds the objects of a single kind that a Sync pass would create, update, and delete.
+// current holds the objects in update as they are in the cluster, in the same order, so plans can
+// show what an update changes.
+type changeSet[T any] struct {
+	create  []T
+	update  []T
+	current []T
+	delete  []T
+}
+
+// addUpdate adds an update of current to updated
+func (cs *changeSet[T]) addUpdate(current, updated T) {
+	cs.update = append(cs.update, updated)
+	cs.current = append(cs.current, current)
+}
+
+// PlanAction is the kind of change Sync would make to a resource
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "create"
+	PlanActionUpdate PlanAction = "update"
+	PlanActionDelete PlanAction = "delete"
+)
+
+// PlanChange is a single change Sync would make to a resource. For creates and updates Object is
+// the desired object, for deletes it is the object currently in the cluster. Updates also list the
+// fields they change.
+type PlanChange struct {
+	Action    PlanAction        `json:"action"`
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Object    client.Object     `json:"object"`
+	Fields    []PlanFieldChange `json:"fields,omitempty"`
+}
+
+// PlanFieldChange is a field an update changes, identified by its path in the object's JSON. Old or
+// New is nil when the field is unset on that side.
+type PlanFieldChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Plan is the set of changes Sync would make to bring the cluster in line with the store
+type Plan struct {
+	Domains    []PlanChange `json:"domains"`
+	HTTPSEdges []PlanChange `json:"httpsEdges"`
+	TLSEdges   []PlanChange `json:"tlsEdges"`
+	TCPEdges   []PlanChange `json:"tcpEdges"`
+	Tunnels    []PlanChange `json:"tunnels"`
+}
+
+// Plan calculates the desired state the same way Sync does and diffs it against the current state
+// read from c, but returns the changes instead of applying them. The driver should be seeded first.
+func (d *Driver) Plan(ctx context.Context, c client.Reader) (*Plan, error) {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
+	changes, err := d.calculateChanges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		Domains:    planChanges("Domain", changes.domains),
+		HTTPSEdges: planChanges("HTTPSEdge", changes.edges),
+		TLSEdges:   planChanges("TLSEdge", changes.tlsEdges),
+		TCPEdges:   planChanges("TCPEdge", changes.tcpEdges),
+		Tunnels:    planChanges("Tunnel", changes.tunnels),
+	}, nil
+}
+
+// planChanges converts a changeSet into a stable, sorted list of PlanChanges
+func planChanges[T any, PT interface {
+	*T
+	client.Object
+}](kind string, cs changeSet[T]) []PlanChange {
+	changes := []PlanChange{}
+	add := func(action PlanAction, objs []T, current []T) {
+		for i := range objs {
+			obj := PT(&objs[i])
+			name := obj.GetName()
+			if name == "" {
+				// Created edges and tunnels only have a generated name until the API server assigns one
+				name = obj.GetGenerateName() + "<generated>"
+			}
+			change := PlanChange{
+				Action:    action,
+				Kind:      kind,
+				Namespace: obj.GetNamespace(),
+				Name:      name,
+				Object:    obj,
+			}
+			if current != nil {
+				change.Fields = diffFields(PT(&current[i]), obj)
+			}
+			changes = append(changes, change)
+		}
+	}
+	add(PlanActionCreate, cs.create, nil)
+	add(PlanActionUpdate, cs.update, cs.current)
+	add(PlanActionDelete, cs.delete, nil)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// Empty returns true if the plan contains no changes
+func (p *Plan) Empty() bool {
+	return len(p.Domains) == 0 && len(p.HTTPSEdges) == 0 && len(p.TLSEdges) == 0 &&
+		len(p.TCPEdges) == 0 && len(p.Tunnels) == 0
+}
+
+// WriteText writes a human readable summary of the plan to w, one change per line
+func (p *Plan) WriteText(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+
+	symbols := map[PlanAction]string{
+		PlanActionCreate: "+",
+		PlanActionUpdate: "~",
+		PlanActionDelete: "-",
+	}
+
+	counts := map[PlanAction]int{}
+	for _, changes := range [][]PlanChange{p.Domains, p.HTTPSEdges, p.TLSEdges, p.TCPEdges, p.Tunnels} {
+		for _, change := range changes {
+			counts[change.Action]++
+			if _, err := fmt.Fprintf(w, "%s %s %s/%s\n", symbols[change.Action], change.Kind, change.Namespace, change.Name); err != nil {
+				return err
+			}
+			for _, field := range change.Fields {
+				if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", field.Path, planValue(field.Old), planValue(field.New)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n",
+		counts[PlanActionCreate], counts[PlanActionUpdate], counts[PlanActionDelete])
+	return err
+}
+
+// diffFields returns the fields that differ between the JSON representations of current and desired,
+// sorted by path
+func diffFields(current, desired client.Object) []PlanFieldChange {
+	var currentFields, desiredFields any
+	if err := roundTripJSON(current, &currentFields); err != nil {
+		return nil
+	}
+	if err := roundTripJSON(desired, &desiredFields); err != nil {
+		return nil
+	}
+
+	fields := []PlanFieldChange{}
+	appendFieldChanges(&fields, "", currentFields, desiredFields)
+	return fields
+}
+
+func roundTripJSON(obj any, out *any) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// appendFieldChanges walks objects and arrays down to the values that differ and appends them to fields
+func appendFieldChanges(fields *[]PlanFieldChange, path string, current, desired any) {
+	if reflect.DeepEqual(current, desired) {
+		return
+	}
+
+	currentMap, currentIsMap := current.(map[string]any)
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	if currentIsMap && desiredIsMap {
+		keys := make([]string, 0, len(currentMap)+len(desiredMap))
+		for k := range currentMap {
+			keys = append(keys, k)
+		}
+		for k := range desiredMap {
+			if _, ok := currentMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendFieldChanges(fields, fieldPath(path, k), currentMap[k], desiredMap[k])
+		}
+		return
+	}
+
+	currentSlice, currentIsSlice := current.([]any)
+	desiredSlice, desiredIsSlice := desired.([]any)
+	if currentIsSlice && desiredIsSlice {
+		for i := 0; i < max(len(currentSlice), len(desiredSlice)); i++ {
+			var c, d any
+			if i < len(currentSlice) {
+				c = currentSlice[i]
+			}
+			if i < len(desiredSlice) {
+				d = desiredSlice[i]
+			}
+			appendFieldChanges(fields, fmt.Sprintf("%s[%d]", path, i), c, d)
+		}
+		return
+	}
+
+	*fields = append(*fields, PlanFieldChange{Path: path, Old: current, New: desired})
+}
+
+// fieldPath appends key to path. Keys that would make the path ambiguous, like label names, are quoted.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]\"") || key == "" {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// planValue formats a field value for the text output of a plan
+func planValue(v any) string {
+	if v == nil {
+		return "<unset>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -25,6 +25,7 @@ import (
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"github.com/go-logr/logr"
 )
@@ -43,8 +44,12 @@ type Storer interface {
 	GetServiceV1(name, namespace string) (*corev1.Service, error)
 	GetNgrokIngressV1(name, namespace string) (*netv1.Ingress, error)
 	GetNgrokModuleSetV1(name, namespace string) (*ingressv1alpha1.NgrokModuleSet, error)
+	GetGatewayClass(name string) (*gatewayv1.GatewayClass, error)
 	GetGateway(name string, namespace string) (*gatewayv1.Gateway, error)
+	GetNgrokGateway(name string, namespace string) (*gatewayv1.Gateway, error)
 	GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute, error)
+	GetTLSRoute(name string, namespace string) (*gatewayv1alpha2.TLSRoute, error)
+	GetTCPRoute(name string, namespace string) (*gatewayv1alpha2.TCPRoute, error)
 
 	ListIngressClassesV1() []*netv1.IngressClass
 	ListNgrokIngressClassesV1() []*netv1.IngressClass
@@ -53,12 +58,16 @@ type Storer interface {
 	ListNgrokIngressesV1() []*netv1.Ingress
 
 	ListGateways() []*gatewayv1.Gateway
+	ListNgrokGateways() []*gatewayv1.Gateway
 	ListHTTPRoutes() []*gatewayv1.HTTPRoute
+	ListTLSRoutes() []*gatewayv1alpha2.TLSRoute
+	ListTCPRoutes() []*gatewayv1alpha2.TCPRoute
 
 	ListDomainsV1() []*ingressv1alpha1.Domain
 	ListTunnelsV1() []*ingressv1alpha1.Tunnel
 	ListHTTPSEdgesV1() []*ingressv1alpha1.HTTPSEdge
 	ListNgrokModuleSetsV1() []*ingressv1alpha1.NgrokModuleSet
+	ListClusterNgrokModuleSetsV1() []*ingressv1alpha1.ClusterNgrokModuleSet
 }
 
 // Store implements Storer and can be used to list Ingress, Services
@@ -163,6 +172,17 @@ func (s Store) GetNgrokModuleSetV1(name, namespace string) (*ingressv1alpha1.Ngr
 	return p.(*ingressv1alpha1.NgrokModuleSet), nil
 }
 
+func (s Store) GetGatewayClass(name string) (*gatewayv1.GatewayClass, error) {
+	class, exists, err := s.stores.GatewayClass.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("GatewayClass %v not found", name))
+	}
+	return class.(*gatewayv1.GatewayClass), nil
+}
+
 func (s Store) GetGateway(name string, namespace string) (*gatewayv1.Gateway, error) {
 	gtw, exists, err := s.stores.Gateway.GetByKey(getKey(name, namespace))
 	if err != nil {
@@ -174,6 +194,19 @@ func (s Store) GetGateway(name string, namespace string) (*gatewayv1.Gateway, er
 	return gtw.(*gatewayv1.Gateway), nil
 }
 
+// GetNgrokGateway looks up the Gateway resource by name and namespace and returns it if it's found
+// and its GatewayClass is handled by the ngrok gateway controller
+func (s Store) GetNgrokGateway(name string, namespace string) (*gatewayv1.Gateway, error) {
+	gtw, err := s.GetGateway(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isNgrokGateway(gtw) {
+		return nil, nil
+	}
+	return gtw, nil
+}
+
 func (s Store) GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute, error) {
 	obj, exists, err := s.stores.HTTPRoute.GetByKey(getKey(name, namespace))
 	if err != nil {
@@ -185,6 +218,28 @@ func (s Store) GetHTTPRoute(name string, namespace string) (*gatewayv1.HTTPRoute
 	return obj.(*gatewayv1.HTTPRoute), nil
 }
 
+func (s Store) GetTLSRoute(name string, namespace string) (*gatewayv1alpha2.TLSRoute, error) {
+	obj, exists, err := s.stores.TLSRoute.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("TLSRoute %v not found", name))
+	}
+	return obj.(*gatewayv1alpha2.TLSRoute), nil
+}
+
+func (s Store) GetTCPRoute(name string, namespace string) (*gatewayv1alpha2.TCPRoute, error) {
+	obj, exists, err := s.stores.TCPRoute.GetByKey(getKey(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewErrorNotFound(fmt.Sprintf("TCPRoute %v not found", name))
+	}
+	return obj.(*gatewayv1alpha2.TCPRoute), nil
+}
+
 // ListIngressClassesV1 returns the list of Ingresses in the Ingress v1 store.
 func (s Store) ListIngressClassesV1() []*netv1.IngressClass {
 	// filter ingress rules
@@ -263,6 +318,27 @@ func (s Store) ListGateways() []*gatewayv1.Gateway {
 	return gateways
 }
 
+// ListNgrokGateways returns the list of Gateways in the Gateway store filtered by ones whose
+// GatewayClass is handled by the ngrok gateway controller
+func (s Store) ListNgrokGateways() []*gatewayv1.Gateway {
+	filteredGateways := []*gatewayv1.Gateway{}
+	for _, gtw := range s.ListGateways() {
+		if s.isNgrokGateway(gtw) {
+			filteredGateways = append(filteredGateways, gtw)
+		}
+	}
+	return filteredGateways
+}
+
+// isNgrokGateway checks the Gateway's GatewayClass exists and is handled by the ngrok gateway controller
+func (s Store) isNgrokGateway(gtw *gatewayv1.Gateway) bool {
+	class, err := s.GetGatewayClass(string(gtw.Spec.GatewayClassName))
+	if err != nil {
+		return false
+	}
+	return class.Spec.ControllerName == GatewayControllerName
+}
+
 func (s Store) ListHTTPRoutes() []*gatewayv1.HTTPRoute {
 	var httproutes []*gatewayv1.HTTPRoute
 
@@ -279,6 +355,48 @@ func (s Store) ListHTTPRoutes() []*gatewayv1.HTTPRoute {
 	return httproutes
 }
 
+func (s Store) ListTLSRoutes() []*gatewayv1alpha2.TLSRoute {
+	var tlsroutes []*gatewayv1alpha2.TLSRoute
+
+	for _, item := range s.stores.TLSRoute.List() {
+		tlsroute, ok := item.(*gatewayv1alpha2.TLSRoute)
+		if !ok {
+			e := fmt.Sprintf("TLSRoute: dropping object of unexpected type: %#v", item)
+			s.log.Error(fmt.Errorf(e), e)
+			continue
+		}
+		tlsroutes = append(tlsroutes, tlsroute)
+	}
+
+	sort.SliceStable(tlsroutes, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", tlsroutes[i].Namespace, tlsroutes[i].Name),
+			fmt.Sprintf("%s/%s", tlsroutes[j].Namespace, tlsroutes[j].Name)) < 0
+	})
+
+	return tlsroutes
+}
+
+func (s Store) ListTCPRoutes() []*gatewayv1alpha2.TCPRoute {
+	var tcproutes []*gatewayv1alpha2.TCPRoute
+
+	for _, item := range s.stores.TCPRoute.List() {
+		tcproute, ok := item.(*gatewayv1alpha2.TCPRoute)
+		if !ok {
+			e := fmt.Sprintf("TCPRoute: dropping object of unexpected type: %#v", item)
+			s.log.Error(fmt.Errorf(e), e)
+			continue
+		}
+		tcproutes = append(tcproutes, tcproute)
+	}
+
+	sort.SliceStable(tcproutes, func(i, j int) bool {
+		return strings.Compare(fmt.Sprintf("%s/%s", tcproutes[i].Namespace, tcproutes[i].Name),
+			fmt.Sprintf("%s/%s", tcproutes[j].Namespace, tcproutes[j].Name)) < 0
+	})
+
+	return tcproutes
+}
+
 func (s Store) ListNgrokIngressesV1() []*netv1.Ingress {
 	ings := s.ListIngressesV1()
 
@@ -373,6 +491,25 @@ func (s Store) ListNgrokModuleSetsV1() []*ingressv1alpha1.NgrokModuleSet {
 	return modules
 }
 
+// ListClusterNgrokModuleSetsV1 returns the list of ClusterNgrokModuleSets in the store, sorted by name.
+func (s Store) ListClusterNgrokModuleSetsV1() []*ingressv1alpha1.ClusterNgrokModuleSet {
+	var modules []*ingressv1alpha1.ClusterNgrokModuleSet
+	for _, item := range s.stores.ClusterNgrokModuleV1.List() {
+		module, ok := item.(*ingressv1alpha1.ClusterNgrokModuleSet)
+		if !ok {
+			s.log.Info("listClusterNgrokModulesV1: dropping object of unexpected type: %#v", item)
+			continue
+		}
+		modules = append(modules, module)
+	}
+
+	sort.SliceStable(modules, func(i, j int) bool {
+		return strings.Compare(modules[i].Name, modules[j].Name) < 0
+	})
+
+	return modules
+}
+
 func (s Store) shouldHandleIngress(ing *netv1.Ingress) (bool, error) {
 	ok, err := s.shouldHandleIngressIsValid(ing)
 	if err != nil {
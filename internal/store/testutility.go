@@ -6,6 +6,7 @@ import (
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 func NewTestIngressClass(name string, isDefault bool, isNgrok bool) netv1.IngressClass {
@@ -33,6 +34,22 @@ func NewTestIngressClass(name string, isDefault bool, isNgrok bool) netv1.Ingres
 	return i
 }
 
+func NewTestGatewayClass(name string, isNgrok bool) gatewayv1.GatewayClass {
+	c := gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	if isNgrok {
+		c.Spec.ControllerName = GatewayControllerName
+	} else {
+		c.Spec.ControllerName = "example.com/gateway-other"
+	}
+
+	return c
+}
+
 func NewTestIngressV1WithClass(name string, namespace string, ingressClass string) netv1.Ingress {
 	i := NewTestIngressV1(name, namespace)
 	i.Spec.IngressClassName = &ingressClass
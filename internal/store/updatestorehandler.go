@@ -2,8 +2,10 @@ package store
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/go-logr/logr"
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -18,6 +20,8 @@ var _ handler.EventHandler = &UpdateStoreHandler{}
 // This handler takes a basic object and updates/deletes the store with it.
 // It is used to simply watch some resources and keep their values updated in the store.
 // It is used to keep various crds like edges/tunnels/domains, and core resources like ingress classes, updated.
+// Every change marks the driver dirty so the background sync loop picks it up, except status-only updates
+// to resources whose status the driver never reads.
 type UpdateStoreHandler struct {
 	client client.Client
 	driver *Driver
@@ -41,6 +45,7 @@ func (e *UpdateStoreHandler) Create(ctx context.Context, evt event.CreateEvent,
 		e.log.Error(err, "error updating object in create", "object", evt.Object)
 		return
 	}
+	e.driver.MarkDirty()
 }
 
 // Update is called in response to an update event -  e.g. Edge Updated.
@@ -49,10 +54,10 @@ func (e *UpdateStoreHandler) Update(ctx context.Context, evt event.UpdateEvent,
 		e.log.Error(err, "error updating object in update", "object", evt.ObjectNew)
 		return
 	}
-	if err := e.driver.updateIngressStatuses(ctx, e.client); err != nil {
-		e.log.Error(err, "error syncing after object update", "object", evt.ObjectNew)
+	if isIgnoredStatusUpdate(evt.ObjectOld, evt.ObjectNew) {
 		return
 	}
+	e.driver.MarkDirty()
 }
 
 // Delete is called in response to a delete event - e.g. Edge Deleted.
@@ -61,6 +66,7 @@ func (e *UpdateStoreHandler) Delete(ctx context.Context, evt event.DeleteEvent,
 		e.log.Error(err, "error deleting object", "object", evt.Object)
 		return
 	}
+	e.driver.MarkDirty()
 }
 
 // Generic is called in response to an event of an unknown type or a synthetic event triggered as a cron or
@@ -70,4 +76,22 @@ func (e *UpdateStoreHandler) Generic(ctx context.Context, evt event.GenericEvent
 		e.log.Error(err, "error updating object in generic", "object", evt.Object)
 		return
 	}
+	e.driver.MarkDirty()
+}
+
+// isIgnoredStatusUpdate returns true if the update only changed the status of a resource whose status
+// doesn't feed into the driver's sync. Tunnel agent status is reported periodically, and resyncing on
+// every report would rebuild all the ngrok resources for nothing.
+func isIgnoredStatusUpdate(oldObj, newObj client.Object) bool {
+	if _, ok := newObj.(*ingressv1alpha1.Tunnel); !ok {
+		return false
+	}
+	// Objects without a status subresource don't track a generation, so any update might be a spec change
+	if newObj.GetGeneration() == 0 || oldObj.GetGeneration() != newObj.GetGeneration() {
+		return false
+	}
+	return reflect.DeepEqual(oldObj.GetLabels(), newObj.GetLabels()) &&
+		reflect.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations()) &&
+		reflect.DeepEqual(oldObj.GetFinalizers(), newObj.GetFinalizers()) &&
+		oldObj.GetDeletionTimestamp().Equal(newObj.GetDeletionTimestamp())
 }
@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+// domainValidator rejects malformed domain names and domains that are already claimed by a Domain in another
+// namespace. ngrok only reserves a domain once, so the second Domain could never be reconciled.
+type domainValidator struct {
+	client client.Reader
+}
+
+func (v *domainValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(ctx, obj, nil)
+}
+
+func (v *domainValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(ctx, newObj, oldObj)
+}
+
+func (v *domainValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *domainValidator) validate(ctx context.Context, obj, oldObj runtime.Object) error {
+	domain, ok := obj.(*ingressv1alpha1.Domain)
+	if !ok {
+		return fmt.Errorf("expected a Domain but got a %T", obj)
+	}
+
+	path := field.NewPath("spec", "domain")
+	errs := validateDomainName(path, domain.Spec.Domain)
+
+	// Only check for duplicates when the domain changes so existing duplicates can still be updated and cleaned up
+	if old, ok := oldObj.(*ingressv1alpha1.Domain); !ok || old.Spec.Domain != domain.Spec.Domain {
+		domains := &ingressv1alpha1.DomainList{}
+		if err := v.client.List(ctx, domains); err != nil {
+			return err
+		}
+		for _, other := range domains.Items {
+			if other.Namespace != domain.Namespace && other.Spec.Domain == domain.Spec.Domain {
+				errs = append(errs, field.Invalid(path, domain.Spec.Domain, fmt.Sprintf("domain is already used by Domain %s/%s", other.Namespace, other.Name)))
+				break
+			}
+		}
+	}
+
+	return invalid(ingressv1alpha1.GroupVersion.WithKind("Domain").GroupKind(), domain, errs)
+}
+
+// validateDomainName checks that name is a valid DNS name, optionally with a leading wildcard label
+func validateDomainName(path *field.Path, name string) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(name, "*.")) {
+		errs = append(errs, field.Invalid(path, name, msg))
+	}
+	return errs
+}
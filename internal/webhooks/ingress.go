@@ -0,0 +1,260 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/circuit_breaker"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/oauth"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/oidc"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/parser"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/policy"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/saml"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/annotations/websocket_tcp_converter"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/errors"
+)
+
+// ingressValidator validates Ingresses that belong to one of the controller's IngressClasses. It checks that
+// the NgrokModuleSets they reference exist and can be combined, and that their hosts aren't already used by
+// an Ingress in another namespace.
+type ingressValidator struct {
+	client         client.Reader
+	controllerName string
+}
+
+func (v *ingressValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(ctx, obj, nil)
+}
+
+func (v *ingressValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(ctx, newObj, oldObj)
+}
+
+func (v *ingressValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ingressValidator) validate(ctx context.Context, obj, oldObj runtime.Object) error {
+	ing, ok := obj.(*netv1.Ingress)
+	if !ok {
+		return fmt.Errorf("expected an Ingress but got a %T", obj)
+	}
+
+	classes, err := v.ngrokIngressClasses(ctx)
+	if err != nil {
+		return err
+	}
+	if !isNgrokIngress(ing, classes) {
+		return nil
+	}
+
+	errs, err := v.validateModuleSets(ctx, ing)
+	if err != nil {
+		return err
+	}
+	pathErrs, err := v.validatePathModuleSets(ctx, ing)
+	if err != nil {
+		return err
+	}
+	errs = append(errs, pathErrs...)
+	errs = append(errs, validateModuleAnnotations(ing)...)
+
+	old, _ := oldObj.(*netv1.Ingress)
+	hostErrs, err := v.validateHosts(ctx, ing, old, classes)
+	if err != nil {
+		return err
+	}
+	errs = append(errs, hostErrs...)
+
+	return invalid(netv1.SchemeGroupVersion.WithKind("Ingress").GroupKind(), ing, errs)
+}
+
+// ngrokIngressClasses returns the IngressClasses handled by the controller
+func (v *ingressValidator) ngrokIngressClasses(ctx context.Context) ([]netv1.IngressClass, error) {
+	classes := &netv1.IngressClassList{}
+	if err := v.client.List(ctx, classes); err != nil {
+		return nil, err
+	}
+
+	var ngrokClasses []netv1.IngressClass
+	for _, class := range classes.Items {
+		if class.Spec.Controller == v.controllerName {
+			ngrokClasses = append(ngrokClasses, class)
+		}
+	}
+	return ngrokClasses, nil
+}
+
+// isNgrokIngress returns true if the Ingress uses one of classes, or doesn't set a class and one of classes
+// is the default. This matches how the store decides which Ingresses to handle.
+func isNgrokIngress(ing *netv1.Ingress, classes []netv1.IngressClass) bool {
+	for _, class := range classes {
+		if ing.Spec.IngressClassName != nil {
+			if *ing.Spec.IngressClassName == class.Name {
+				return true
+			}
+		} else if class.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateModuleSets checks that all of the NgrokModuleSets in the modules annotation exist in the Ingress's
+// namespace and that, once merged, they don't configure more than one authentication module
+func (v *ingressValidator) validateModuleSets(ctx context.Context, ing *netv1.Ingress) (field.ErrorList, error) {
+	path := field.NewPath("metadata", "annotations").Key(parser.GetAnnotationWithPrefix("modules"))
+
+	names, err := annotations.ExtractNgrokModuleSetsFromAnnotations(ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return nil, nil
+		}
+		return field.ErrorList{field.Invalid(path, ing.Annotations[parser.GetAnnotationWithPrefix("modules")], err.Error())}, nil
+	}
+
+	var errs field.ErrorList
+	merged := &ingressv1alpha1.NgrokModuleSet{}
+	for _, name := range names {
+		ms := &ingressv1alpha1.NgrokModuleSet{}
+		if err := v.client.Get(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: name}, ms); err != nil {
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, field.NotFound(path, name))
+				continue
+			}
+			return nil, err
+		}
+		merged.Merge(ms)
+	}
+
+	errs = append(errs, validateAuthModules(path, merged.Modules.OAuth, merged.Modules.OIDC, merged.Modules.SAML)...)
+	return errs, nil
+}
+
+// validatePathModuleSets checks that every path in the path-modules annotation is one of the Ingress's paths and
+// that the NgrokModuleSets for them exist in the Ingress's namespace
+func (v *ingressValidator) validatePathModuleSets(ctx context.Context, ing *netv1.Ingress) (field.ErrorList, error) {
+	key := parser.GetAnnotationWithPrefix("path-modules")
+	path := field.NewPath("metadata", "annotations").Key(key)
+
+	pathModuleSets, err := annotations.ExtractPathNgrokModuleSetsFromAnnotations(ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return nil, nil
+		}
+		return field.ErrorList{field.Invalid(path, ing.Annotations[key], err.Error())}, nil
+	}
+
+	ingressPaths := map[string]bool{}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			ingressPaths[p.Path] = true
+			ingressPaths[rule.Host+p.Path] = true
+		}
+	}
+
+	var errs field.ErrorList
+	for _, ingressPath := range sets.List(sets.KeySet(pathModuleSets)) {
+		if !ingressPaths[ingressPath] {
+			errs = append(errs, field.Invalid(path, ingressPath, "not a path of the Ingress's rules"))
+		}
+		for _, name := range pathModuleSets[ingressPath] {
+			ms := &ingressv1alpha1.NgrokModuleSet{}
+			if err := v.client.Get(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: name}, ms); err != nil {
+				if apierrors.IsNotFound(err) {
+					errs = append(errs, field.NotFound(path, name))
+					continue
+				}
+				return nil, err
+			}
+		}
+	}
+	return errs, nil
+}
+
+// validateModuleAnnotations checks that the annotations configuring route modules can be parsed and that at
+// most one of the OAuth, OIDC and SAML annotations is set. The controller only logs annotations it can't
+// parse, so this is where users see the error.
+func validateModuleAnnotations(ing *netv1.Ingress) field.ErrorList {
+	path := field.NewPath("metadata", "annotations")
+
+	var errs field.ErrorList
+	parse := func(name string, p parser.IngressAnnotation) interface{} {
+		val, err := p.Parse(ing)
+		if err != nil {
+			if !errors.IsMissingAnnotations(err) {
+				key := parser.GetAnnotationWithPrefix(name)
+				errs = append(errs, field.Invalid(path.Key(key), ing.Annotations[key], err.Error()))
+			}
+			return nil
+		}
+		return val
+	}
+
+	oauthModule, _ := parse("oauth-provider", oauth.NewParser()).(*ingressv1alpha1.EndpointOAuth)
+	oidcModule, _ := parse("oidc-issuer", oidc.NewParser()).(*ingressv1alpha1.EndpointOIDC)
+	samlModule, _ := parse("saml-idp-metadata", saml.NewParser()).(*ingressv1alpha1.EndpointSAML)
+	parse("circuit-breaker-error-threshold", circuit_breaker.NewParser())
+	parse("policy", policy.NewParser())
+	parse("websocket-tcp-converter", websocket_tcp_converter.NewParser())
+
+	return append(errs, validateAuthModules(path, oauthModule, oidcModule, samlModule)...)
+}
+
+// validateHosts checks that none of the Ingress's hosts are used by an ngrok Ingress in another namespace.
+// Ingresses in the same namespace can share a host, their rules are merged into a single edge. On update only
+// the added hosts are checked, so existing conflicts don't block unrelated changes.
+func (v *ingressValidator) validateHosts(ctx context.Context, ing, old *netv1.Ingress, classes []netv1.IngressClass) (field.ErrorList, error) {
+	existingHosts := map[string]bool{}
+	if old != nil {
+		for _, rule := range old.Spec.Rules {
+			existingHosts[rule.Host] = true
+		}
+	}
+
+	newHosts := map[string]int{}
+	for i, rule := range ing.Spec.Rules {
+		if rule.Host != "" && !existingHosts[rule.Host] {
+			newHosts[rule.Host] = i
+		}
+	}
+	if len(newHosts) == 0 {
+		return nil, nil
+	}
+
+	ingresses := &netv1.IngressList{}
+	if err := v.client.List(ctx, ingresses); err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	for _, other := range ingresses.Items {
+		if other.Namespace == ing.Namespace || !isNgrokIngress(&other, classes) {
+			continue
+		}
+		for _, rule := range other.Spec.Rules {
+			i, ok := newHosts[rule.Host]
+			if !ok {
+				continue
+			}
+			errs = append(errs, field.Invalid(field.NewPath("spec", "rules").Index(i).Child("host"), rule.Host,
+				fmt.Sprintf("host is already used by Ingress %s/%s", other.Namespace, other.Name)))
+			delete(newHosts, rule.Host)
+		}
+	}
+	return errs, nil
+}
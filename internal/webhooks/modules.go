@@ -0,0 +1,128 @@
+package webhooks
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+// policyActionTypes are the action types ngrok accepts in a traffic policy rule
+var policyActionTypes = []string{
+	"add-headers",
+	"compress-response",
+	"custom-response",
+	"deny",
+	"jwt-validation",
+	"log",
+	"oauth",
+	"openid-connect",
+	"rate-limit",
+	"redirect",
+	"remove-headers",
+	"restrict-ips",
+	"url-rewrite",
+	"verify-webhook",
+}
+
+// oauthProviderNames are the OAuth providers supported by the ngrok OAuth module
+var oauthProviderNames = []string{"amazon", "facebook", "github", "gitlab", "google", "linkedin", "microsoft", "twitch"}
+
+// validateModules validates a set of route modules, such as the ones in an NgrokModuleSet
+func validateModules(path *field.Path, modules ingressv1alpha1.NgrokModuleSetModules) field.ErrorList {
+	errs := validateAuthModules(path, modules.OAuth, modules.OIDC, modules.SAML)
+	errs = append(errs, validatePolicy(path.Child("policy"), modules.Policy)...)
+	return errs
+}
+
+// validateAuthModules checks that at most one of the OAuth, OIDC, and SAML modules is configured, since an
+// ngrok edge route can only authenticate with one of them
+func validateAuthModules(path *field.Path, oauth *ingressv1alpha1.EndpointOAuth, oidc *ingressv1alpha1.EndpointOIDC, saml *ingressv1alpha1.EndpointSAML) field.ErrorList {
+	var errs field.ErrorList
+	var configured []string
+	if oauth != nil {
+		configured = append(configured, "oauth")
+		errs = append(errs, validateOAuth(path.Child("oauth"), oauth)...)
+	}
+	if oidc != nil {
+		configured = append(configured, "oidc")
+	}
+	if saml != nil {
+		configured = append(configured, "saml")
+	}
+
+	if len(configured) > 1 {
+		errs = append(errs, field.Forbidden(path, fmt.Sprintf("only one of oauth, oidc, or saml may be configured, found %s", strings.Join(configured, ", "))))
+	}
+	return errs
+}
+
+// validateOAuth checks that exactly one known provider is configured. Unknown providers are pruned from the
+// object by the API server, so they show up here as no provider at all.
+func validateOAuth(path *field.Path, oauth *ingressv1alpha1.EndpointOAuth) field.ErrorList {
+	providers := oauthProviders(oauth)
+	switch len(providers) {
+	case 0:
+		return field.ErrorList{field.Required(path, fmt.Sprintf("exactly one OAuth provider must be configured, one of %s", strings.Join(oauthProviderNames, ", ")))}
+	case 1:
+		return nil
+	default:
+		return field.ErrorList{field.Forbidden(path, fmt.Sprintf("only one OAuth provider may be configured, found %s", strings.Join(providers, ", ")))}
+	}
+}
+
+// oauthProviders returns the names of the providers configured in oauth
+func oauthProviders(oauth *ingressv1alpha1.EndpointOAuth) []string {
+	var providers []string
+	for _, provider := range []struct {
+		name string
+		set  bool
+	}{
+		{"amazon", oauth.Amazon != nil},
+		{"facebook", oauth.Facebook != nil},
+		{"github", oauth.Github != nil},
+		{"gitlab", oauth.Gitlab != nil},
+		{"google", oauth.Google != nil},
+		{"linkedin", oauth.Linkedin != nil},
+		{"microsoft", oauth.Microsoft != nil},
+		{"twitch", oauth.Twitch != nil},
+	} {
+		if provider.set {
+			providers = append(providers, provider.name)
+		}
+	}
+	return providers
+}
+
+// validatePolicy checks that every rule of the policy has at least one action and that all of the actions
+// are of a type ngrok supports
+func validatePolicy(path *field.Path, policy *ingressv1alpha1.EndpointPolicy) field.ErrorList {
+	if policy == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	validateRules := func(path *field.Path, rules []ingressv1alpha1.EndpointRule) {
+		for i, rule := range rules {
+			rulePath := path.Index(i)
+			if len(rule.Actions) == 0 {
+				errs = append(errs, field.Required(rulePath.Child("actions"), "a policy rule must have at least one action"))
+			}
+			for j, action := range rule.Actions {
+				actionPath := rulePath.Child("actions").Index(j).Child("type")
+				switch {
+				case action.Type == "":
+					errs = append(errs, field.Required(actionPath, "the action type must be set"))
+				case !slices.Contains(policyActionTypes, action.Type):
+					errs = append(errs, field.NotSupported(actionPath, action.Type, policyActionTypes))
+				}
+			}
+		}
+	}
+	validateRules(path.Child("inbound"), policy.Inbound)
+	validateRules(path.Child("outbound"), policy.Outbound)
+	return errs
+}
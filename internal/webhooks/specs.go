@@ -0,0 +1,47 @@
+package webhooks
+
+import (
+	"net"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+func validateHTTPSEdge(edge *ingressv1alpha1.HTTPSEdge) field.ErrorList {
+	var errs field.ErrorList
+	routesPath := field.NewPath("spec", "routes")
+	for i, route := range edge.Spec.Routes {
+		path := routesPath.Index(i)
+		errs = append(errs, validateAuthModules(path, route.OAuth, route.OIDC, route.SAML)...)
+		errs = append(errs, validatePolicy(path.Child("policy"), route.Policy)...)
+	}
+	return errs
+}
+
+func validateTCPEdge(edge *ingressv1alpha1.TCPEdge) field.ErrorList {
+	return validatePolicy(field.NewPath("spec", "policy"), edge.Spec.Policy)
+}
+
+func validateTLSEdge(edge *ingressv1alpha1.TLSEdge) field.ErrorList {
+	return validatePolicy(field.NewPath("spec", "policy"), edge.Spec.Policy)
+}
+
+func validateIPPolicy(policy *ingressv1alpha1.IPPolicy) field.ErrorList {
+	var errs field.ErrorList
+	rulesPath := field.NewPath("spec", "rules")
+	for i, rule := range policy.Spec.Rules {
+		if _, _, err := net.ParseCIDR(rule.CIDR); err != nil {
+			errs = append(errs, field.Invalid(rulesPath.Index(i).Child("cidr"), rule.CIDR, "must be a valid CIDR such as 10.0.0.0/8 or 2001:db8::/32"))
+		}
+	}
+	return errs
+}
+
+func validateNgrokModuleSet(ms *ingressv1alpha1.NgrokModuleSet) field.ErrorList {
+	return validateModules(field.NewPath("modules"), ms.Modules)
+}
+
+func validateClusterNgrokModuleSet(ms *ingressv1alpha1.ClusterNgrokModuleSet) field.ErrorList {
+	return validateModules(field.NewPath("modules"), ms.Modules)
+}
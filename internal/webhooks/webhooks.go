@@ -0,0 +1,86 @@
+// Package webhooks contains the validating admission webhooks for the ngrok CRDs and for Ingresses of the
+// controller's class. They reject specs the ngrok API would refuse, so the error is reported when the object is
+// applied instead of surfacing later as a failed reconcile.
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+// SetupWithManager registers the validating webhooks with the manager's webhook server. Ingresses that don't
+// belong to one of controllerName's IngressClasses are always allowed.
+func SetupWithManager(mgr ctrl.Manager, controllerName string) error {
+	webhooks := []struct {
+		obj       client.Object
+		validator admission.CustomValidator
+	}{
+		{&ingressv1alpha1.HTTPSEdge{}, newSpecValidator("HTTPSEdge", validateHTTPSEdge)},
+		{&ingressv1alpha1.TCPEdge{}, newSpecValidator("TCPEdge", validateTCPEdge)},
+		{&ingressv1alpha1.TLSEdge{}, newSpecValidator("TLSEdge", validateTLSEdge)},
+		{&ingressv1alpha1.IPPolicy{}, newSpecValidator("IPPolicy", validateIPPolicy)},
+		{&ingressv1alpha1.NgrokModuleSet{}, newSpecValidator("NgrokModuleSet", validateNgrokModuleSet)},
+		{&ingressv1alpha1.ClusterNgrokModuleSet{}, newSpecValidator("ClusterNgrokModuleSet", validateClusterNgrokModuleSet)},
+		{&ingressv1alpha1.Domain{}, &domainValidator{client: mgr.GetClient()}},
+		{&netv1.Ingress{}, &ingressValidator{client: mgr.GetClient(), controllerName: controllerName}},
+	}
+
+	for _, w := range webhooks {
+		if err := ctrl.NewWebhookManagedBy(mgr).For(w.obj).WithValidator(w.validator).Complete(); err != nil {
+			return fmt.Errorf("unable to create webhook for %T: %w", w.obj, err)
+		}
+	}
+	return nil
+}
+
+// specValidator validates objects whose validity only depends on their own spec
+type specValidator[T client.Object] struct {
+	kind     schema.GroupKind
+	validate func(T) field.ErrorList
+}
+
+func newSpecValidator[T client.Object](kind string, validate func(T) field.ErrorList) *specValidator[T] {
+	return &specValidator[T]{
+		kind:     ingressv1alpha1.GroupVersion.WithKind(kind).GroupKind(),
+		validate: validate,
+	}
+}
+
+func (v *specValidator[T]) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validateObject(obj)
+}
+
+func (v *specValidator[T]) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validateObject(newObj)
+}
+
+func (v *specValidator[T]) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *specValidator[T]) validateObject(obj runtime.Object) error {
+	o, ok := obj.(T)
+	if !ok {
+		return fmt.Errorf("expected a %s but got a %T", v.kind.Kind, obj)
+	}
+	return invalid(v.kind, o, v.validate(o))
+}
+
+// invalid returns an Invalid API error for obj listing errs, or nil if there are none
+func invalid(kind schema.GroupKind, obj client.Object, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(kind, obj.GetName(), errs)
+}
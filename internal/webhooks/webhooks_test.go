@@ -0,0 +1,279 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+)
+
+func newFakeClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ingressv1alpha1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestIPPolicyRejectsMalformedCIDRs(t *testing.T) {
+	v := newSpecValidator("IPPolicy", validateIPPolicy)
+	policy := &ingressv1alpha1.IPPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+		Spec: ingressv1alpha1.IPPolicySpec{
+			Rules: []ingressv1alpha1.IPPolicyRule{
+				{CIDR: "10.0.0.0/8", Action: "allow"},
+				{CIDR: "2001:db8::/32", Action: "allow"},
+				{CIDR: "10.0.0.1", Action: "deny"},
+			},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), policy)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.ErrorContains(t, err, "spec.rules[2].cidr")
+	assert.NotContains(t, err.Error(), "spec.rules[0]")
+
+	policy.Spec.Rules = policy.Spec.Rules[:2]
+	_, err = v.ValidateUpdate(context.Background(), policy, policy)
+	assert.NoError(t, err)
+}
+
+func TestHTTPSEdgeRejectsConflictingAuthModules(t *testing.T) {
+	v := newSpecValidator("HTTPSEdge", validateHTTPSEdge)
+	edge := &ingressv1alpha1.HTTPSEdge{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge"},
+		Spec: ingressv1alpha1.HTTPSEdgeSpec{
+			Routes: []ingressv1alpha1.HTTPSEdgeRouteSpec{
+				{
+					Match: "/",
+					OAuth: &ingressv1alpha1.EndpointOAuth{Google: &ingressv1alpha1.EndpointOAuthGoogle{}},
+					OIDC:  &ingressv1alpha1.EndpointOIDC{Issuer: "https://example.com"},
+				},
+			},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), edge)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.ErrorContains(t, err, "only one of oauth, oidc, or saml may be configured, found oauth, oidc")
+
+	edge.Spec.Routes[0].OIDC = nil
+	_, err = v.ValidateCreate(context.Background(), edge)
+	assert.NoError(t, err)
+}
+
+func TestOAuthRequiresExactlyOneKnownProvider(t *testing.T) {
+	errs := validateOAuth(nil, &ingressv1alpha1.EndpointOAuth{})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "exactly one OAuth provider must be configured")
+
+	errs = validateOAuth(nil, &ingressv1alpha1.EndpointOAuth{
+		Github: &ingressv1alpha1.EndpointOAuthGitHub{},
+		Gitlab: &ingressv1alpha1.EndpointOAuthGitLab{},
+	})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "found github, gitlab")
+}
+
+func TestModuleSetRejectsInvalidPolicyActions(t *testing.T) {
+	v := newSpecValidator("NgrokModuleSet", validateNgrokModuleSet)
+	ms := &ingressv1alpha1.NgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "modules"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			Policy: &ingressv1alpha1.EndpointPolicy{
+				Inbound: []ingressv1alpha1.EndpointRule{
+					{Name: "ok", Actions: []ingressv1alpha1.EndpointAction{{Type: "deny"}}},
+					{Name: "bad", Actions: []ingressv1alpha1.EndpointAction{{Type: "explode"}}},
+				},
+				Outbound: []ingressv1alpha1.EndpointRule{
+					{Name: "empty"},
+				},
+			},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), ms)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.ErrorContains(t, err, `modules.policy.inbound[1].actions[0].type: Unsupported value: "explode"`)
+	assert.ErrorContains(t, err, "modules.policy.outbound[0].actions: Required value")
+	assert.NotContains(t, err.Error(), "inbound[0]")
+}
+
+func TestClusterModuleSetRejectsConflictingAuthModules(t *testing.T) {
+	v := newSpecValidator("ClusterNgrokModuleSet", validateClusterNgrokModuleSet)
+	ms := &ingressv1alpha1.ClusterNgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sso"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			OAuth: &ingressv1alpha1.EndpointOAuth{Google: &ingressv1alpha1.EndpointOAuthGoogle{}},
+			OIDC:  &ingressv1alpha1.EndpointOIDC{Issuer: "https://accounts.example.com"},
+		},
+		Enforced: true,
+	}
+
+	_, err := v.ValidateCreate(context.Background(), ms)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.ErrorContains(t, err, "found oauth, oidc")
+}
+
+func TestDomainRejectsDuplicatesInOtherNamespaces(t *testing.T) {
+	existing := &ingressv1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "other"},
+		Spec:       ingressv1alpha1.DomainSpec{Domain: "example.com"},
+	}
+	v := &domainValidator{client: newFakeClient(existing)}
+
+	domain := &ingressv1alpha1.Domain{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "default"},
+		Spec:       ingressv1alpha1.DomainSpec{Domain: "example.com"},
+	}
+	_, err := v.ValidateCreate(context.Background(), domain)
+	assert.ErrorContains(t, err, "domain is already used by Domain other/example-com")
+
+	// Existing duplicates can still be updated
+	_, err = v.ValidateUpdate(context.Background(), domain, domain)
+	assert.NoError(t, err)
+
+	domain.Spec.Domain = "*.example.com"
+	_, err = v.ValidateCreate(context.Background(), domain)
+	assert.NoError(t, err)
+
+	domain.Spec.Domain = "not_a_domain"
+	_, err = v.ValidateCreate(context.Background(), domain)
+	assert.ErrorContains(t, err, "spec.domain")
+}
+
+func TestIngressValidation(t *testing.T) {
+	ngrokClass := &netv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "ngrok"},
+		Spec:       netv1.IngressClassSpec{Controller: "k8s.ngrok.com/ingress-controller"},
+	}
+	otherClass := &netv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec:       netv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+	}
+	newIngress := func(namespace, name, class, host string) *netv1.Ingress {
+		return &netv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: netv1.IngressSpec{
+				IngressClassName: ptr.To(class),
+				Rules:            []netv1.IngressRule{{Host: host}},
+			},
+		}
+	}
+	oauth := &ingressv1alpha1.NgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth", Namespace: "default"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			OAuth: &ingressv1alpha1.EndpointOAuth{Google: &ingressv1alpha1.EndpointOAuthGoogle{}},
+		},
+	}
+	saml := &ingressv1alpha1.NgrokModuleSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "saml", Namespace: "default"},
+		Modules: ingressv1alpha1.NgrokModuleSetModules{
+			SAML: &ingressv1alpha1.EndpointSAML{IdPMetadata: "<xml/>"},
+		},
+	}
+
+	v := &ingressValidator{
+		client: newFakeClient(
+			ngrokClass, otherClass, oauth, saml,
+			newIngress("other", "taken", "ngrok", "taken.example.com"),
+			newIngress("other", "nginx", "nginx", "nginx.example.com"),
+		),
+		controllerName: "k8s.ngrok.com/ingress-controller",
+	}
+	ctx := context.Background()
+
+	t.Run("allows ingresses of other classes", func(t *testing.T) {
+		ing := newIngress("default", "test", "nginx", "taken.example.com")
+		ing.Annotations = map[string]string{"k8s.ngrok.com/modules": "missing"}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects unknown module sets", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "test.example.com")
+		ing.Annotations = map[string]string{"k8s.ngrok.com/modules": "oauth,missing"}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.ErrorContains(t, err, `metadata.annotations[k8s.ngrok.com/modules]: Not found: "missing"`)
+	})
+
+	t.Run("rejects module sets with conflicting auth modules", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "test.example.com")
+		ing.Annotations = map[string]string{"k8s.ngrok.com/modules": "oauth,saml"}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.ErrorContains(t, err, "found oauth, saml")
+	})
+
+	t.Run("rejects path module sets for unknown paths or module sets", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "test.example.com")
+		ing.Spec.Rules[0].HTTP = &netv1.HTTPIngressRuleValue{Paths: []netv1.HTTPIngressPath{{Path: "/api"}}}
+		ing.Annotations = map[string]string{"k8s.ngrok.com/path-modules": "/api: oauth\ntest.example.com/api: saml"}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.NoError(t, err)
+
+		ing.Annotations = map[string]string{"k8s.ngrok.com/path-modules": "/admin: oauth\n/api: missing"}
+		_, err = v.ValidateCreate(ctx, ing)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.ErrorContains(t, err, `metadata.annotations[k8s.ngrok.com/path-modules]: Invalid value: "/admin"`)
+		assert.ErrorContains(t, err, `metadata.annotations[k8s.ngrok.com/path-modules]: Not found: "missing"`)
+	})
+
+	t.Run("rejects invalid or conflicting auth annotations", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "test.example.com")
+		ing.Annotations = map[string]string{"k8s.ngrok.com/oauth-provider": "myspace"}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.ErrorContains(t, err, `metadata.annotations[k8s.ngrok.com/oauth-provider]: Invalid value: "myspace"`)
+
+		ing.Annotations = map[string]string{
+			"k8s.ngrok.com/oauth-provider":    "google",
+			"k8s.ngrok.com/saml-idp-metadata": "<xml/>",
+		}
+		_, err = v.ValidateCreate(ctx, ing)
+		assert.ErrorContains(t, err, "found oauth, saml")
+
+		ing.Annotations = map[string]string{"k8s.ngrok.com/oauth-provider": "google"}
+		_, err = v.ValidateCreate(ctx, ing)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects invalid circuit breaker and policy annotations", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "test.example.com")
+		ing.Annotations = map[string]string{
+			"k8s.ngrok.com/circuit-breaker-error-threshold": "50",
+			"k8s.ngrok.com/policy":                          "inbound: [",
+		}
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.ErrorContains(t, err, "metadata.annotations[k8s.ngrok.com/circuit-breaker-error-threshold]")
+		assert.ErrorContains(t, err, "metadata.annotations[k8s.ngrok.com/policy]")
+	})
+
+	t.Run("rejects hosts used in other namespaces", func(t *testing.T) {
+		ing := newIngress("default", "test", "ngrok", "taken.example.com")
+		_, err := v.ValidateCreate(ctx, ing)
+		assert.ErrorContains(t, err, "host is already used by Ingress other/taken")
+
+		// Existing conflicts don't block updates
+		_, err = v.ValidateUpdate(ctx, ing, ing)
+		assert.NoError(t, err)
+
+		// Hosts of Ingresses handled by other controllers don't conflict
+		_, err = v.ValidateCreate(ctx, newIngress("default", "test", "ngrok", "nginx.example.com"))
+		assert.NoError(t, err)
+
+		// Ingresses in the same namespace can share hosts
+		_, err = v.ValidateCreate(ctx, newIngress("other", "test", "ngrok", "taken.example.com"))
+		assert.NoError(t, err)
+	})
+}
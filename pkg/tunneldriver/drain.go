@@ -0,0 +1,147 @@
+package tunneldriver
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"golang.ngrok.com/ngrok"
+)
+
+// servedTunnel is an ngrok tunnel along with the connections it accepted that are still open, so they can be
+// drained when the tunnel is stopped
+type servedTunnel struct {
+	ngrok.Tunnel
+	conns connSet
+}
+
+func newServedTunnel(tun ngrok.Tunnel) *servedTunnel {
+	return &servedTunnel{Tunnel: tun}
+}
+
+// connSet tracks open connections so they can be waited on, and closed if they don't finish in time
+type connSet struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	// drained is closed once the set is empty after draining started, nil until then
+	drained chan struct{}
+}
+
+func (s *connSet) add(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	s.conns[conn] = struct{}{}
+}
+
+func (s *connSet) remove(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	if len(s.conns) == 0 && s.drained != nil {
+		select {
+		case <-s.drained:
+		default:
+			close(s.drained)
+		}
+	}
+}
+
+// len returns the number of open connections
+func (s *connSet) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// drain waits for every connection to close or ctx to be done, whichever comes first, and then closes the
+// connections still open. It returns how many connections had to be closed.
+func (s *connSet) drain(ctx context.Context) int {
+	s.mu.Lock()
+	if s.drained == nil {
+		s.drained = make(chan struct{})
+		if len(s.conns) == 0 {
+			close(s.drained)
+		}
+	}
+	drained := s.drained
+	s.mu.Unlock()
+
+	select {
+	case <-drained:
+		return 0
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	closed := 0
+	for conn := range s.conns {
+		//nolint:errcheck
+		conn.Close()
+		closed++
+	}
+	return closed
+}
+
+// drainTunnel stops tun from accepting connections, then lets the connections it already accepted finish for
+// up to the drain timeout before closing them. Closing an ngrok tunnel doesn't close the connections it
+// accepted, those are only cut when the session closes.
+func (td *TunnelDriver) drainTunnel(tun *servedTunnel) {
+	if tun == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), td.opts.DrainTimeout)
+	defer cancel()
+	if err := td.stopTunnel(ctx, tun); err != nil {
+		td.logger.Error(err, "error closing tunnel, draining its connections anyway", "id", tun.ID())
+	}
+	td.drainConnections(ctx, tun)
+}
+
+// drainConnections lets the connections of a tunnel that was already closed finish until ctx is done, then
+// closes the ones still open
+func (td *TunnelDriver) drainConnections(ctx context.Context, tun *servedTunnel) {
+	if tun == nil {
+		return
+	}
+	logger := td.logger.WithValues("id", tun.ID())
+	if n := tun.conns.len(); n > 0 {
+		logger.Info("Draining tunnel connections", "connections", n, "timeout", td.opts.DrainTimeout)
+	}
+	if closed := tun.conns.drain(ctx); closed > 0 {
+		logger.Info("Closed tunnel connections still open after the drain timeout", "connections", closed)
+	}
+}
+
+// shutdown stops the driver when the manager stops. Every tunnel stops accepting connections and is drained,
+// then the session is closed.
+func (td *TunnelDriver) shutdown() {
+	td.mu.Lock()
+	td.stopped = true
+	tuns := make([]*servedTunnel, 0, len(td.tunnels))
+	for _, state := range td.tunnels {
+		tuns = append(tuns, state.tun)
+	}
+	td.tunnels = make(map[string]*tunnelState)
+	session := td.session
+	td.mu.Unlock()
+
+	td.logger.Info("Draining tunnels before shutting down", "tunnels", len(tuns), "timeout", td.opts.DrainTimeout)
+	var wg sync.WaitGroup
+	for _, tun := range tuns {
+		wg.Add(1)
+		go func(tun *servedTunnel) {
+			defer wg.Done()
+			td.drainTunnel(tun)
+		}(tun)
+	}
+	wg.Wait()
+
+	if err := session.Close(); err != nil {
+		td.logger.Error(err, "error closing ngrok session")
+	}
+}
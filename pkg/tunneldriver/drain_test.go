@@ -0,0 +1,110 @@
+package tunneldriver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/mocks"
+)
+
+func TestConnSetDrainWaitsForConnections(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	var conns connSet
+	conns.add(server)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		conns.remove(server)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if closed := conns.drain(ctx); closed != 0 {
+		t.Errorf("expected no connections to be closed, got %d", closed)
+	}
+}
+
+func TestConnSetDrainClosesConnectionsAfterTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	var conns connSet
+	conns.add(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if closed := conns.drain(ctx); closed != 1 {
+		t.Errorf("expected the open connection to be closed, got %d", closed)
+	}
+	if _, err := server.Write([]byte("x")); err == nil {
+		t.Error("expected writes to a closed connection to fail")
+	}
+}
+
+func TestConnSetDrainWithoutConnections(t *testing.T) {
+	var conns connSet
+	if closed := conns.drain(context.Background()); closed != 0 {
+		t.Errorf("expected nothing to be closed, got %d", closed)
+	}
+}
+
+func TestShutdownDrainsTunnels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tun := mocks.NewMockTunnel(ctrl)
+	tun.EXPECT().ID().Return("tn_123").AnyTimes()
+	// The tunnel stops accepting connections before the session closes
+	tun.EXPECT().CloseWithContext(gomock.Any()).Return(nil)
+	closed := false
+	td := &TunnelDriver{
+		logger:  logr.Discard(),
+		opts:    TunnelDriverOpts{DrainTimeout: time.Second},
+		session: &fakeSession{close: func() error { closed = true; return nil }},
+		tunnels: map[string]*tunnelState{"default/tunnel": {tun: newServedTunnel(tun)}},
+	}
+
+	td.shutdown()
+	if !closed {
+		t.Error("expected the session to be closed")
+	}
+	if err := td.CreateTunnel(context.Background(), "default/other", ingressv1alpha1.TunnelSpec{}, nil); err == nil {
+		t.Error("expected no new tunnels to be started after shutdown")
+	}
+}
+
+func TestDeleteTunnelClosesTunnelOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tun := mocks.NewMockTunnel(ctrl)
+	tun.EXPECT().ID().Return("tn_123").AnyTimes()
+	tun.EXPECT().CloseWithContext(gomock.Any()).Return(nil).Times(1)
+
+	// A connection still open after the drain timeout is closed once draining is done
+	drained := make(chan struct{})
+	conn := mocks.NewMockConn(ctrl)
+	conn.EXPECT().Close().DoAndReturn(func() error {
+		close(drained)
+		return nil
+	})
+
+	served := newServedTunnel(tun)
+	served.conns.add(conn)
+	td := &TunnelDriver{
+		logger:  logr.Discard(),
+		opts:    TunnelDriverOpts{DrainTimeout: 10 * time.Millisecond},
+		tunnels: map[string]*tunnelState{"default/tunnel": {tun: served, stats: tunnelStats{metrics: newTunnelMetrics("default/tunnel", nil)}}},
+	}
+
+	if err := td.DeleteTunnel(context.Background(), "default/tunnel"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the tunnel's connections to be drained")
+	}
+}
@@ -5,10 +5,16 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
 	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
@@ -38,25 +44,142 @@ const (
 	// TODO: Make this configurable via helm and document it so users can
 	// use it for things like proxies
 	customCertsPath = "/etc/ssl/certs/ngrok/"
+
+	// authtokenPollInterval is how often the authtoken file is checked for a rotated authtoken
+	authtokenPollInterval = 10 * time.Second
+
+	// relistenMinBackoff and relistenMaxBackoff bound the delay between attempts to restart a tunnel that
+	// was closed by ngrok
+	relistenMinBackoff = time.Second
+	relistenMaxBackoff = 30 * time.Second
+
+	// sessionLivenessTimeout is how long the session can stay disconnected before the liveness check fails and
+	// the pod is restarted
+	sessionLivenessTimeout = 10 * time.Minute
+
+	// DefaultDrainTimeout is how long a stopped tunnel's connections are given to finish by default
+	DefaultDrainTimeout = 30 * time.Second
 )
 
 // TunnelDriver is a driver for creating and deleting ngrok tunnels
 type TunnelDriver struct {
+	logger   logr.Logger
+	opts     TunnelDriverOpts
+	connOpts []ngrok.ConnectOption
+
+	// tunnelLocks serializes creating and deleting each tunnel, so concurrent reconciles of the same tunnel
+	// don't race while reconciles of different tunnels, and their calls to ngrok, run in parallel
+	tunnelLocks keyedMutex
+
+	// mu guards the session and tunnels, which are read by the status reporter and readiness checks while they
+	// are reconciled. It's never held while calling ngrok.
+	mu      sync.Mutex
 	session ngrok.Session
-	tunnels map[string]ngrok.Tunnel
+	health  *sessionHealth
+	tunnels map[string]*tunnelState
+	// authtoken is the authtoken the session was started with. failedAuthtoken is the last authtoken a new
+	// session couldn't be started with, so it isn't retried every time the authtoken file is checked.
+	authtoken       string
+	failedAuthtoken string
+	// stopped is set once the driver starts shutting down, after which no new tunnels are started
+	stopped bool
+}
+
+// sessionHealth tracks whether a session is connected to ngrok. It's updated by the session's connect and
+// disconnect handlers.
+type sessionHealth struct {
+	mu        sync.Mutex
+	connected bool
+	lastError error
+	// disconnectedAt is when the session last went from connected to disconnected
+	disconnectedAt time.Time
+}
+
+func (h *sessionHealth) setConnected() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected = true
+	h.lastError = nil
+}
+
+func (h *sessionHealth) setDisconnected(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.connected || h.disconnectedAt.IsZero() {
+		h.disconnectedAt = time.Now()
+	}
+	h.connected = false
+	if err == nil {
+		err = errors.New("session closed")
+	}
+	h.lastError = err
+}
+
+// disconnectedFor returns how long the session has been disconnected, 0 if it's connected
+func (h *sessionHealth) disconnectedFor(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.connected || h.disconnectedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(h.disconnectedAt)
+}
+
+// check returns nil if the session is connected, or why it isn't
+func (h *sessionHealth) check() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.connected {
+		return nil
+	}
+	if h.lastError != nil {
+		return fmt.Errorf("ngrok session disconnected: %w", h.lastError)
+	}
+	return errors.New("ngrok session not connected")
+}
+
+// tunnelState is what the driver keeps for each tunnel name. It outlives the ngrok tunnel it was created for,
+// so when a tunnel is replaced its stats carry over and connections still draining from the old tunnel keep
+// being counted.
+type tunnelState struct {
+	// tun and spec are the running tunnel and the spec it was started with. They're guarded by the driver's mu.
+	tun  *servedTunnel
+	spec ingressv1alpha1.TunnelSpec
+
+	stats   tunnelStats
+	backend atomic.Pointer[backend]
+	// online is whether tun is accepting connections. It's false while a tunnel closed by ngrok is restarted.
+	// It's guarded by the driver's mu, like tun.
+	online bool
+}
+
+// backend is how connections accepted by a tunnel are forwarded. It's swapped in place when a tunnel's backend
+// settings change, so the ngrok tunnel doesn't need to be restarted.
+type backend struct {
+	dest string
+	// proxyProtocolVersion is the version of the PROXY protocol header sent to the backend, 0 if disabled
+	proxyProtocolVersion int
+	// tlsConfig is set for HTTPS backends
+	tlsConfig *tls.Config
 }
 
 // TunnelDriverOpts are options for creating a new TunnelDriver
 type TunnelDriverOpts struct {
 	ServerAddr string
 	Region     string
+	// AuthtokenFile is a file to read the authtoken from, usually a key of a mounted Secret. It's re-read
+	// periodically while the driver runs, and the session is restarted when the authtoken changes. If it's
+	// empty, the authtoken is read once from the NGROK_AUTHTOKEN environment variable.
+	AuthtokenFile string
+	// DrainTimeout is how long connections accepted by a tunnel are given to finish when the tunnel is replaced
+	// or deleted, or the driver shuts down, before they're closed. Defaults to DefaultDrainTimeout.
+	DrainTimeout time.Duration
 }
 
 // New creates and initializes a new TunnelDriver
 func New(logger logr.Logger, opts TunnelDriverOpts) (*TunnelDriver, error) {
 	connOpts := []ngrok.ConnectOption{
 		ngrok.WithClientInfo("ngrok-ingress-controller", version.GetVersion()),
-		ngrok.WithAuthtokenFromEnv(),
 		ngrok.WithLogger(k8sLogger{logger}),
 	}
 
@@ -77,14 +200,198 @@ func New(logger logr.Logger, opts TunnelDriverOpts) (*TunnelDriver, error) {
 		connOpts = append(connOpts, ngrok.WithCA(caCerts))
 	}
 
-	session, err := ngrok.Connect(context.Background(), connOpts...)
+	if opts.DrainTimeout == 0 {
+		opts.DrainTimeout = DefaultDrainTimeout
+	}
+
+	td := &TunnelDriver{
+		logger:   logger,
+		opts:     opts,
+		connOpts: connOpts,
+		tunnels:  make(map[string]*tunnelState),
+	}
+
+	authtoken, err := td.readAuthtoken()
 	if err != nil {
 		return nil, err
 	}
-	return &TunnelDriver{
-		session: session,
-		tunnels: make(map[string]ngrok.Tunnel),
-	}, nil
+	session, health, err := td.connect(authtoken)
+	if err != nil {
+		return nil, err
+	}
+	td.session = session
+	td.health = health
+	td.authtoken = authtoken
+	return td, nil
+}
+
+// readAuthtoken reads the authtoken from the authtoken file, or from the environment if there isn't one
+func (td *TunnelDriver) readAuthtoken() (string, error) {
+	if td.opts.AuthtokenFile == "" {
+		return os.Getenv("NGROK_AUTHTOKEN"), nil
+	}
+	b, err := os.ReadFile(td.opts.AuthtokenFile)
+	if err != nil {
+		return "", fmt.Errorf("reading authtoken file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// connect starts a new session with authtoken. It blocks until the session first connects. The returned
+// sessionHealth follows the session's connection state from then on.
+func (td *TunnelDriver) connect(authtoken string) (ngrok.Session, *sessionHealth, error) {
+	health := &sessionHealth{}
+	logger := td.logger
+	connOpts := append([]ngrok.ConnectOption{
+		ngrok.WithAuthtoken(authtoken),
+		ngrok.WithConnectHandler(func(_ context.Context, _ ngrok.Session) {
+			logger.Info("ngrok session connected")
+			health.setConnected()
+		}),
+		ngrok.WithDisconnectHandler(func(_ context.Context, _ ngrok.Session, err error) {
+			if err != nil {
+				logger.Error(err, "ngrok session disconnected, reconnecting")
+			}
+			health.setDisconnected(err)
+		}),
+	}, td.connOpts...)
+
+	// The session lives until it's closed, so it can't be bound to a shorter context
+	session, err := ngrok.Connect(context.Background(), connOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, health, nil
+}
+
+// Ready is a readiness check that fails while the ngrok session is disconnected. ngrok reconnects the session
+// and restarts its tunnels on its own, so this only reports the outage.
+func (td *TunnelDriver) Ready(_ *http.Request) error {
+	td.mu.Lock()
+	health := td.health
+	td.mu.Unlock()
+	return health.check()
+}
+
+// Live is a liveness check that fails once the ngrok session has been disconnected for longer than it takes to
+// ride out a network blip or an ngrok outage, so a session that's stuck gets a fresh start with the pod
+func (td *TunnelDriver) Live(_ *http.Request) error {
+	td.mu.Lock()
+	health := td.health
+	td.mu.Unlock()
+	if d := health.disconnectedFor(time.Now()); d > sessionLivenessTimeout {
+		return fmt.Errorf("ngrok session has been disconnected for %s", d.Round(time.Second))
+	}
+	return nil
+}
+
+// Start watches the authtoken file while the manager runs, and restarts the session with the new authtoken
+// when it's rotated. When the manager stops, it drains every tunnel before closing the session. It implements
+// manager.Runnable.
+func (td *TunnelDriver) Start(ctx context.Context) error {
+	defer td.shutdown()
+
+	if td.opts.AuthtokenFile == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(authtokenPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			td.checkAuthtoken(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every pod runs its own session
+func (td *TunnelDriver) NeedLeaderElection() bool {
+	return false
+}
+
+// checkAuthtoken restarts the session if the authtoken file has changed since the session was started
+func (td *TunnelDriver) checkAuthtoken(ctx context.Context) {
+	authtoken, err := td.readAuthtoken()
+	if err != nil {
+		td.logger.Error(err, "unable to check for a rotated authtoken")
+		return
+	}
+
+	td.mu.Lock()
+	unchanged := authtoken == td.authtoken || authtoken == td.failedAuthtoken
+	td.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	td.logger.Info("authtoken changed, restarting ngrok session")
+	if err := td.rotateSession(ctx, authtoken); err != nil {
+		td.logger.Error(err, "unable to start a session with the new authtoken, keeping the current session")
+		td.mu.Lock()
+		td.failedAuthtoken = authtoken
+		td.mu.Unlock()
+	}
+}
+
+// rotateSession starts a session with authtoken and moves every tunnel onto it before closing the current
+// session. Tunnels are started on the new session before being drained on the old one, so they keep accepting
+// connections throughout and the old session is only closed once its connections are done. A tunnel that
+// can't be moved is restarted once the old session is closed.
+func (td *TunnelDriver) rotateSession(ctx context.Context, authtoken string) error {
+	session, health, err := td.connect(authtoken)
+	if err != nil {
+		return err
+	}
+
+	td.mu.Lock()
+	if td.stopped {
+		td.mu.Unlock()
+		return session.Close()
+	}
+	oldSession := td.session
+	td.session = session
+	td.health = health
+	td.authtoken = authtoken
+	td.failedAuthtoken = ""
+	tunnels := maps.Clone(td.tunnels)
+	td.mu.Unlock()
+
+	var drained sync.WaitGroup
+	for name, state := range tunnels {
+		td.mu.Lock()
+		oldTun, spec := state.tun, state.spec
+		td.mu.Unlock()
+
+		tun, err := session.Listen(ctx, td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol))
+		if err != nil {
+			td.logger.Error(err, "unable to move tunnel to the new session", "name", name)
+			continue
+		}
+		served := newServedTunnel(tun)
+		if !td.replaceTunnel(name, state, oldTun, served) {
+			//nolint:errcheck
+			td.stopTunnel(ctx, served)
+			continue
+		}
+		go td.serve(ctx, name, state, served)
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			td.drainTunnel(oldTun)
+		}()
+	}
+
+	go func() {
+		drained.Wait()
+		if err := oldSession.Close(); err != nil {
+			td.logger.Error(err, "error closing the previous ngrok session")
+		}
+	}()
+	return nil
 }
 
 // caCerts combines the system ca certs with a directory of custom ca certs
@@ -122,55 +429,183 @@ func caCerts() (*x509.CertPool, error) {
 }
 
 // CreateTunnel creates and starts a new tunnel in a goroutine. If a tunnel with the same name already exists,
-// it will be stopped and replaced with a new tunnel unless the labels match.
-func (td *TunnelDriver) CreateTunnel(ctx context.Context, name string, spec ingressv1alpha1.TunnelSpec) error {
+// it will be stopped and replaced with a new tunnel unless its labels, forwardsTo and appProtocol match, in which
+// case only the way connections are forwarded to the backend is updated. backendTLS configures connections to
+// HTTPS backends, without it the backend's certificate isn't verified. It's safe to call concurrently, calls for
+// the same name wait on each other.
+func (td *TunnelDriver) CreateTunnel(ctx context.Context, name string, spec ingressv1alpha1.TunnelSpec, backendTLS *tls.Config) error {
 	log := log.FromContext(ctx)
+	defer td.tunnelLocks.lock(name)()
 
-	if tun, ok := td.tunnels[name]; ok {
-		if maps.Equal(tun.Labels(), spec.Labels) {
-			log.Info("Tunnel labels match existing tunnel, doing nothing")
-			return nil
-		}
-		// There is already a tunnel with this name, start the new one and defer closing the old one
-		//nolint:errcheck
-		defer td.stopTunnel(context.Background(), tun)
+	td.mu.Lock()
+	state, ok := td.tunnels[name]
+	var current ingressv1alpha1.TunnelSpec
+	if ok {
+		current = state.spec
+	}
+	stopped := td.stopped
+	td.mu.Unlock()
+	if stopped {
+		return errors.New("tunnel driver is shutting down")
 	}
+	if !ok {
+		state = &tunnelState{}
+	}
+	state.backend.Store(newBackend(spec, backendTLS))
 
-	tun, err := td.session.Listen(ctx, td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol))
+	if ok && maps.Equal(current.Labels, spec.Labels) && current.ForwardsTo == spec.ForwardsTo && current.AppProtocol == spec.AppProtocol {
+		log.Info("Tunnel matches existing tunnel, updated backend only")
+		return nil
+	}
+
+	td.mu.Lock()
+	session := td.session
+	td.mu.Unlock()
+	tun, err := session.Listen(ctx, td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol))
 	if err != nil {
 		return err
 	}
-	td.tunnels[name] = tun
-
-	protocol := ""
-	if spec.BackendConfig != nil {
-		protocol = spec.BackendConfig.Protocol
+	served := newServedTunnel(tun)
+	// Only register the tunnel's series once it's running, a tunnel that fails to start would otherwise
+	// report zeros forever since it's never deleted
+	if state.stats.metrics == nil {
+		state.stats.metrics = newTunnelMetrics(name, spec.Labels)
 	}
 
-	go handleConnections(ctx, &net.Dialer{}, tun, spec.ForwardsTo, protocol, spec.AppProtocol)
+	td.mu.Lock()
+	oldTun := state.tun
+	state.tun = served
+	state.spec = spec
+	state.online = true
+	td.tunnels[name] = state
+	td.mu.Unlock()
+
+	go td.serve(ctx, name, state, served)
+	// There was already a tunnel with this name, drain it now that the new one is running
+	if oldTun != nil {
+		go td.drainTunnel(oldTun)
+	}
 	return nil
 }
 
 // DeleteTunnel stops and deletes a tunnel
 func (td *TunnelDriver) DeleteTunnel(ctx context.Context, name string) error {
 	log := log.FromContext(ctx).WithValues("name", name)
+	defer td.tunnelLocks.lock(name)()
 
-	tun := td.tunnels[name]
-	if tun == nil {
+	// Forget the tunnel before stopping it, so it isn't restarted when it closes
+	td.mu.Lock()
+	state := td.tunnels[name]
+	delete(td.tunnels, name)
+	td.mu.Unlock()
+	if state == nil {
 		log.Info("Tunnel not found while trying to delete tunnel")
 		return nil
 	}
 
-	err := td.stopTunnel(ctx, tun)
+	err := td.stopTunnel(ctx, state.tun)
 	if err != nil {
+		td.mu.Lock()
+		if _, ok := td.tunnels[name]; !ok {
+			td.tunnels[name] = state
+		}
+		td.mu.Unlock()
 		return err
 	}
-	delete(td.tunnels, name)
+	// The tunnel no longer accepts connections, let the ones it already accepted finish in the background
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), td.opts.DrainTimeout)
+		defer cancel()
+		td.drainConnections(ctx, state.tun)
+	}()
+	state.stats.metrics.delete()
 	log.Info("Tunnel deleted successfully")
 	return nil
 }
 
-func (td *TunnelDriver) stopTunnel(ctx context.Context, tun ngrok.Tunnel) error {
+// TunnelStatus returns a snapshot of the state of the named tunnel, or false if the driver isn't running it
+func (td *TunnelDriver) TunnelStatus(name string) (TunnelStatus, bool) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	state, ok := td.tunnels[name]
+	if !ok {
+		return TunnelStatus{}, false
+	}
+	status := state.stats.snapshot(state.tun.ID())
+	status.Online = state.online && td.health.check() == nil
+	return status, true
+}
+
+// serve forwards connections accepted by tun until it's closed. If the driver didn't close it, because ngrok
+// stopped the tunnel on its side, it's restarted on the current session with a backoff until the driver
+// replaces or deletes it.
+func (td *TunnelDriver) serve(ctx context.Context, name string, state *tunnelState, tun *servedTunnel) {
+	logger := log.FromContext(ctx).WithValues("name", name)
+	dialer := &net.Dialer{}
+	backoff := relistenMinBackoff
+	for {
+		handleConnections(ctx, dialer, tun, state)
+
+		for {
+			if !td.markOffline(name, state, tun) {
+				return
+			}
+			logger.Info("Tunnel closed unexpectedly, restarting it", "id", tun.ID(), "delay", backoff)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			td.mu.Lock()
+			session, spec := td.session, state.spec
+			td.mu.Unlock()
+			listened, err := session.Listen(ctx, td.buildTunnelConfig(spec.Labels, spec.ForwardsTo, spec.AppProtocol))
+			if err != nil {
+				logger.Error(err, "Error restarting tunnel")
+				backoff = min(backoff*2, relistenMaxBackoff)
+				continue
+			}
+			newTun := newServedTunnel(listened)
+			if !td.replaceTunnel(name, state, tun, newTun) {
+				//nolint:errcheck
+				td.stopTunnel(context.Background(), newTun)
+				return
+			}
+			tun = newTun
+			backoff = relistenMinBackoff
+			break
+		}
+	}
+}
+
+// markOffline marks the tunnel running for name as offline if it's still tun. It returns false if the driver
+// has replaced or deleted tun.
+func (td *TunnelDriver) markOffline(name string, state *tunnelState, tun *servedTunnel) bool {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	if td.tunnels[name] != state || state.tun != tun {
+		return false
+	}
+	state.online = false
+	return true
+}
+
+// replaceTunnel swaps oldTun for newTun as the tunnel running for name, unless the driver has already replaced
+// or deleted oldTun
+func (td *TunnelDriver) replaceTunnel(name string, state *tunnelState, oldTun, newTun *servedTunnel) bool {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	if td.tunnels[name] != state || state.tun != oldTun {
+		return false
+	}
+	state.tun = newTun
+	state.online = true
+	return true
+}
+
+func (td *TunnelDriver) stopTunnel(ctx context.Context, tun *servedTunnel) error {
 	if tun == nil {
 		return nil
 	}
@@ -187,8 +622,41 @@ func (td *TunnelDriver) buildTunnelConfig(labels map[string]string, destination,
 	return config.LabeledTunnel(opts...)
 }
 
-func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, dest string, protocol string, appProtocol string) {
-	logger := log.FromContext(ctx).WithValues("id", tun.ID(), "protocol", protocol, "dest", dest)
+// newBackend builds the backend for a tunnel spec. HTTPS backends use a copy of tlsConfig with the server name,
+// renegotiation and ALPN settings filled in.
+func newBackend(spec ingressv1alpha1.TunnelSpec, tlsConfig *tls.Config) *backend {
+	b := &backend{dest: spec.ForwardsTo}
+	if spec.BackendConfig == nil {
+		return b
+	}
+	b.proxyProtocolVersion = spec.BackendConfig.ProxyProtocolVersion
+	if spec.BackendConfig.Protocol != "HTTPS" {
+		return b
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(spec.ForwardsTo)
+		if err != nil {
+			host = spec.ForwardsTo
+		}
+		tlsConfig.ServerName = host
+	}
+	tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
+	if spec.AppProtocol == "http2" {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	b.tlsConfig = tlsConfig
+	return b
+}
+
+func handleConnections(ctx context.Context, dialer Dialer, tun *servedTunnel, state *tunnelState) {
+	logger := log.FromContext(ctx).WithValues("id", tun.ID())
 	for {
 		conn, err := tun.Accept()
 		if err != nil {
@@ -201,12 +669,20 @@ func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, des
 			// that should be true.
 			return
 		}
-		connLogger := logger.WithValues("remoteAddr", conn.RemoteAddr())
+		b := state.backend.Load()
+		connLogger := logger.WithValues("remoteAddr", conn.RemoteAddr(), "dest", b.dest, "tls", b.tlsConfig != nil)
 		connLogger.Info("Accepted connection")
+		state.stats.connOpened()
+		tun.conns.add(conn)
+		accepted := time.Now()
 
 		go func() {
+			defer func() {
+				tun.conns.remove(conn)
+				state.stats.connClosed(time.Since(accepted))
+			}()
 			ctx := log.IntoContext(ctx, connLogger)
-			err := handleConn(ctx, dest, protocol, appProtocol, dialer, conn)
+			err := handleConn(ctx, &state.stats, b, dialer, conn)
 			if err == nil || errors.Is(err, net.ErrClosed) {
 				connLogger.Info("Connection closed")
 				return
@@ -217,30 +693,33 @@ func handleConnections(ctx context.Context, dialer Dialer, tun ngrok.Tunnel, des
 	}
 }
 
-func handleConn(ctx context.Context, dest string, protocol string, appProtocol string, dialer Dialer, conn net.Conn) error {
+func handleConn(ctx context.Context, stats *tunnelStats, b *backend, dialer Dialer, conn net.Conn) error {
 	log := log.FromContext(ctx)
-	next, err := dialer.DialContext(ctx, "tcp", dest)
+	dialStart := time.Now()
+	next, err := dialer.DialContext(ctx, "tcp", b.dest)
 	if err != nil {
+		stats.dialFailed(err, time.Since(dialStart))
 		return err
 	}
-
-	// Support HTTPS backends
-	if protocol == "HTTPS" {
-		host, _, err := net.SplitHostPort(dest)
-		if err != nil {
-			host = dest
+	backendAddr := next.RemoteAddr()
+	stats.dialSucceeded(backendAddr.String(), time.Since(dialStart))
+
+	// Tell the backend the client's address. This has to come before anything else, including the TLS handshake.
+	if b.proxyProtocolVersion != 0 {
+		header, err := proxyProtoHeader(b.proxyProtocolVersion, conn.RemoteAddr(), backendAddr)
+		if err == nil {
+			_, err = next.Write(header)
 		}
-		var nextProtos []string
-		if appProtocol == "http2" {
-			nextProtos = []string{"h2", "http/1.1"}
+		if err != nil {
+			//nolint:errcheck
+			next.Close()
+			return err
 		}
+	}
 
-		next = tls.Client(next, &tls.Config{
-			ServerName:         host,
-			InsecureSkipVerify: true,
-			Renegotiation:      tls.RenegotiateFreelyAsClient,
-			NextProtos:         nextProtos,
-		})
+	// Support HTTPS backends
+	if b.tlsConfig != nil {
+		next = tls.Client(next, b.tlsConfig)
 	}
 
 	var g errgroup.Group
@@ -251,7 +730,7 @@ func handleConn(ctx context.Context, dest string, protocol string, appProtocol s
 			}
 		}()
 
-		_, err := io.Copy(next, conn)
+		_, err := io.Copy(stats.countReceived(next), conn)
 		return err
 	})
 	g.Go(func() error {
@@ -261,7 +740,7 @@ func handleConn(ctx context.Context, dest string, protocol string, appProtocol s
 			}
 		}()
 
-		_, err := io.Copy(conn, next)
+		_, err := io.Copy(stats.countSent(conn), next)
 		return err
 	})
 	return g.Wait()
@@ -2,13 +2,25 @@ package tunneldriver
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	ingressv1alpha1 "github.com/ngrok/kubernetes-ingress-controller/api/ingress/v1alpha1"
 	"github.com/ngrok/kubernetes-ingress-controller/internal/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.ngrok.com/ngrok"
+	"golang.ngrok.com/ngrok/config"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestConnectionIsClosed(t *testing.T) {
@@ -30,6 +42,8 @@ func TestConnectionIsClosed(t *testing.T) {
 		// dial the backend
 		mockNgrokConn.EXPECT().RemoteAddr().Return(&net.TCPAddr{}),
 		mockDialer.EXPECT().DialContext(gomock.Any(), "tcp", "target:port").Return(mockBackendConn, nil),
+		// and record the address it resolved to
+		mockBackendConn.EXPECT().RemoteAddr().Return(&net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 8080}),
 	)
 
 	// both conns should receive a read, and if they EOF get closed.
@@ -45,8 +59,279 @@ func TestConnectionIsClosed(t *testing.T) {
 		select {}
 	}).AnyTimes()
 
-	go handleConnections(ctx, mockDialer, mockTun, "target:port", "", "")
+	state := &tunnelState{stats: tunnelStats{metrics: newTunnelMetrics("default/tunnel", nil)}}
+	defer state.stats.metrics.delete()
+	state.backend.Store(&backend{dest: "target:port"})
+	go handleConnections(ctx, mockDialer, newServedTunnel(mockTun), state)
 
 	bothClosed.Wait()
 	ctrl.Finish()
+
+	if accepted := testutil.ToFloat64(state.stats.metrics.accepted); accepted != 1 {
+		t.Errorf("expected 1 accepted connection, got %v", accepted)
+	}
+	status := state.stats.snapshot("tn_123")
+	if status.ForwardsToAddr != "10.0.0.1:8080" {
+		t.Errorf("expected forwardsTo to resolve to 10.0.0.1:8080, got %q", status.ForwardsToAddr)
+	}
+}
+
+func TestDialErrorIsRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDialer := mocks.NewMockDialer(ctrl)
+	mockNgrokConn := mocks.NewMockConn(ctrl)
+
+	dialErr := errors.New("connection refused")
+	mockDialer.EXPECT().DialContext(gomock.Any(), "tcp", "target:port").Return(nil, dialErr)
+
+	stats := &tunnelStats{}
+	err := handleConn(context.Background(), stats, &backend{dest: "target:port"}, mockDialer, mockNgrokConn)
+	if !errors.Is(err, dialErr) {
+		t.Fatalf("expected dial error, got %v", err)
+	}
+
+	status := stats.snapshot("tn_123")
+	if status.LastDialError != "connection refused" || status.LastDialErrorTime.IsZero() {
+		t.Errorf("expected dial error to be recorded, got %+v", status)
+	}
+	if status.ForwardsToAddr != "" {
+		t.Errorf("expected forwardsTo to be unresolved, got %q", status.ForwardsToAddr)
+	}
+}
+
+func TestNewBackendTLSConfig(t *testing.T) {
+	spec := ingressv1alpha1.TunnelSpec{
+		ForwardsTo:    "svc.default.svc.cluster.local:443",
+		BackendConfig: &ingressv1alpha1.BackendConfig{Protocol: "HTTPS"},
+		AppProtocol:   "http2",
+	}
+
+	// Without a TLS config the backend's certificate isn't verified
+	b := newBackend(spec, nil)
+	if b.tlsConfig == nil || !b.tlsConfig.InsecureSkipVerify {
+		t.Fatalf("expected an unverified TLS config, got %+v", b.tlsConfig)
+	}
+	if b.tlsConfig.ServerName != "svc.default.svc.cluster.local" {
+		t.Errorf("expected the server name to default to the forwardsTo host, got %q", b.tlsConfig.ServerName)
+	}
+	if len(b.tlsConfig.NextProtos) != 2 || b.tlsConfig.NextProtos[0] != "h2" {
+		t.Errorf("expected h2 to be negotiated, got %v", b.tlsConfig.NextProtos)
+	}
+
+	cfg := &tls.Config{ServerName: "backend.example.com"}
+	b = newBackend(spec, cfg)
+	if b.tlsConfig.InsecureSkipVerify || b.tlsConfig.ServerName != "backend.example.com" {
+		t.Errorf("expected the provided TLS config to be used, got %+v", b.tlsConfig)
+	}
+	if cfg.NextProtos != nil {
+		t.Errorf("expected the provided TLS config not to be modified")
+	}
+
+	spec.BackendConfig.Protocol = "HTTP"
+	if b := newBackend(spec, cfg); b.tlsConfig != nil {
+		t.Errorf("expected no TLS for HTTP backends")
+	}
+}
+
+// fakeSession is a session that only supports Listen and Close
+type fakeSession struct {
+	ngrok.Session
+	listen func() (ngrok.Tunnel, error)
+	close  func() error
+}
+
+func (s *fakeSession) Listen(context.Context, config.Tunnel) (ngrok.Tunnel, error) {
+	return s.listen()
+}
+
+func (s *fakeSession) Close() error {
+	return s.close()
+}
+
+func TestClosedTunnelIsRestarted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+	closedTun := mocks.NewMockTunnel(ctrl)
+	newTun := mocks.NewMockTunnel(ctrl)
+
+	closedTun.EXPECT().ID().Return("tn_closed").AnyTimes()
+	closedTun.EXPECT().Accept().Return(nil, errors.New("Tunnel closed"))
+	newTun.EXPECT().ID().Return("tn_new").AnyTimes()
+	accepting := make(chan struct{})
+	newTun.EXPECT().Accept().DoAndReturn(func() (net.Conn, error) {
+		close(accepting)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	td := &TunnelDriver{
+		session: &fakeSession{listen: func() (ngrok.Tunnel, error) { return newTun, nil }},
+		health:  &sessionHealth{connected: true},
+		tunnels: map[string]*tunnelState{},
+	}
+	served := newServedTunnel(closedTun)
+	state := &tunnelState{tun: served, online: true}
+	state.backend.Store(&backend{dest: "target:port"})
+	td.tunnels["default/tunnel"] = state
+
+	go td.serve(ctx, "default/tunnel", state, served)
+
+	select {
+	case <-accepting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the closed tunnel to be restarted")
+	}
+	status, _ := td.TunnelStatus("default/tunnel")
+	if status.TunnelID != "tn_new" || !status.Online {
+		t.Errorf("expected the restarted tunnel to be online, got %+v", status)
+	}
+}
+
+func TestDeletedTunnelIsNotRestarted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tun := mocks.NewMockTunnel(ctrl)
+	tun.EXPECT().ID().Return("tn_123").AnyTimes()
+	tun.EXPECT().Accept().Return(nil, errors.New("Tunnel closed"))
+
+	td := &TunnelDriver{
+		session: &fakeSession{listen: func() (ngrok.Tunnel, error) {
+			t.Error("expected a deleted tunnel not to be restarted")
+			return nil, errors.New("unexpected listen")
+		}},
+		tunnels: map[string]*tunnelState{},
+	}
+
+	// serve returns right away for a tunnel the driver isn't running
+	served := newServedTunnel(tun)
+	td.serve(context.Background(), "default/tunnel", &tunnelState{tun: served}, served)
+}
+
+func TestSessionHealth(t *testing.T) {
+	td := &TunnelDriver{health: &sessionHealth{}}
+	if err := td.Ready(nil); err == nil {
+		t.Error("expected a session that hasn't connected not to be ready")
+	}
+
+	td.health.setConnected()
+	if err := td.Ready(nil); err != nil {
+		t.Errorf("expected a connected session to be ready, got %v", err)
+	}
+
+	td.health.setDisconnected(errors.New("connection reset"))
+	if err := td.Ready(nil); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expected the disconnect error, got %v", err)
+	}
+
+	// The pod is only restarted once the session has been disconnected for a while
+	if err := td.Live(nil); err != nil {
+		t.Errorf("expected a recently disconnected session to be live, got %v", err)
+	}
+	td.health.disconnectedAt = time.Now().Add(-sessionLivenessTimeout - time.Minute)
+	if err := td.Live(nil); err == nil {
+		t.Error("expected a session disconnected for too long not to be live")
+	}
+	td.health.setConnected()
+	if err := td.Live(nil); err != nil {
+		t.Errorf("expected a reconnected session to be live, got %v", err)
+	}
+}
+
+func TestReadAuthtokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "AUTHTOKEN")
+	if err := os.WriteFile(path, []byte("token-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	td := &TunnelDriver{opts: TunnelDriverOpts{AuthtokenFile: path}}
+	token, err := td.readAuthtoken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" {
+		t.Errorf("expected the authtoken to be trimmed, got %q", token)
+	}
+
+	// A rotation that can't be applied isn't retried until the authtoken changes again
+	td.authtoken = "token-0"
+	td.failedAuthtoken = "token-1"
+	td.checkAuthtoken(context.Background())
+	if td.authtoken != "token-0" {
+		t.Errorf("expected the session to be kept, got authtoken %q", td.authtoken)
+	}
+}
+
+func TestCreateTunnelsConcurrently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+
+	// The first tunnel doesn't finish starting until the second one has started
+	secondStarted := make(chan struct{})
+	var listens atomic.Int32
+	td := &TunnelDriver{
+		session: &fakeSession{listen: func() (ngrok.Tunnel, error) {
+			tun := mocks.NewMockTunnel(ctrl)
+			tun.EXPECT().ID().Return("tn_123").AnyTimes()
+			tun.EXPECT().Accept().DoAndReturn(func() (net.Conn, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).AnyTimes()
+
+			if listens.Add(1) == 1 {
+				select {
+				case <-secondStarted:
+				case <-time.After(5 * time.Second):
+					return nil, errors.New("timed out waiting for the second tunnel")
+				}
+			} else {
+				close(secondStarted)
+			}
+			return tun, nil
+		}},
+		health:  &sessionHealth{connected: true},
+		tunnels: map[string]*tunnelState{},
+	}
+
+	var g errgroup.Group
+	for _, name := range []string{"default/first", "default/second"} {
+		name := name
+		g.Go(func() error {
+			return td.CreateTunnel(ctx, name, ingressv1alpha1.TunnelSpec{ForwardsTo: name}, nil)
+		})
+		// Make sure the first tunnel is the one that waits
+		for name == "default/first" && listens.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"default/first", "default/second"} {
+		if _, ok := td.TunnelStatus(name); !ok {
+			t.Errorf("expected %s to be running", name)
+		}
+		td.tunnels[name].stats.metrics.delete()
+	}
+}
+
+func TestFailedTunnelHasNoMetrics(t *testing.T) {
+	td := &TunnelDriver{
+		session: &fakeSession{listen: func() (ngrok.Tunnel, error) {
+			return nil, errors.New("failed to start tunnel")
+		}},
+		health:  &sessionHealth{connected: true},
+		tunnels: map[string]*tunnelState{},
+	}
+
+	spec := ingressv1alpha1.TunnelSpec{ForwardsTo: "example.default.svc.cluster.local:80"}
+	if err := td.CreateTunnel(context.Background(), "default/failed", spec, nil); err == nil {
+		t.Fatal("expected an error when the tunnel fails to start")
+	}
+	if _, ok := td.TunnelStatus("default/failed"); ok {
+		t.Error("expected the failed tunnel not to be running")
+	}
+	if connectionsAccepted.DeleteLabelValues("default", "failed", "", "") {
+		t.Error("expected no series for the failed tunnel")
+	}
 }
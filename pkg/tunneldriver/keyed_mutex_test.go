@@ -0,0 +1,53 @@
+package tunneldriver
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var k keyedMutex
+	unlockA := k.lock("a")
+
+	// Another key isn't blocked by a
+	done := make(chan struct{})
+	go func() {
+		k.lock("b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected locking b not to wait on a")
+	}
+
+	// The same key is
+	locked := make(chan struct{})
+	go func() {
+		unlock := k.lock("a")
+		close(locked)
+		unlock()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("expected a to stay locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-locked
+
+	// Mutexes are removed once they're released
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.lock("a")()
+		}()
+	}
+	wg.Wait()
+	if len(k.locks) != 0 {
+		t.Errorf("expected no mutexes to be left, got %d", len(k.locks))
+	}
+}
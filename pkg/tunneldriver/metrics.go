@@ -0,0 +1,132 @@
+package tunneldriver
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "ngrok"
+	metricsSubsystem = "tunnel"
+)
+
+// Labels of the tunnel metrics. service and port are taken from the tunnel's ngrok labels, so they're empty
+// for tunnels that weren't created for a Service.
+var tunnelMetricLabels = []string{"namespace", "tunnel", "service", "port"}
+
+var (
+	connectionsAccepted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "connections_accepted_total",
+		Help:      "Number of connections accepted from ngrok",
+	}, tunnelMetricLabels)
+
+	connectionsActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "connections_active",
+		Help:      "Number of connections currently being forwarded to the backend",
+	}, tunnelMetricLabels)
+
+	connectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "connection_duration_seconds",
+		Help:      "How long connections stay open, from being accepted until both sides are closed",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, tunnelMetricLabels)
+
+	bytesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "received_bytes_total",
+		Help:      "Number of bytes received from clients and forwarded to the backend",
+	}, tunnelMetricLabels)
+
+	bytesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "sent_bytes_total",
+		Help:      "Number of bytes received from the backend and sent to clients",
+	}, tunnelMetricLabels)
+
+	backendDialDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "backend_dial_duration_seconds",
+		Help:      "How long it takes to connect to the backend, including failed attempts",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, tunnelMetricLabels)
+
+	backendDialErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "backend_dial_errors_total",
+		Help:      "Number of failed attempts to connect to the backend",
+	}, tunnelMetricLabels)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		connectionsAccepted,
+		connectionsActive,
+		connectionDuration,
+		bytesReceived,
+		bytesSent,
+		backendDialDuration,
+		backendDialErrors,
+	)
+}
+
+// tunnelMetrics are the metrics of a single tunnel
+type tunnelMetrics struct {
+	labels prometheus.Labels
+
+	accepted     prometheus.Counter
+	active       prometheus.Gauge
+	duration     prometheus.Observer
+	received     prometheus.Counter
+	sent         prometheus.Counter
+	dialDuration prometheus.Observer
+	dialErrors   prometheus.Counter
+}
+
+// newTunnelMetrics returns the metrics for the tunnel with the given name, in the form namespace/name, and
+// ngrok labels
+func newTunnelMetrics(name string, ngrokLabels map[string]string) *tunnelMetrics {
+	namespace, tunnelName, found := strings.Cut(name, "/")
+	if !found {
+		namespace, tunnelName = "", name
+	}
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"tunnel":    tunnelName,
+		"service":   ngrokLabels["k8s.ngrok.com/service"],
+		"port":      ngrokLabels["k8s.ngrok.com/port"],
+	}
+
+	return &tunnelMetrics{
+		labels:       labels,
+		accepted:     connectionsAccepted.With(labels),
+		active:       connectionsActive.With(labels),
+		duration:     connectionDuration.With(labels),
+		received:     bytesReceived.With(labels),
+		sent:         bytesSent.With(labels),
+		dialDuration: backendDialDuration.With(labels),
+		dialErrors:   backendDialErrors.With(labels),
+	}
+}
+
+// delete removes the tunnel's series so deleted tunnels don't keep reporting stale values
+func (m *tunnelMetrics) delete() {
+	connectionsAccepted.Delete(m.labels)
+	connectionsActive.Delete(m.labels)
+	connectionDuration.Delete(m.labels)
+	bytesReceived.Delete(m.labels)
+	bytesSent.Delete(m.labels)
+	backendDialDuration.Delete(m.labels)
+	backendDialErrors.Delete(m.labels)
+}
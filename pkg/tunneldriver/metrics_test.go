@@ -0,0 +1,53 @@
+package tunneldriver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ngrok/kubernetes-ingress-controller/internal/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTunnelMetrics(t *testing.T) {
+	m := newTunnelMetrics("default/example-80-abc", map[string]string{
+		"k8s.ngrok.com/service": "example",
+		"k8s.ngrok.com/port":    "80",
+	})
+	defer m.delete()
+	stats := &tunnelStats{metrics: m}
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mocks.NewMockDialer(ctrl)
+	mockDialer.EXPECT().DialContext(gomock.Any(), "tcp", "target:port").Return(nil, errors.New("connection refused"))
+
+	stats.connOpened()
+	_ = handleConn(context.Background(), stats, &backend{dest: "target:port"}, mockDialer, mocks.NewMockConn(ctrl))
+	stats.connClosed(time.Second)
+
+	if v := testutil.ToFloat64(connectionsAccepted.WithLabelValues("default", "example-80-abc", "example", "80")); v != 1 {
+		t.Errorf("expected 1 accepted connection, got %v", v)
+	}
+	if v := testutil.ToFloat64(connectionsActive.WithLabelValues("default", "example-80-abc", "example", "80")); v != 0 {
+		t.Errorf("expected no active connections, got %v", v)
+	}
+	if v := testutil.ToFloat64(backendDialErrors.WithLabelValues("default", "example-80-abc", "example", "80")); v != 1 {
+		t.Errorf("expected 1 dial error, got %v", v)
+	}
+
+	var buf bytes.Buffer
+	if _, err := stats.countSent(&buf).Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if v := testutil.ToFloat64(bytesSent.WithLabelValues("default", "example-80-abc", "example", "80")); v != 5 {
+		t.Errorf("expected 5 bytes sent, got %v", v)
+	}
+
+	m.delete()
+	if n := testutil.CollectAndCount(connectionsAccepted); n != 0 {
+		t.Errorf("expected deleted tunnel series to be removed, found %d", n)
+	}
+}
@@ -0,0 +1,82 @@
+package tunneldriver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// proxyProtoV2Signature starts every PROXY protocol v2 header
+var proxyProtoV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyProtoHeader returns a PROXY protocol header of the given version for a connection from src to dst. See
+// https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt. If src isn't a TCP address, the header doesn't
+// carry any addresses and backends fall back to the address of the connection itself. The PROXY protocol
+// requires both addresses to be of the same family, so dst is replaced by the unspecified address of src's
+// family when they differ.
+func proxyProtoHeader(version int, src, dst net.Addr) ([]byte, error) {
+	srcAddr, dstAddr := proxyProtoAddrs(src, dst)
+
+	switch version {
+	case 1:
+		if srcAddr == nil {
+			return []byte("PROXY UNKNOWN\r\n"), nil
+		}
+		family := "TCP4"
+		if srcAddr.IP.To4() == nil {
+			family = "TCP6"
+		}
+		return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", family, srcAddr.IP, dstAddr.IP, srcAddr.Port, dstAddr.Port)), nil
+	case 2:
+		var buf bytes.Buffer
+		buf.Write(proxyProtoV2Signature)
+		// Version 2, PROXY command
+		buf.WriteByte(0x21)
+
+		var addrs []byte
+		switch {
+		case srcAddr == nil:
+			// AF_UNSPEC
+			buf.WriteByte(0x00)
+		case srcAddr.IP.To4() != nil:
+			// AF_INET, STREAM
+			buf.WriteByte(0x11)
+			addrs = append(addrs, srcAddr.IP.To4()...)
+			addrs = append(addrs, dstAddr.IP.To4()...)
+		default:
+			// AF_INET6, STREAM
+			buf.WriteByte(0x21)
+			addrs = append(addrs, srcAddr.IP.To16()...)
+			addrs = append(addrs, dstAddr.IP.To16()...)
+		}
+		if srcAddr != nil {
+			addrs = binary.BigEndian.AppendUint16(addrs, uint16(srcAddr.Port))
+			addrs = binary.BigEndian.AppendUint16(addrs, uint16(dstAddr.Port))
+		}
+
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(addrs)))
+		buf.Write(addrs)
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol version %d, must be 1 or 2", version)
+	}
+}
+
+// proxyProtoAddrs converts src and dst to TCP addresses of the same family. It returns nil addresses if src
+// isn't a TCP address.
+func proxyProtoAddrs(src, dst net.Addr) (*net.TCPAddr, *net.TCPAddr) {
+	srcAddr, ok := src.(*net.TCPAddr)
+	if !ok || srcAddr.IP == nil {
+		return nil, nil
+	}
+
+	dstAddr, ok := dst.(*net.TCPAddr)
+	if !ok || dstAddr.IP == nil || (srcAddr.IP.To4() == nil) != (dstAddr.IP.To4() == nil) {
+		dstAddr = &net.TCPAddr{IP: net.IPv4zero}
+		if srcAddr.IP.To4() == nil {
+			dstAddr.IP = net.IPv6zero
+		}
+	}
+	return srcAddr, dstAddr
+}
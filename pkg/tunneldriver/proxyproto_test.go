@@ -0,0 +1,70 @@
+package tunneldriver
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestProxyProtoHeaderV1(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5432}
+
+	header, err := proxyProtoHeader(1, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(header) != "PROXY TCP4 203.0.113.7 10.0.0.1 51234 5432\r\n" {
+		t.Errorf("unexpected header %q", header)
+	}
+
+	// Mixed families use the unspecified address of the client's family for the destination
+	src = &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 51234}
+	header, err = proxyProtoHeader(1, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(header) != "PROXY TCP6 2001:db8::1 :: 51234 0\r\n" {
+		t.Errorf("unexpected header %q", header)
+	}
+
+	header, err = proxyProtoHeader(1, &net.UnixAddr{Name: "sock"}, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(header) != "PROXY UNKNOWN\r\n" {
+		t.Errorf("unexpected header %q", header)
+	}
+}
+
+func TestProxyProtoHeaderV2(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5432}
+
+	header, err := proxyProtoHeader(2, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte("\r\n\r\n\x00\r\nQUIT\n"),
+		0x21, 0x11, 0x00, 0x0c, // v2 PROXY, TCP over IPv4, 12 bytes of addresses
+		203, 0, 113, 7, // source address
+		10, 0, 0, 1, // destination address
+		0xc8, 0x22, // source port
+		0x15, 0x38, // destination port
+	)
+	if !bytes.Equal(header, expected) {
+		t.Errorf("unexpected header %x", header)
+	}
+
+	header, err = proxyProtoHeader(2, &net.UnixAddr{Name: "sock"}, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(header[12:], []byte{0x21, 0x00, 0x00, 0x00}) {
+		t.Errorf("expected an AF_UNSPEC header, got %x", header)
+	}
+
+	if _, err := proxyProtoHeader(3, src, dst); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
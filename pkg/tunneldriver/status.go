@@ -0,0 +1,111 @@
+package tunneldriver
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TunnelStatus is a snapshot of the state of a tunnel run by the driver
+type TunnelStatus struct {
+	// TunnelID is the ngrok ID of the tunnel
+	TunnelID string
+	// Online is whether the tunnel is accepting connections, which requires the session to be connected
+	Online bool
+	// ForwardsToAddr is the backend address the last successful dial connected to
+	ForwardsToAddr string
+	// LastDialError is the last error returned dialing the backend, if any
+	LastDialError string
+	// LastDialErrorTime is when LastDialError happened
+	LastDialErrorTime time.Time
+}
+
+// tunnelStats tracks the backend dials of a tunnel for its status, and its connections for its metrics. Stats
+// are kept per tunnel name and carried over when a tunnel is replaced, so connections still draining from the
+// old tunnel are counted until they close.
+type tunnelStats struct {
+	// metrics is nil when the tunnel's metrics aren't reported
+	metrics *tunnelMetrics
+
+	mu                sync.Mutex
+	forwardsToAddr    string
+	lastDialError     string
+	lastDialErrorTime time.Time
+}
+
+func (s *tunnelStats) connOpened() {
+	if s.metrics != nil {
+		s.metrics.accepted.Inc()
+		s.metrics.active.Inc()
+	}
+}
+
+func (s *tunnelStats) connClosed(duration time.Duration) {
+	if s.metrics != nil {
+		s.metrics.active.Dec()
+		s.metrics.duration.Observe(duration.Seconds())
+	}
+}
+
+func (s *tunnelStats) dialSucceeded(addr string, duration time.Duration) {
+	if s.metrics != nil {
+		s.metrics.dialDuration.Observe(duration.Seconds())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forwardsToAddr = addr
+}
+
+func (s *tunnelStats) dialFailed(err error, duration time.Duration) {
+	if s.metrics != nil {
+		s.metrics.dialDuration.Observe(duration.Seconds())
+		s.metrics.dialErrors.Inc()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastDialError = err.Error()
+	s.lastDialErrorTime = time.Now()
+}
+
+// countReceived wraps w, the connection to the backend, to count the bytes received from the client
+func (s *tunnelStats) countReceived(w io.Writer) io.Writer {
+	if s.metrics == nil {
+		return w
+	}
+	return byteCounter{w, s.metrics.received}
+}
+
+// countSent wraps w, the connection from ngrok, to count the bytes sent to the client
+func (s *tunnelStats) countSent(w io.Writer) io.Writer {
+	if s.metrics == nil {
+		return w
+	}
+	return byteCounter{w, s.metrics.sent}
+}
+
+func (s *tunnelStats) snapshot(tunnelID string) TunnelStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return TunnelStatus{
+		TunnelID:          tunnelID,
+		ForwardsToAddr:    s.forwardsToAddr,
+		LastDialError:     s.lastDialError,
+		LastDialErrorTime: s.lastDialErrorTime,
+	}
+}
+
+// byteCounter counts the bytes written through it
+type byteCounter struct {
+	io.Writer
+	counter prometheus.Counter
+}
+
+func (w byteCounter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.counter.Add(float64(n))
+	return n, err
+}